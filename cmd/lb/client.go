@@ -0,0 +1,189 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/nhutphuongasasa/loadbalancer/internal/registry/provider"
+	"github.com/spf13/cobra"
+)
+
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+)
+
+/*
+*Thong tin ket noi toi balancer dang chay, dung chung cho cac lenh client
+ */
+type clientOptions struct {
+	adminURL      string
+	adminToken    string
+	registryURL   string
+	registryToken string // registry.auth.admin_token, dung cho lenh weight
+	apiKey        string
+	hmacSecret    string
+	output        string
+	timeout       time.Duration
+}
+
+func (o *clientOptions) addFlags(cmd *cobra.Command) {
+	f := cmd.Flags()
+	f.StringVar(&o.adminURL, "admin-url", envOr("LB_ADMIN_URL", "http://127.0.0.1:9090"), "admin API url, unix:///path/to.sock for a unix socket")
+	f.StringVar(&o.adminToken, "admin-token", os.Getenv("LB_ADMIN_TOKEN"), "bearer token for the admin API")
+	f.StringVar(&o.registryURL, "registry-url", envOr("LB_REGISTRY_URL", "http://127.0.0.1:8000"), "registry API url")
+	f.StringVar(&o.registryToken, "registry-token", os.Getenv("LB_REGISTRY_TOKEN"), "registry admin token (registry.auth.admin_token)")
+	f.StringVar(&o.apiKey, "api-key", os.Getenv("LB_API_KEY"), "registry API key (auth mode api_key)")
+	f.StringVar(&o.hmacSecret, "hmac-secret", os.Getenv("LB_HMAC_SECRET"), "registry shared secret (auth mode hmac)")
+	f.StringVarP(&o.output, "output", "o", outputTable, "output format: table or json")
+	f.DurationVar(&o.timeout, "timeout", 10*time.Second, "request timeout")
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+type apiClient struct {
+	base  string
+	http  *http.Client
+	sign  func(req *http.Request, body []byte)
+	token string
+}
+
+/*
+*Client cho admin API, ho tro ca TCP va unix socket
+ */
+func (o *clientOptions) adminClient() *apiClient {
+	c := &apiClient{
+		base:  strings.TrimRight(o.adminURL, "/"),
+		http:  &http.Client{Timeout: o.timeout},
+		token: o.adminToken,
+	}
+
+	if socket, ok := strings.CutPrefix(o.adminURL, "unix://"); ok {
+		c.base = "http://admin"
+		c.http.Transport = &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", socket)
+			},
+		}
+	}
+
+	return c
+}
+
+/*
+*Client cho registry API, ky request theo che do xac thuc da cau hinh
+ */
+func (o *clientOptions) registryClient() *apiClient {
+	return &apiClient{
+		base:  strings.TrimRight(o.registryURL, "/"),
+		http:  &http.Client{Timeout: o.timeout},
+		token: o.registryToken,
+		sign: func(req *http.Request, body []byte) {
+			if o.apiKey != "" {
+				req.Header.Set(provider.HeaderAPIKey, o.apiKey)
+			}
+			if o.hmacSecret != "" {
+				ts := strconv.FormatInt(time.Now().Unix(), 10)
+				req.Header.Set(provider.HeaderTimestamp, ts)
+				req.Header.Set(provider.HeaderSignature, provider.SignRequest([]byte(o.hmacSecret), ts, req.Method, req.URL.Path, body))
+			}
+		},
+	}
+}
+
+/*
+*Gui request va giai ma JSON vao out, loi API duoc chuyen thanh error doc duoc
+ */
+func (c *apiClient) do(ctx context.Context, method, path string, in, out any) error {
+	var body []byte
+	if in != nil {
+		var err error
+		if body, err = json.Marshal(in); err != nil {
+			return err
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.base+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	if c.sign != nil {
+		c.sign(req, body)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode >= 400 {
+		var apiErr struct {
+			Code    string `json:"error"`
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(data, &apiErr) == nil && apiErr.Code != "" {
+			return fmt.Errorf("%s %s: %s: %s (HTTP %d)", method, path, apiErr.Code, apiErr.Message, resp.StatusCode)
+		}
+		return fmt.Errorf("%s %s: HTTP %d: %s", method, path, resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+
+	if out == nil || len(data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(data, out)
+}
+
+/*
+*In ket qua theo --output: json in nguyen response, table giai ma vao v roi goi ham render
+ */
+func (o *clientOptions) render(cmd *cobra.Command, raw json.RawMessage, v any, table func(w *tabwriter.Writer)) error {
+	out := cmd.OutOrStdout()
+
+	switch o.output {
+	case outputJSON:
+		var buf bytes.Buffer
+		if err := json.Indent(&buf, raw, "", "  "); err != nil {
+			return err
+		}
+		buf.WriteByte('\n')
+		_, err := buf.WriteTo(out)
+		return err
+	case outputTable:
+		if err := json.Unmarshal(raw, v); err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+		table(w)
+		return w.Flush()
+	}
+
+	return fmt.Errorf("unknown output format %q (expected table or json)", o.output)
+}
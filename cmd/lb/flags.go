@@ -0,0 +1,27 @@
+package main
+
+import (
+	"log/slog"
+
+	"github.com/nhutphuongasasa/loadbalancer/internal/config"
+	"github.com/spf13/cobra"
+)
+
+/*
+*Flag --config dung chung cho cac lenh can doc config.yml/routing.yml
+ */
+func addConfigFlag(cmd *cobra.Command, path *string) {
+	cmd.Flags().StringVarP(path, "config", "c", "",
+		"config directory (config.yml + routing.yml) or a single combined config file; "+
+			"defaults to $"+config.EnvConfigDir+", $XDG_CONFIG_HOME/lb, /etc/lb, ./config")
+}
+
+func discoverConfig(path string) (*config.Location, error) {
+	loc, err := config.Discover(path)
+	if err != nil {
+		return nil, err
+	}
+
+	slog.Debug("Using configuration", "config", loc.ConfigFile, "routing", loc.RoutingFile, "source", loc.Source)
+	return loc, nil
+}
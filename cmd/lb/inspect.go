@@ -0,0 +1,183 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+type breakerRow struct {
+	Name                string `json:"name"`
+	State               string `json:"state"`
+	Requests            uint32 `json:"requests"`
+	TotalFailures       uint32 `json:"totalFailures"`
+	ConsecutiveFailures uint32 `json:"consecutiveFailures"`
+}
+
+type certificateRow struct {
+	Subject  string    `json:"subject"`
+	DNSNames []string  `json:"dnsNames"`
+	Source   string    `json:"source"`
+	NotAfter time.Time `json:"notAfter"`
+	DaysLeft int       `json:"daysLeft"`
+	Status   string    `json:"status"`
+	Default  bool      `json:"default"`
+}
+
+type instanceRow struct {
+	ServiceName    string      `json:"serviceName"`
+	InstanceID     string      `json:"instanceID"`
+	Host           string      `json:"host"`
+	Port           int         `json:"port"`
+	Weight         int         `json:"weight"`
+	State          string      `json:"state"`
+	ActiveConns    int32       `json:"activeConns"`
+	CircuitBreaker *breakerRow `json:"circuitBreaker"`
+}
+
+type serviceRow struct {
+	Name      string         `json:"name"`
+	Total     int            `json:"total"`
+	Available int            `json:"available"`
+	Instances []*instanceRow `json:"instances"`
+}
+
+func newStatusCmd(o *clientOptions) *cobra.Command {
+	return &cobra.Command{
+		Use:   "status",
+		Short: "Show overall status of a running balancer",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var raw json.RawMessage
+			if err := o.adminClient().do(cmd.Context(), "GET", "/v1/status", nil, &raw); err != nil {
+				return err
+			}
+
+			var st struct {
+				Build struct {
+					Version string `json:"version"`
+					Commit  string `json:"commit"`
+				} `json:"build"`
+				Uptime    string `json:"uptime"`
+				Services  int    `json:"services"`
+				Instances int    `json:"instances"`
+				Available int    `json:"available"`
+			}
+			return o.render(cmd, raw, &st, func(w *tabwriter.Writer) {
+				fmt.Fprintf(w, "VERSION\t%s\n", st.Build.Version)
+				if st.Build.Commit != "" {
+					fmt.Fprintf(w, "COMMIT\t%s\n", st.Build.Commit)
+				}
+				fmt.Fprintf(w, "UPTIME\t%s\n", st.Uptime)
+				fmt.Fprintf(w, "SERVICES\t%d\n", st.Services)
+				fmt.Fprintf(w, "INSTANCES\t%d (%d available)\n", st.Instances, st.Available)
+			})
+		},
+	}
+}
+
+func newServicesCmd(o *clientOptions) *cobra.Command {
+	return &cobra.Command{
+		Use:   "services",
+		Short: "List registered services",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var raw json.RawMessage
+			if err := o.adminClient().do(cmd.Context(), "GET", "/v1/services", nil, &raw); err != nil {
+				return err
+			}
+
+			var services []*serviceRow
+			return o.render(cmd, raw, &services, func(w *tabwriter.Writer) {
+				fmt.Fprintln(w, "SERVICE\tINSTANCES\tAVAILABLE")
+				for _, svc := range services {
+					fmt.Fprintf(w, "%s\t%d\t%d\n", svc.Name, svc.Total, svc.Available)
+				}
+			})
+		},
+	}
+}
+
+func newInstancesCmd(o *clientOptions) *cobra.Command {
+	return &cobra.Command{
+		Use:   "instances <service>",
+		Short: "List instances of a service",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var raw json.RawMessage
+			path := "/v1/services?service=" + url.QueryEscape(args[0])
+			if err := o.adminClient().do(cmd.Context(), "GET", path, nil, &raw); err != nil {
+				return err
+			}
+
+			var services []*serviceRow
+			return o.render(cmd, raw, &services, func(w *tabwriter.Writer) {
+				fmt.Fprintln(w, "INSTANCE\tADDRESS\tWEIGHT\tSTATE\tCONNS\tBREAKER")
+				for _, svc := range services {
+					for _, inst := range svc.Instances {
+						breaker := "-"
+						if inst.CircuitBreaker != nil {
+							breaker = inst.CircuitBreaker.State
+						}
+						fmt.Fprintf(w, "%s\t%s:%d\t%d\t%s\t%d\t%s\n",
+							inst.InstanceID, inst.Host, inst.Port, inst.Weight, inst.State, inst.ActiveConns, breaker)
+					}
+				}
+			})
+		},
+	}
+}
+
+func newBreakersCmd(o *clientOptions) *cobra.Command {
+	return &cobra.Command{
+		Use:   "breakers",
+		Short: "List circuit breaker states of all instances",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var raw json.RawMessage
+			if err := o.adminClient().do(cmd.Context(), "GET", "/v1/circuit-breakers", nil, &raw); err != nil {
+				return err
+			}
+
+			var breakers []*breakerRow
+			return o.render(cmd, raw, &breakers, func(w *tabwriter.Writer) {
+				fmt.Fprintln(w, "NAME\tSTATE\tREQUESTS\tFAILURES\tCONSECUTIVE_FAILURES")
+				for _, cb := range breakers {
+					fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%d\n", cb.Name, cb.State, cb.Requests, cb.TotalFailures, cb.ConsecutiveFailures)
+				}
+			})
+		},
+	}
+}
+
+func newCertsCmd(o *clientOptions) *cobra.Command {
+	return &cobra.Command{
+		Use:   "certs",
+		Short: "List served TLS certificates and when they expire",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var raw json.RawMessage
+			if err := o.adminClient().do(cmd.Context(), "GET", "/v1/tls", nil, &raw); err != nil {
+				return err
+			}
+
+			var certs []*certificateRow
+			return o.render(cmd, raw, &certs, func(w *tabwriter.Writer) {
+				fmt.Fprintln(w, "SUBJECT\tNAMES\tSOURCE\tNOT_AFTER\tDAYS_LEFT\tSTATUS")
+				for _, c := range certs {
+					subject := c.Subject
+					if c.Default {
+						subject += " (default)"
+					}
+					fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%s\n", subject, strings.Join(c.DNSNames, ","), c.Source,
+						c.NotAfter.Format(time.RFC3339), c.DaysLeft, c.Status)
+				}
+			})
+		},
+	}
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/nhutphuongasasa/loadbalancer/internal/model"
+	"github.com/spf13/cobra"
+)
+
+func instancePath(service, id string) string {
+	return "/v1/instances/" + url.PathEscape(service) + "/" + url.PathEscape(id)
+}
+
+func newRegisterCmd(o *clientOptions) *cobra.Command {
+	input := &model.Input{}
+
+	cmd := &cobra.Command{
+		Use:   "register",
+		Short: "Register a backend instance with the registry",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var raw json.RawMessage
+			if err := o.registryClient().do(cmd.Context(), http.MethodPost, "/", input, &raw); err != nil {
+				return err
+			}
+
+			var resp struct {
+				InstanceID  string `json:"instanceID"`
+				ServiceName string `json:"serviceName"`
+				Host        string `json:"host"`
+				Port        int    `json:"port"`
+				Weight      int    `json:"weight"`
+			}
+			return o.render(cmd, raw, &resp, func(w *tabwriter.Writer) {
+				fmt.Fprintln(w, "SERVICE\tINSTANCE\tADDRESS\tWEIGHT")
+				fmt.Fprintf(w, "%s\t%s\t%s:%d\t%d\n", resp.ServiceName, resp.InstanceID, resp.Host, resp.Port, resp.Weight)
+			})
+		},
+	}
+
+	f := cmd.Flags()
+	f.StringVarP(&input.ServiceName, "service", "s", "", "service name (required)")
+	f.StringVar(&input.InstanceID, "id", "", "instance id (generated when empty)")
+	f.StringVar(&input.Host, "host", "", "instance host (defaults to the caller address)")
+	f.IntVar(&input.Port, "port", 0, "instance port (required)")
+	f.IntVar(&input.Weight, "weight", 0, "instance weight (default 10)")
+	f.StringToStringVar(&input.Metadata, "meta", nil, "metadata key=value, repeatable")
+	_ = cmd.MarkFlagRequired("service")
+	_ = cmd.MarkFlagRequired("port")
+
+	return cmd
+}
+
+func newDeregisterCmd(o *clientOptions) *cobra.Command {
+	return &cobra.Command{
+		Use:   "deregister <service> <instance>",
+		Short: "Remove an instance from the registry",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := o.registryClient().do(cmd.Context(), http.MethodDelete, instancePath(args[0], args[1]), nil, nil); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "instance %s/%s deregistered\n", args[0], args[1])
+			return nil
+		},
+	}
+}
+
+/*
+*Bang trang thai instance tra ve tu drain, resume va weight
+ */
+func (o *clientOptions) renderInstanceStatus(cmd *cobra.Command, raw json.RawMessage) error {
+	var st struct {
+		ServiceName string `json:"serviceName"`
+		InstanceID  string `json:"instanceID"`
+		Weight      int    `json:"weight"`
+		State       string `json:"state"`
+		ActiveConns int32  `json:"activeConns"`
+		SafeToStop  bool   `json:"safeToStop"`
+	}
+	return o.render(cmd, raw, &st, func(w *tabwriter.Writer) {
+		fmt.Fprintln(w, "SERVICE\tINSTANCE\tWEIGHT\tSTATE\tCONNS\tSAFE_TO_STOP")
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%d\t%t\n", st.ServiceName, st.InstanceID, st.Weight, st.State, st.ActiveConns, st.SafeToStop)
+	})
+}
+
+func newDrainCmd(o *clientOptions) *cobra.Command {
+	var resume bool
+
+	cmd := &cobra.Command{
+		Use:   "drain <service> <instance>",
+		Short: "Stop sending new traffic to an instance (--resume to undo)",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			method := http.MethodPost
+			if resume {
+				method = http.MethodDelete
+			}
+
+			var raw json.RawMessage
+			if err := o.registryClient().do(cmd.Context(), method, instancePath(args[0], args[1])+"/drain", nil, &raw); err != nil {
+				return err
+			}
+			return o.renderInstanceStatus(cmd, raw)
+		},
+	}
+
+	cmd.Flags().BoolVar(&resume, "resume", false, "put a draining instance back into rotation")
+	return cmd
+}
+
+func newWeightCmd(o *clientOptions) *cobra.Command {
+	var revertAfter time.Duration
+
+	cmd := &cobra.Command{
+		Use:   "weight <service> <instance> <weight>",
+		Short: "Change the weight of an instance at runtime",
+		Args:  cobra.ExactArgs(3),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			weight, err := strconv.Atoi(args[2])
+			if err != nil || weight < 0 {
+				return fmt.Errorf("weight must be a non-negative integer, got %q", args[2])
+			}
+
+			body := map[string]any{"weight": weight}
+			if revertAfter > 0 {
+				body["revertAfter"] = revertAfter.String()
+			}
+
+			var raw json.RawMessage
+			if err := o.registryClient().do(cmd.Context(), http.MethodPut, instancePath(args[0], args[1])+"/weight", body, &raw); err != nil {
+				return err
+			}
+			return o.renderInstanceStatus(cmd, raw)
+		},
+	}
+
+	cmd.Flags().DurationVar(&revertAfter, "revert-after", 0, "restore the previous weight after this duration")
+	return cmd
+}
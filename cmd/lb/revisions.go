@@ -0,0 +1,141 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+type revisionRow struct {
+	Number int       `json:"number"`
+	File   string    `json:"file"`
+	Kind   string    `json:"kind"`
+	Hash   string    `json:"hash"`
+	Size   int       `json:"size"`
+	Time   time.Time `json:"time"`
+	Source string    `json:"source"`
+}
+
+/*
+*Nhom lenh xem lich su, diff va rollback config cua balancer dang chay
+ */
+func newConfigCmd(o *clientOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Inspect and roll back config revisions of a running balancer",
+	}
+
+	subs := []*cobra.Command{
+		newRevisionsCmd(o),
+		newConfigDiffCmd(o),
+		newRollbackCmd(o),
+	}
+	for _, sub := range subs {
+		o.addFlags(sub)
+		cmd.AddCommand(sub)
+	}
+
+	return cmd
+}
+
+func newRevisionsCmd(o *clientOptions) *cobra.Command {
+	return &cobra.Command{
+		Use:   "revisions",
+		Short: "List recorded revisions of config.yml and routing.yml, newest first",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var raw json.RawMessage
+			if err := o.adminClient().do(cmd.Context(), http.MethodGet, "/v1/config/revisions", nil, &raw); err != nil {
+				return err
+			}
+
+			var revisions []*revisionRow
+			return o.render(cmd, raw, &revisions, func(w *tabwriter.Writer) {
+				fmt.Fprintln(w, "REV\tKIND\tFILE\tHASH\tTIME\tSOURCE")
+				for _, rev := range revisions {
+					fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\n",
+						rev.Number, rev.Kind, filepath.Base(rev.File), shortHash(rev.Hash),
+						rev.Time.Local().Format(time.DateTime), rev.Source)
+				}
+			})
+		},
+	}
+}
+
+func newConfigDiffCmd(o *clientOptions) *cobra.Command {
+	return &cobra.Command{
+		Use:   "diff <from> [to]",
+		Short: "Show a unified diff between two revisions (to defaults to the latest revision of the same file)",
+		Args:  cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			query := url.Values{"from": {args[0]}}
+			if len(args) == 2 {
+				query.Set("to", args[1])
+			}
+
+			var raw json.RawMessage
+			if err := o.adminClient().do(cmd.Context(), http.MethodGet, "/v1/config/diff?"+query.Encode(), nil, &raw); err != nil {
+				return err
+			}
+
+			if o.output == outputJSON {
+				return o.render(cmd, raw, nil, nil)
+			}
+
+			var resp struct {
+				From int    `json:"from"`
+				To   int    `json:"to"`
+				Diff string `json:"diff"`
+			}
+			if err := json.Unmarshal(raw, &resp); err != nil {
+				return err
+			}
+
+			if resp.Diff == "" {
+				fmt.Fprintf(cmd.OutOrStdout(), "revisions %d and %d are identical\n", resp.From, resp.To)
+				return nil
+			}
+			fmt.Fprint(cmd.OutOrStdout(), resp.Diff)
+			return nil
+		},
+	}
+}
+
+func newRollbackCmd(o *clientOptions) *cobra.Command {
+	return &cobra.Command{
+		Use:   "rollback <revision>",
+		Short: "Write a previous revision back to disk and apply it through the normal reload path",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if _, err := strconv.Atoi(args[0]); err != nil {
+				return fmt.Errorf("revision must be a number: %q", args[0])
+			}
+
+			var raw json.RawMessage
+			path := "/v1/config/revisions/" + args[0] + "/rollback"
+			if err := o.adminClient().do(cmd.Context(), http.MethodPost, path, nil, &raw); err != nil {
+				return err
+			}
+
+			var rev revisionRow
+			return o.render(cmd, raw, &rev, func(w *tabwriter.Writer) {
+				fmt.Fprintf(w, "Rolled back %s to revision %s (now revision %d, %s)\n",
+					filepath.Base(rev.File), args[0], rev.Number, shortHash(rev.Hash))
+			})
+		},
+	}
+}
+
+func shortHash(hash string) string {
+	if len(hash) > 12 {
+		return hash[:12]
+	}
+	return hash
+}
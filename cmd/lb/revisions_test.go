@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nhutphuongasasa/loadbalancer/internal/admin"
+	"github.com/nhutphuongasasa/loadbalancer/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+/*
+*Admin API that voi history cua 1 file tam co 2 revision
+ */
+func newRevisionsAdmin(t *testing.T) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "config.yml")
+	require.NoError(t, os.WriteFile(path, []byte("server:\n  port: 8080\n"), 0644))
+
+	h := config.NewHistory(0)
+	require.NoError(t, h.Track(path, "config", func() error {
+		_, err := h.RecordFile(path, "config", config.SourceFile)
+		return err
+	}))
+	require.NoError(t, os.WriteFile(path, []byte("server:\n  port: 8081\n"), 0644))
+	_, err := h.RecordFile(path, "config", config.SourceFile)
+	require.NoError(t, err)
+
+	srv := httptest.NewServer(admin.NewServer(nil, admin.WithConfigHistory(h), admin.WithToken("s3cret")).Handler())
+	t.Cleanup(srv.Close)
+	return srv.URL
+}
+
+func runLB(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+
+	var out bytes.Buffer
+	cmd := newRootCmd()
+	cmd.SetOut(&out)
+	cmd.SetErr(&bytes.Buffer{})
+	cmd.SetArgs(args)
+	err := cmd.Execute()
+	return out.String(), err
+}
+
+func TestConfigRevisionsCmd(t *testing.T) {
+	url := newRevisionsAdmin(t)
+
+	out, err := runLB(t, "config", "revisions", "--admin-url", url, "--admin-token", "s3cret")
+	require.NoError(t, err)
+	lines := bytes.Split(bytes.TrimSpace([]byte(out)), []byte("\n"))
+	require.Len(t, lines, 3)
+	assert.Contains(t, string(lines[0]), "REV")
+	assert.Contains(t, string(lines[1]), "config.yml")
+	assert.Contains(t, string(lines[1]), config.SourceFile)
+	assert.Contains(t, string(lines[2]), config.SourceStartup)
+
+	out, err = runLB(t, "config", "revisions", "--admin-url", url, "--admin-token", "s3cret", "-o", "json")
+	require.NoError(t, err)
+	var revisions []revisionRow
+	require.NoError(t, json.Unmarshal([]byte(out), &revisions))
+	require.Len(t, revisions, 2)
+	assert.Equal(t, 2, revisions[0].Number)
+
+	_, err = runLB(t, "config", "revisions", "--admin-url", url)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unauthorized")
+}
+
+func TestConfigDiffAndRollbackCmd(t *testing.T) {
+	url := newRevisionsAdmin(t)
+
+	out, err := runLB(t, "config", "diff", "1", "--admin-url", url, "--admin-token", "s3cret")
+	require.NoError(t, err)
+	assert.Contains(t, out, "-  port: 8080\n+  port: 8081\n")
+
+	out, err = runLB(t, "config", "diff", "2", "2", "--admin-url", url, "--admin-token", "s3cret")
+	require.NoError(t, err)
+	assert.Equal(t, "revisions 2 and 2 are identical\n", out)
+
+	_, err = runLB(t, "config", "rollback", "latest", "--admin-url", url, "--admin-token", "s3cret")
+	assert.ErrorContains(t, err, "revision must be a number")
+
+	out, err = runLB(t, "config", "rollback", "1", "--admin-url", url, "--admin-token", "s3cret")
+	require.NoError(t, err)
+	assert.Contains(t, out, "Rolled back config.yml to revision 1 (now revision 3")
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/nhutphuongasasa/loadbalancer/internal/router"
+	"github.com/spf13/cobra"
+)
+
+func newRoutesCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "routes",
+		Short: "Inspect routing rules",
+	}
+
+	cmd.AddCommand(newRoutesTestCmd())
+	return cmd
+}
+
+func newRoutesTestCmd() *cobra.Command {
+	var configPath string
+
+	cmd := &cobra.Command{
+		Use:   "test <url>",
+		Short: "Show which rule and service a URL is routed to",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runRoutesTest(cmd, configPath, args[0])
+		},
+	}
+
+	addConfigFlag(cmd, &configPath)
+	return cmd
+}
+
+func runRoutesTest(cmd *cobra.Command, configPath, rawURL string) error {
+	loc, err := discoverConfig(configPath)
+	if err != nil {
+		return err
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("invalid url: %w", err)
+	}
+
+	path := u.Path
+	if path == "" {
+		path = "/"
+	}
+
+	// log cua router khong can thiet cho lenh nay
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	rt, err := router.LoadPathRouter(loc.RoutingFile, logger)
+	if err != nil {
+		return err
+	}
+
+	out := cmd.OutOrStdout()
+	fmt.Fprintf(out, "path:          %s\n", path)
+
+	rule, matched := rt.MatchRule(path)
+	service := rule.Service
+	if matched {
+		fmt.Fprintf(out, "rule:          prefix=%s\n", rule.Prefix)
+	} else {
+		service = rt.MatchService(path)
+		fmt.Fprintln(out, "rule:          (none, using default_service)")
+	}
+
+	if service == "" {
+		fmt.Fprintln(out, "service:       (none) -> 404 No matching service")
+		return nil
+	}
+	fmt.Fprintf(out, "service:       %s\n", service)
+
+	if len(rule.Selector) > 0 {
+		pairs := make([]string, 0, len(rule.Selector))
+		for k, v := range rule.Selector {
+			pairs = append(pairs, k+"="+v)
+		}
+		sort.Strings(pairs)
+		fmt.Fprintf(out, "selector:      %s\n", strings.Join(pairs, ","))
+	}
+
+	strip := rt.GetStripPrefix(path)
+	fmt.Fprintf(out, "strip_prefix:  %t\n", strip)
+	fmt.Fprintf(out, "upstream path: %s\n", router.ForwardPath(path, service, strip))
+
+	return nil
+}
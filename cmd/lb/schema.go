@@ -0,0 +1,34 @@
+package main
+
+import (
+	"github.com/nhutphuongasasa/loadbalancer/internal/config"
+	"github.com/spf13/cobra"
+)
+
+func newSchemaCmd() *cobra.Command {
+	var routing bool
+
+	cmd := &cobra.Command{
+		Use:   "schema",
+		Short: "Print the JSON Schema of the configuration file for editor validation",
+		Example: "  lb schema > config/config.schema.json\n" +
+			"  lb schema --routing > config/routing.schema.json",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			generate := config.JSONSchema
+			if routing {
+				generate = config.RoutingJSONSchema
+			}
+
+			data, err := generate()
+			if err != nil {
+				return err
+			}
+
+			_, err = cmd.OutOrStdout().Write(data)
+			return err
+		},
+	}
+
+	cmd.Flags().BoolVar(&routing, "routing", false, "print the schema of a separate routing.yml instead")
+	return cmd
+}
@@ -0,0 +1,270 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/nhutphuongasasa/loadbalancer/internal/admin"
+	"github.com/nhutphuongasasa/loadbalancer/internal/app"
+	"github.com/nhutphuongasasa/loadbalancer/internal/config"
+	"github.com/nhutphuongasasa/loadbalancer/internal/middleware"
+	"github.com/nhutphuongasasa/loadbalancer/internal/tcpproxy"
+	"github.com/spf13/cobra"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+type serveOptions struct {
+	configPath   string
+	port         int
+	registryPort int
+	adminAddr    string
+	logLevel     string
+}
+
+func newServeCmd() *cobra.Command {
+	o := &serveOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Start the load balancer, registry and admin servers",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runServe(cmd, o)
+		},
+	}
+
+	addConfigFlag(cmd, &o.configPath)
+	cmd.Flags().IntVarP(&o.port, "port", "p", 0, "public proxy port (overrides server.port)")
+	cmd.Flags().IntVar(&o.registryPort, "registry-port", 0, "service registry port (overrides registry.port)")
+	cmd.Flags().StringVar(&o.adminAddr, "admin-addr", "", "admin API address (overrides admin.addr)")
+	cmd.Flags().StringVar(&o.logLevel, "log-level", "", "log level: debug, info, warn, error (overrides log.level)")
+
+	return cmd
+}
+
+func runServe(cmd *cobra.Command, o *serveOptions) error {
+	loc, err := discoverConfig(o.configPath)
+	if err != nil {
+		return err
+	}
+	slog.Info("Using configuration", "config", loc.ConfigFile, "routing", loc.RoutingFile, "source", loc.Source)
+
+	opts := []app.Option{app.WithConfigLocation(loc)}
+	if cmd.Flags().Changed("port") {
+		opts = append(opts, app.WithOverride("server.port", o.port))
+	}
+	if cmd.Flags().Changed("registry-port") {
+		opts = append(opts, app.WithOverride("registry.port", o.registryPort))
+	}
+	if o.adminAddr != "" {
+		opts = append(opts, app.WithOverride("admin.addr", o.adminAddr))
+	}
+	if o.logLevel != "" {
+		opts = append(opts, app.WithOverride("log.level", o.logLevel))
+	}
+
+	application, err := app.NewApp(loc.BaseDir(), opts...)
+	if err != nil {
+		return fmt.Errorf("init app: %w", err)
+	}
+
+	if err := application.StartSubService(); err != nil {
+		application.StopSubService()
+		return err
+	}
+
+	cfg := application.GetConfigManager().GetConfig()
+	port := strconv.Itoa(application.GetConfigManager().GetPortServer())
+	registryPort := strconv.Itoa(cfg.Registry.Port)
+
+	publicServer, httpsServer := newPublicServers(application, port)
+
+	registryServer := &http.Server{
+		Addr:         ":" + registryPort,
+		Handler:      application.GetProviderServer().RegisterHTTPHandler(),
+		ReadTimeout:  15 * time.Second,
+		WriteTimeout: 15 * time.Second,
+		IdleTimeout:  60 * time.Second,
+	}
+
+	//loi tu bat ky server nao deu dung ca chuong trinh
+	serveErr := make(chan error, 5)
+
+	if publicServer != nil {
+		go func() {
+			slog.Info("Load balancer is starting", "port", port)
+			if err := publicServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				serveErr <- fmt.Errorf("public server: %w", err)
+			}
+		}()
+	}
+
+	if httpsServer != nil {
+		go func() {
+			slog.Info("Load balancer HTTPS is starting", "addr", httpsServer.Addr)
+			// chung chi lay tu TLSConfig cua TLS manager nen khong truyen file
+			if err := httpsServer.ListenAndServeTLS("", ""); err != nil && err != http.ErrServerClosed {
+				serveErr <- fmt.Errorf("https server: %w", err)
+			}
+		}()
+	}
+
+	go func() {
+		slog.Info("Load registry server is starting", "port", registryPort)
+		if err := registryServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			serveErr <- fmt.Errorf("registry server: %w", err)
+		}
+	}()
+
+	tcpProxy := application.GetTCPProxy()
+	if tcpProxy != nil {
+		go func() {
+			if err := tcpProxy.ListenAndServe(); err != nil && err != tcpproxy.ErrProxyClosed {
+				serveErr <- fmt.Errorf("tcp proxy: %w", err)
+			}
+		}()
+	}
+
+	var adminServer *http.Server
+	if adminCfg := application.GetConfigManager().GetConfig().Admin; adminCfg != nil && adminCfg.Enabled {
+		ln, err := admin.Listen(adminCfg)
+		if err != nil {
+			application.StopSubService()
+			return fmt.Errorf("open admin listener: %w", err)
+		}
+
+		adminServer = &http.Server{
+			Handler:     application.GetAdminServer().Handler(),
+			ReadTimeout: 15 * time.Second,
+			// pprof profile/trace can giu ket noi lau hon WriteTimeout thong thuong
+			WriteTimeout: 2 * time.Minute,
+			IdleTimeout:  60 * time.Second,
+		}
+
+		go func() {
+			slog.Info("Admin server is starting", "addr", ln.Addr().String())
+			if err := adminServer.Serve(ln); err != nil && err != http.ErrServerClosed {
+				serveErr <- fmt.Errorf("admin server: %w", err)
+			}
+		}()
+	}
+
+	//tao channel tin hieu bao dong
+	quit := make(chan os.Signal, 1)
+	//SIGINT la ctrl+c
+	// SIGTERM la lenh tat may
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+
+	var runErr error
+	select {
+	case <-quit:
+	case runErr = <-serveErr:
+		slog.Error("Server error", "error", runErr)
+	}
+
+	slog.Info("Shutting down Load balancer")
+
+	// ngung nhan ket noi tcp ngay, ket noi dang mo duoc tcp.drain_timeout de ket thuc
+	tcpDrained := make(chan struct{})
+	go func() {
+		defer close(tcpDrained)
+		if tcpProxy == nil {
+			return
+		}
+		// drain_timeout = 0 thi cho den khi moi ket noi tu ket thuc
+		ctx := context.Background()
+		if cfg.TCP.DrainTimeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, cfg.TCP.DrainTimeout)
+			defer cancel()
+		}
+		if err := tcpProxy.Shutdown(ctx); err != nil {
+			slog.Error("TCP proxy forced to shutdown", "error", err)
+		}
+	}()
+
+	application.StopSubService()
+
+	//huy theo timout
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	servers := map[string]*http.Server{
+		"public":   publicServer,
+		"https":    httpsServer,
+		"registry": registryServer,
+		"admin":    adminServer,
+	}
+	for name, srv := range servers {
+		if srv == nil {
+			continue
+		}
+		if err := srv.Shutdown(ctx); err != nil {
+			slog.Error("Server forced to shutdown", "server", name, "error", err)
+		}
+	}
+
+	<-tcpDrained
+
+	slog.Info("Load Balancer exited gracefully")
+	return runErr
+}
+
+/*
+*Listener public theo tls: HTTPS dung TLSConfig cua TLS manager (chung chi doi khong can restart),
+*server.port phuc vu binh thuong, redirect sang HTTPS hoac tat theo tls.http.
+*server.h2c cho server.port nhan them HTTP/2 cleartext (prior knowledge hoac Upgrade: h2c)
+ */
+func newPublicServers(application *app.App, port string) (plain, secure *http.Server) {
+	handler := application.GetHandler()
+	cfg := application.GetConfigManager().GetConfig()
+
+	// WebSocket, SSE, gRPC bo qua read/write timeout, dung server.stream_idle_timeout (middleware Streaming)
+	newServer := func(addr string, h http.Handler) *http.Server {
+		return &http.Server{
+			Addr:              addr,
+			Handler:           h,
+			ReadHeaderTimeout: cfg.Server.ReadTimeout,
+			ReadTimeout:       cfg.Server.ReadTimeout,
+			WriteTimeout:      cfg.Server.WriteTimeout,
+			IdleTimeout:       cfg.Server.IdleTimeout,
+		}
+	}
+	newPlainServer := func(h http.Handler) *http.Server {
+		if cfg.Server.H2C {
+			h = h2c.NewHandler(h, &http2.Server{IdleTimeout: cfg.Server.IdleTimeout})
+		}
+		return newServer(":"+port, h)
+	}
+
+	if !application.TLSEnabled() {
+		return newPlainServer(handler), nil
+	}
+
+	tlsCfg := cfg.TLS
+	secure = newServer(":"+strconv.Itoa(tlsCfg.Port), application.GetHSTS().Middleware(handler))
+	secure.TLSConfig = application.GetTLSManager().GetTLSConfig()
+	// ALPN do TLS manager chao (tls.http2), Protocols phai khop de net/http phuc vu h2
+	secure.Protocols = new(http.Protocols)
+	secure.Protocols.SetHTTP1(true)
+	secure.Protocols.SetHTTP2(tlsCfg.HTTP2)
+
+	// listener HTTP con tra loi HTTP-01 challenge khi dung ACME
+	acmeHandler := application.GetTLSManager().HTTPHandler
+	switch tlsCfg.HTTP {
+	case config.HTTPModeServe:
+		plain = newPlainServer(acmeHandler(handler))
+	case config.HTTPModeOff:
+	default:
+		plain = newServer(":"+port, acmeHandler(middleware.RedirectHTTPS(tlsCfg.Port)))
+	}
+
+	return plain, secure
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/nhutphuongasasa/loadbalancer/internal/config"
+	"github.com/nhutphuongasasa/loadbalancer/internal/router"
+	"github.com/spf13/cobra"
+)
+
+const outputText = "text"
+
+type validateOptions struct {
+	configPath string
+	output     string
+	strict     bool
+}
+
+/*
+*Ket qua validate cho --output json, dung trong CI
+ */
+type validateReport struct {
+	Valid    bool            `json:"valid"`
+	Files    []string        `json:"files"`
+	Errors   int             `json:"errors"`
+	Warnings int             `json:"warnings"`
+	Problems config.Problems `json:"problems"`
+}
+
+func newValidateCmd() *cobra.Command {
+	o := &validateOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "validate",
+		Short: "Validate config.yml and routing.yml without starting the load balancer",
+		Long: "Validate config.yml and routing.yml without starting the load balancer.\n" +
+			"Every problem is reported with its YAML path and line; the command exits non-zero on errors " +
+			"(or on warnings with --strict).",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runValidate(cmd, o)
+		},
+	}
+
+	addConfigFlag(cmd, &o.configPath)
+	cmd.Flags().StringVarP(&o.output, "output", "o", outputText, "output format: text or json")
+	cmd.Flags().BoolVar(&o.strict, "strict", false, "treat warnings as errors")
+	return cmd
+}
+
+func runValidate(cmd *cobra.Command, o *validateOptions) error {
+	if o.output != outputText && o.output != outputJSON {
+		return fmt.Errorf("unknown output format %q (expected text or json)", o.output)
+	}
+
+	loc, err := discoverConfig(o.configPath)
+	if err != nil {
+		return err
+	}
+
+	report := validateLocation(loc)
+	report.Valid = report.Errors == 0 && (!o.strict || report.Warnings == 0)
+
+	out := cmd.OutOrStdout()
+	if o.output == outputJSON {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			return err
+		}
+	} else {
+		for _, p := range report.Problems {
+			fmt.Fprintln(out, p.String())
+		}
+		fmt.Fprintf(out, "%d error(s), %d warning(s) in %v\n", report.Errors, report.Warnings, report.Files)
+	}
+
+	if !report.Valid {
+		cmd.SilenceErrors = o.output == outputJSON
+		return errors.New("configuration is invalid")
+	}
+	return nil
+}
+
+/*
+*Kiem tra config, routing va doi chieu service giua 2 file
+ */
+func validateLocation(loc *config.Location) *validateReport {
+	report := &validateReport{Files: []string{loc.ConfigFile}, Problems: config.Problems{}}
+
+	cfg, problems := config.ValidateFile(loc.ConfigFile)
+	report.Problems = append(report.Problems, problems...)
+
+	// file gop thi routing nam trong section routing
+	routingPrefix := ""
+	if loc.Combined() {
+		routingPrefix = "routing"
+	} else {
+		report.Files = append(report.Files, loc.RoutingFile)
+	}
+
+	var routingProblems config.Problems
+	routing, err := router.LoadRoutingConfig(loc.RoutingFile)
+	if err != nil {
+		routingProblems = config.Problems{{Severity: config.SeverityError, Message: err.Error()}}
+	} else {
+		routingProblems = router.CheckRoutingConfig(routing)
+
+		if cfg != nil {
+			fromRouting, fromConfig := router.CheckBackendReferences(routing, cfg.BackEnds)
+			routingProblems = append(routingProblems, fromRouting...)
+			routingProblems = append(routingProblems, router.CheckTCPServices(routing, cfg.TCP)...)
+			report.Problems = append(report.Problems, fromConfig.In(loc.ConfigFile, "")...)
+		}
+	}
+	report.Problems = append(report.Problems, routingProblems.In(loc.RoutingFile, routingPrefix)...)
+
+	report.Problems = config.LocateProblems(report.Problems)
+	report.Problems.Sort()
+
+	report.Errors = len(report.Problems.Errors())
+	report.Warnings = len(report.Problems.Warnings())
+	return report
+}
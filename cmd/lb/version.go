@@ -0,0 +1,32 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/nhutphuongasasa/loadbalancer/internal/version"
+	"github.com/spf13/cobra"
+)
+
+func newVersionCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "version",
+		Short: "Print build information",
+		Run: func(cmd *cobra.Command, args []string) {
+			info := version.Get()
+			out := cmd.OutOrStdout()
+
+			fmt.Fprintf(out, "lb %s\n", info.Version)
+			if info.Commit != "" {
+				commit := info.Commit
+				if info.Modified {
+					commit += " (modified)"
+				}
+				fmt.Fprintf(out, "commit:     %s\n", commit)
+			}
+			if info.BuildDate != "" {
+				fmt.Fprintf(out, "built:      %s\n", info.BuildDate)
+			}
+			fmt.Fprintf(out, "go:         %s %s\n", info.GoVersion, info.Platform)
+		},
+	}
+}
@@ -0,0 +1,225 @@
+package admin
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/nhutphuongasasa/loadbalancer/internal/metric"
+	"github.com/nhutphuongasasa/loadbalancer/internal/model"
+	"github.com/nhutphuongasasa/loadbalancer/internal/registry/provider"
+	"github.com/nhutphuongasasa/loadbalancer/internal/resilience"
+	"github.com/nhutphuongasasa/loadbalancer/internal/tls"
+	"github.com/nhutphuongasasa/loadbalancer/internal/version"
+)
+
+type breakerStatus struct {
+	Name                 string `json:"name"`
+	State                string `json:"state"`
+	Requests             uint32 `json:"requests"`
+	TotalSuccesses       uint32 `json:"totalSuccesses"`
+	TotalFailures        uint32 `json:"totalFailures"`
+	ConsecutiveSuccesses uint32 `json:"consecutiveSuccesses"`
+	ConsecutiveFailures  uint32 `json:"consecutiveFailures"`
+}
+
+type instanceView struct {
+	*provider.InstanceStatus
+	LastSeen       time.Time      `json:"lastSeen"`
+	CircuitBreaker *breakerStatus `json:"circuitBreaker,omitempty"`
+}
+
+type serviceView struct {
+	Name      string          `json:"name"`
+	Total     int             `json:"total"`
+	Available int             `json:"available"`
+	Instances []*instanceView `json:"instances"`
+}
+
+type statusView struct {
+	Build     version.Info `json:"build"`
+	StartedAt time.Time    `json:"startedAt"`
+	Uptime    string       `json:"uptime"`
+	Services  int          `json:"services"`
+	Instances int          `json:"instances"`
+	Available int          `json:"available"`
+}
+
+func (s *Server) statusHandler(w http.ResponseWriter, req *http.Request) {
+	st := statusView{
+		Build:     version.Get(),
+		StartedAt: s.startedAt,
+		Uptime:    time.Since(s.startedAt).Round(time.Second).String(),
+	}
+
+	if s.registry != nil {
+		services := make(map[string]bool)
+		for _, srv := range s.registry.ListInstances() {
+			services[srv.ServiceName] = true
+			st.Instances++
+			if srv.IsAvailable() {
+				st.Available++
+			}
+		}
+		st.Services = len(services)
+	}
+
+	writeJSON(w, http.StatusOK, st)
+}
+
+/*
+*Danh sach service va instance trong registry, kem trang thai circuit breaker cua tung instance
+ */
+func (s *Server) servicesHandler(w http.ResponseWriter, req *http.Request) {
+	if s.registry == nil {
+		writeError(w, http.StatusNotImplemented, "registry_unavailable", "registry is not attached to the admin server")
+		return
+	}
+
+	filter := req.URL.Query().Get("service")
+	services := make([]*serviceView, 0)
+	index := make(map[string]*serviceView)
+
+	// ListInstances da sap xep theo service nen thu tu duoc giu nguyen
+	for _, srv := range s.registry.ListInstances() {
+		if filter != "" && srv.ServiceName != filter {
+			continue
+		}
+
+		svc, ok := index[srv.ServiceName]
+		if !ok {
+			svc = &serviceView{Name: srv.ServiceName, Instances: make([]*instanceView, 0)}
+			index[srv.ServiceName] = svc
+			services = append(services, svc)
+		}
+
+		svc.Total++
+		if srv.IsAvailable() {
+			svc.Available++
+		}
+		svc.Instances = append(svc.Instances, &instanceView{
+			InstanceStatus: provider.NewInstanceStatus(srv),
+			LastSeen:       srv.GetLastSeen(),
+			CircuitBreaker: breakerOf(srv),
+		})
+	}
+
+	writeJSON(w, http.StatusOK, services)
+}
+
+func (s *Server) poolHandler(w http.ResponseWriter, req *http.Request) {
+	if s.pool == nil {
+		writeError(w, http.StatusNotImplemented, "pool_unavailable", "server pool is not attached to the admin server")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, s.pool.Status())
+}
+
+func (s *Server) routesHandler(w http.ResponseWriter, req *http.Request) {
+	if s.router == nil {
+		writeError(w, http.StatusNotImplemented, "router_unavailable", "router is not attached to the admin server")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, s.router.Status())
+}
+
+/*
+*Config dang co hieu luc, cac truong bi mat (password, secret, token, api key) da duoc che
+ */
+func (s *Server) configHandler(w http.ResponseWriter, req *http.Request) {
+	if s.config == nil || s.config.GetConfig() == nil {
+		writeError(w, http.StatusNotImplemented, "config_unavailable", "config is not attached to the admin server")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, redactedConfig(s.config.GetConfig()))
+}
+
+func (s *Server) breakersHandler(w http.ResponseWriter, req *http.Request) {
+	if s.registry == nil {
+		writeError(w, http.StatusNotImplemented, "registry_unavailable", "registry is not attached to the admin server")
+		return
+	}
+
+	breakers := make([]*breakerStatus, 0)
+	for _, srv := range s.registry.ListInstances() {
+		if cb := breakerOf(srv); cb != nil {
+			breakers = append(breakers, cb)
+		}
+	}
+
+	writeJSON(w, http.StatusOK, breakers)
+}
+
+func (s *Server) rateLimitHandler(w http.ResponseWriter, req *http.Request) {
+	if s.limiter == nil {
+		writeError(w, http.StatusNotImplemented, "rate_limit_disabled", "rate limiter is not configured")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, s.limiter.Stats())
+}
+
+func (s *Server) tlsHandler(w http.ResponseWriter, req *http.Request) {
+	if s.certs == nil {
+		writeError(w, http.StatusNotImplemented, "tls_disabled", "TLS is not configured")
+		return
+	}
+
+	certs, err := s.certs.CertificateInfo()
+	if errors.Is(err, tls.ErrNoCertificate) {
+		writeError(w, http.StatusNotFound, "no_certificate", err.Error())
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "certificate_error", err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, certs)
+}
+
+func (s *Server) metricsHandler(w http.ResponseWriter, req *http.Request) {
+	var families []*metric.Family
+	for _, src := range s.metrics {
+		families = append(families, src.Metrics()...)
+	}
+
+	w.Header().Set("Content-Type", metric.ContentType)
+	if err := metric.Write(w, families); err != nil {
+		s.logger.Warn("Failed to write metrics", "err", err)
+	}
+}
+
+func (s *Server) buildHandler(w http.ResponseWriter, req *http.Request) {
+	writeJSON(w, http.StatusOK, version.Get())
+}
+
+/*
+*Lay circuit breaker tu resilient transport cua instance, nil neu instance dung transport khac
+ */
+func breakerOf(srv *model.Server) *breakerStatus {
+	proxy := srv.GetProxy()
+	if proxy == nil {
+		return nil
+	}
+
+	rt, ok := proxy.Transport.(*resilience.ResilientTransport)
+	if !ok || rt.Breaker() == nil {
+		return nil
+	}
+
+	cb := rt.Breaker()
+	counts := cb.Counts()
+	return &breakerStatus{
+		Name:                 cb.Name(),
+		State:                cb.State().String(),
+		Requests:             counts.Requests,
+		TotalSuccesses:       counts.TotalSuccesses,
+		TotalFailures:        counts.TotalFailures,
+		ConsecutiveSuccesses: counts.ConsecutiveSuccesses,
+		ConsecutiveFailures:  counts.ConsecutiveFailures,
+	}
+}
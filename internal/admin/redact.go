@@ -0,0 +1,131 @@
+package admin
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/nhutphuongasasa/loadbalancer/internal/config"
+)
+
+const redacted = "******"
+
+// ten key chua cac chuoi nay se bi che gia tri
+var sensitiveKeys = []string{"password", "secret", "token", "api_keys", "private_key"}
+
+/*
+*Chuyen config thanh map theo ten key trong file yml va che cac gia tri bi mat
+ */
+func redactedConfig(cfg *config.Config) any {
+	return toPlain(reflect.ValueOf(cfg), false)
+}
+
+func isSensitive(key string) bool {
+	key = strings.ToLower(key)
+	for _, s := range sensitiveKeys {
+		if strings.Contains(key, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func toPlain(v reflect.Value, hide bool) any {
+	if v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+
+	if d, ok := v.Interface().(time.Duration); ok {
+		return d.String()
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		out := make(map[string]any, v.NumField())
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			name := strings.Split(field.Tag.Get("mapstructure"), ",")[0]
+			if name == "" {
+				name = field.Name
+			}
+			out[name] = toPlain(v.Field(i), hide || isSensitive(name))
+		}
+		return out
+	case reflect.Map:
+		out := make(map[string]any, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			out[fmt.Sprint(iter.Key().Interface())] = toPlain(iter.Value(), hide)
+		}
+		return out
+	case reflect.Slice, reflect.Array:
+		out := make([]any, v.Len())
+		for i := range out {
+			out[i] = toPlain(v.Index(i), hide)
+		}
+		return out
+	case reflect.String:
+		if hide && v.String() != "" {
+			return redacted
+		}
+		return v.String()
+	default:
+		return v.Interface()
+	}
+}
+
+/*
+*Che gia tri bi mat trong noi dung yml tho (revision, diff) theo tung dong.
+*Key bi mat khong co gia tri tren cung dong (vd api_keys:) thi che ca cac dong con
+ */
+func redactYAML(content string) string {
+	lines := strings.Split(content, "\n")
+	parentIndent := -1 // >= 0 khi dang o trong khoi con cua 1 key bi mat
+
+	for i, line := range lines {
+		body := strings.TrimLeft(line, " ")
+		if body == "" || strings.HasPrefix(body, "#") {
+			continue
+		}
+		indent := len(line) - len(body)
+
+		if parentIndent >= 0 && indent <= parentIndent {
+			parentIndent = -1
+		}
+
+		item := strings.TrimPrefix(body, "- ")
+		lead := line[:len(line)-len(item)]
+		key, value, hasKey := strings.Cut(item, ":")
+		value = strings.TrimSpace(value)
+
+		switch {
+		case parentIndent >= 0 && hasKey:
+			lines[i] = lead + key + ": " + redacted
+		case parentIndent >= 0:
+			lines[i] = lead + redacted
+		case !hasKey || !isSensitive(key) || isEmptyYAML(value):
+		case value == "":
+			parentIndent = indent
+		default:
+			lines[i] = lead + key + ": " + redacted
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func isEmptyYAML(value string) bool {
+	switch value {
+	case "{}", "[]", `""`, "''":
+		return true
+	}
+	return false
+}
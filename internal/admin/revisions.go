@@ -0,0 +1,140 @@
+package admin
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/nhutphuongasasa/loadbalancer/internal/config"
+)
+
+type ConfigHistory interface {
+	List() []config.Revision
+	Get(number int) (*config.Revision, error)
+	Rollback(number int) (*config.Revision, error)
+}
+
+type diffView struct {
+	From int    `json:"from"`
+	To   int    `json:"to"`
+	Diff string `json:"diff"`
+}
+
+/*
+*Lich su revision cua config.yml/routing.yml, bat endpoint revisions, diff va rollback
+ */
+func WithConfigHistory(h ConfigHistory) Option {
+	return func(s *Server) {
+		s.history = h
+	}
+}
+
+func (s *Server) revisionsHandler(w http.ResponseWriter, req *http.Request) {
+	if !s.requireHistory(w) {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, s.history.List())
+}
+
+func (s *Server) revisionHandler(w http.ResponseWriter, req *http.Request) {
+	if !s.requireHistory(w) {
+		return
+	}
+
+	rev, ok := s.lookupRevision(w, req.PathValue("number"))
+	if !ok {
+		return
+	}
+
+	rev.Content = redactYAML(rev.Content)
+	writeJSON(w, http.StatusOK, rev)
+}
+
+/*
+*GET /v1/config/diff?from=N[&to=M], thieu to thi so voi revision moi nhat cung file
+ */
+func (s *Server) diffHandler(w http.ResponseWriter, req *http.Request) {
+	if !s.requireHistory(w) {
+		return
+	}
+
+	from, ok := s.lookupRevision(w, req.URL.Query().Get("from"))
+	if !ok {
+		return
+	}
+
+	var to *config.Revision
+	if raw := req.URL.Query().Get("to"); raw != "" {
+		if to, ok = s.lookupRevision(w, raw); !ok {
+			return
+		}
+	} else {
+		for _, rev := range s.history.List() {
+			if rev.File == from.File {
+				to, _ = s.history.Get(rev.Number)
+				break
+			}
+		}
+	}
+
+	// che gia tri bi mat truoc khi diff, dong bi doi van hien ra
+	diff := config.UnifiedDiff(
+		revisionLabel(from), redactYAML(from.Content),
+		revisionLabel(to), redactYAML(to.Content),
+	)
+	writeJSON(w, http.StatusOK, &diffView{From: from.Number, To: to.Number, Diff: diff})
+}
+
+func (s *Server) rollbackHandler(w http.ResponseWriter, req *http.Request) {
+	if !s.requireHistory(w) {
+		return
+	}
+
+	number, err := strconv.Atoi(req.PathValue("number"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_revision", "revision must be a number")
+		return
+	}
+
+	rev, err := s.history.Rollback(number)
+	switch {
+	case errors.Is(err, config.ErrRevisionNotFound):
+		writeError(w, http.StatusNotFound, "revision_not_found", err.Error())
+		return
+	case err != nil:
+		s.logger.Error("Config rollback failed", "revision", number, "err", err)
+		writeError(w, http.StatusUnprocessableEntity, "rollback_failed", err.Error())
+		return
+	}
+
+	s.logger.Warn("Config rolled back", "revision", number, "file", rev.File, "current", rev.Number)
+	writeJSON(w, http.StatusOK, rev)
+}
+
+func (s *Server) requireHistory(w http.ResponseWriter) bool {
+	if s.history == nil {
+		writeError(w, http.StatusNotImplemented, "history_unavailable", "config history is not attached to the admin server")
+		return false
+	}
+	return true
+}
+
+func (s *Server) lookupRevision(w http.ResponseWriter, raw string) (*config.Revision, bool) {
+	number, err := strconv.Atoi(raw)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_revision", "revision must be a number")
+		return nil, false
+	}
+
+	rev, err := s.history.Get(number)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "revision_not_found", err.Error())
+		return nil, false
+	}
+	return rev, true
+}
+
+func revisionLabel(rev *config.Revision) string {
+	return rev.File + " (revision " + strconv.Itoa(rev.Number) + ")"
+}
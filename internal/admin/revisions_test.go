@@ -0,0 +1,142 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nhutphuongasasa/loadbalancer/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+/*
+*History theo doi 1 file tam, reload chi ghi lai revision nhu duong hot reload that
+ */
+func trackedHistory(t *testing.T, contents ...string) (*config.History, string) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "config.yml")
+	require.NoError(t, os.WriteFile(path, []byte(contents[0]), 0644))
+
+	h := config.NewHistory(0)
+	require.NoError(t, h.Track(path, "config", func() error {
+		_, err := h.RecordFile(path, "config", config.SourceFile)
+		return err
+	}))
+
+	for _, content := range contents[1:] {
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+		_, err := h.RecordFile(path, "config", config.SourceFile)
+		require.NoError(t, err)
+	}
+	return h, path
+}
+
+func TestRevisions_ListAndGet(t *testing.T) {
+	h, path := trackedHistory(t,
+		"server:\n  port: 8080\ncache:\n  password: old-pass\n",
+		"server:\n  port: 8081\ncache:\n  password: new-pass\n",
+	)
+	handler := NewServer(nil, WithConfigHistory(h)).Handler()
+
+	rec := get(t, handler, "/v1/config/revisions", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var revisions []config.Revision
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&revisions))
+	require.Len(t, revisions, 2)
+	assert.Equal(t, 2, revisions[0].Number)
+	assert.Equal(t, path, revisions[0].File)
+	assert.Equal(t, config.SourceStartup, revisions[1].Source)
+	// danh sach khong kem noi dung
+	assert.Empty(t, revisions[0].Content)
+
+	rec = get(t, handler, "/v1/config/revisions/1", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var rev config.Revision
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&rev))
+	assert.Contains(t, rev.Content, "port: 8080")
+	assert.NotContains(t, rev.Content, "old-pass")
+
+	assert.Equal(t, http.StatusNotFound, get(t, handler, "/v1/config/revisions/42", "").Code)
+	assert.Equal(t, http.StatusBadRequest, get(t, handler, "/v1/config/revisions/latest", "").Code)
+}
+
+func TestRevisions_Diff(t *testing.T) {
+	h, _ := trackedHistory(t,
+		"server:\n  port: 8080\ncache:\n  password: old-pass\n",
+		"server:\n  port: 8081\ncache:\n  password: new-pass\n",
+	)
+	handler := NewServer(nil, WithConfigHistory(h)).Handler()
+
+	// thieu to thi so voi revision moi nhat
+	rec := get(t, handler, "/v1/config/diff?from=1", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var view diffView
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&view))
+	assert.Equal(t, 1, view.From)
+	assert.Equal(t, 2, view.To)
+	assert.Contains(t, view.Diff, "-  port: 8080\n+  port: 8081\n")
+	assert.NotContains(t, view.Diff, "old-pass")
+	assert.NotContains(t, view.Diff, "new-pass")
+
+	assert.Equal(t, http.StatusBadRequest, get(t, handler, "/v1/config/diff", "").Code)
+	assert.Equal(t, http.StatusNotFound, get(t, handler, "/v1/config/diff?from=1&to=42", "").Code)
+}
+
+func TestRevisions_Rollback(t *testing.T) {
+	h, path := trackedHistory(t, "server:\n  port: 8080\n", "server:\n  port: 8081\n")
+	handler := NewServer(nil, WithConfigHistory(h), WithToken("s3cret")).Handler()
+
+	post := func(target, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, target, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, post("/v1/config/revisions/1/rollback", "").Code)
+	assert.Equal(t, http.StatusNotFound, post("/v1/config/revisions/42/rollback", "s3cret").Code)
+
+	rec := post("/v1/config/revisions/1/rollback", "s3cret")
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var rev config.Revision
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&rev))
+	assert.Equal(t, 3, rev.Number)
+	assert.Equal(t, "rollback:1", rev.Source)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "server:\n  port: 8080\n", string(data))
+}
+
+func TestRevisions_RollbackRequiresConfiguredToken(t *testing.T) {
+	h, path := trackedHistory(t, "server:\n  port: 8080\n", "server:\n  port: 8081\n")
+	handler := NewServer(nil, WithConfigHistory(h)).Handler()
+
+	// khong co token thi van doc duoc, nhung khong rollback duoc
+	assert.Equal(t, http.StatusOK, get(t, handler, "/v1/config/revisions", "").Code)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/config/revisions/1/rollback", nil))
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "server:\n  port: 8081\n", string(data))
+}
+
+func TestRevisions_WithoutHistory(t *testing.T) {
+	rec := get(t, NewServer(nil).Handler(), "/v1/config/revisions", "")
+	assert.Equal(t, http.StatusNotImplemented, rec.Code)
+}
@@ -0,0 +1,237 @@
+package admin
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/nhutphuongasasa/loadbalancer/internal/config"
+	"github.com/nhutphuongasasa/loadbalancer/internal/metric"
+	"github.com/nhutphuongasasa/loadbalancer/internal/middleware/rate_limit"
+	"github.com/nhutphuongasasa/loadbalancer/internal/model"
+	"github.com/nhutphuongasasa/loadbalancer/internal/router"
+	"github.com/nhutphuongasasa/loadbalancer/internal/server"
+	"github.com/nhutphuongasasa/loadbalancer/internal/tls"
+)
+
+type InstanceLister interface {
+	ListInstances() []*model.Server
+}
+
+type RouterInspector interface {
+	Status() router.Status
+}
+
+type PoolInspector interface {
+	Status() []server.PoolStatus
+}
+
+type CertificateSource interface {
+	CertificateInfo() ([]tls.CertificateInfo, error)
+}
+
+/*
+*Nguon metric cho /metrics (text format cua Prometheus)
+ */
+type MetricsSource interface {
+	Metrics() []*metric.Family
+}
+
+type ConfigSource interface {
+	GetConfig() *config.Config
+}
+
+/*
+*API quan tri chay tren listener rieng (localhost hoac unix socket): doc trang thai
+*va rollback config
+ */
+type Server struct {
+	logger    *slog.Logger
+	token     string
+	startedAt time.Time
+
+	registry InstanceLister
+	pool     PoolInspector
+	router   RouterInspector
+	config   ConfigSource
+	limiter  rate_limit.IRateLimiter
+	certs    CertificateSource
+	history  ConfigHistory
+	metrics  []MetricsSource
+}
+
+type Option func(*Server)
+
+func WithToken(token string) Option {
+	return func(s *Server) {
+		s.token = token
+	}
+}
+
+func WithRegistry(reg InstanceLister) Option {
+	return func(s *Server) {
+		s.registry = reg
+	}
+}
+
+func WithServerPool(pool PoolInspector) Option {
+	return func(s *Server) {
+		s.pool = pool
+	}
+}
+
+func WithRouter(rt RouterInspector) Option {
+	return func(s *Server) {
+		s.router = rt
+	}
+}
+
+func WithConfig(cfg ConfigSource) Option {
+	return func(s *Server) {
+		s.config = cfg
+	}
+}
+
+func WithRateLimiter(limiter rate_limit.IRateLimiter) Option {
+	return func(s *Server) {
+		s.limiter = limiter
+	}
+}
+
+func WithCertificates(certs CertificateSource) Option {
+	return func(s *Server) {
+		s.certs = certs
+	}
+}
+
+func WithMetrics(sources ...MetricsSource) Option {
+	return func(s *Server) {
+		s.metrics = append(s.metrics, sources...)
+	}
+}
+
+func NewServer(logger *slog.Logger, opts ...Option) *Server {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	s := &Server{
+		logger:    logger.With("module", "ADMIN"),
+		startedAt: time.Now(),
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /v1/status", s.statusHandler)
+	mux.HandleFunc("GET /v1/services", s.servicesHandler)
+	mux.HandleFunc("GET /v1/pool", s.poolHandler)
+	mux.HandleFunc("GET /v1/routes", s.routesHandler)
+	mux.HandleFunc("GET /v1/config", s.configHandler)
+	mux.HandleFunc("GET /v1/config/revisions", s.revisionsHandler)
+	mux.HandleFunc("GET /v1/config/revisions/{number}", s.revisionHandler)
+	mux.Handle("POST /v1/config/revisions/{number}/rollback", s.requireConfiguredToken(http.HandlerFunc(s.rollbackHandler)))
+	mux.HandleFunc("GET /v1/config/diff", s.diffHandler)
+	mux.HandleFunc("GET /v1/circuit-breakers", s.breakersHandler)
+	mux.HandleFunc("GET /v1/rate-limit", s.rateLimitHandler)
+	mux.HandleFunc("GET /v1/tls", s.tlsHandler)
+	mux.HandleFunc("GET /v1/build", s.buildHandler)
+	mux.HandleFunc("GET /metrics", s.metricsHandler)
+
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+
+	return s.requireToken(mux)
+}
+
+/*
+*Khi co cau hinh token thi moi request phai gui Authorization: Bearer <token>
+ */
+func (s *Server) requireToken(next http.Handler) http.Handler {
+	if s.token == "" {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		auth := req.Header.Get("Authorization")
+		token := strings.TrimPrefix(auth, "Bearer ")
+		if auth == "" || token == auth || subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) != 1 {
+			writeError(w, http.StatusUnauthorized, "unauthorized", "a valid admin bearer token is required")
+			return
+		}
+		next.ServeHTTP(w, req)
+	})
+}
+
+/*
+*Route thay doi trang thai (rollback) chi mo khi da cau hinh token, doc trang thai thi khong can
+ */
+func (s *Server) requireConfiguredToken(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if s.token == "" {
+			writeError(w, http.StatusForbidden, "admin_token_required", "this operation requires admin.token to be configured")
+			return
+		}
+		next.ServeHTTP(w, req)
+	})
+}
+
+/*
+*Mo listener theo config: unix socket neu co, nguoc lai la dia chi TCP
+ */
+func Listen(cfg *config.AdminConfig) (net.Listener, error) {
+	if cfg == nil || !cfg.Enabled {
+		return nil, errors.New("admin server is disabled")
+	}
+
+	if cfg.Socket == "" {
+		return net.Listen("tcp", cfg.Addr)
+	}
+
+	// socket cu con sot lai khi process truoc bi kill
+	if err := os.Remove(cfg.Socket); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	ln, err := net.Listen("unix", cfg.Socket)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.Chmod(cfg.Socket, 0600); err != nil {
+		ln.Close()
+		return nil, err
+	}
+
+	return ln, nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	writeJSON(w, status, map[string]string{"error": code, "message": message})
+}
@@ -0,0 +1,97 @@
+package admin
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/nhutphuongasasa/loadbalancer/internal/config"
+	"github.com/nhutphuongasasa/loadbalancer/internal/model"
+	"github.com/nhutphuongasasa/loadbalancer/internal/registry/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type staticConfig struct{ cfg *config.Config }
+
+func (s staticConfig) GetConfig() *config.Config { return s.cfg }
+
+func get(t *testing.T, h http.Handler, path, token string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestServices_IncludesCircuitBreaker(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	reg := memory.NewInMemoryRegistry(logger, time.Hour, nil)
+	require.NoError(t, reg.Register(model.NewServer("api-1", "api", "10.0.0.1", 8080, 1, nil, nil)))
+
+	h := NewServer(logger, WithRegistry(reg)).Handler()
+
+	rec := get(t, h, "/v1/services", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var services []struct {
+		Name      string `json:"name"`
+		Available int    `json:"available"`
+		Instances []struct {
+			InstanceID     string `json:"instanceID"`
+			State          string `json:"state"`
+			CircuitBreaker struct {
+				Name  string `json:"name"`
+				State string `json:"state"`
+			} `json:"circuitBreaker"`
+		} `json:"instances"`
+	}
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&services))
+	require.Len(t, services, 1)
+	assert.Equal(t, "api", services[0].Name)
+	assert.Equal(t, 1, services[0].Available)
+	assert.Equal(t, "api-1", services[0].Instances[0].InstanceID)
+	assert.Equal(t, "closed", services[0].Instances[0].CircuitBreaker.State)
+}
+
+func TestConfig_RedactsSecrets(t *testing.T) {
+	cfg := &config.Config{
+		Server:      &config.ServerConfig{Port: 8080},
+		RedisConfig: &config.CacheConfig{Addr: "localhost:6379", Password: "redis-pass", Timeout: 5 * time.Second},
+		Registry: &config.RegistryConfig{Auth: &config.RegistryAuthConfig{
+			Mode:         "api_key",
+			SharedSecret: "hmac-secret",
+			APIKeys:      map[string]string{"user-service": "user-key"},
+			AdminToken:   "admin-t0ken",
+		}},
+	}
+
+	h := NewServer(nil, WithConfig(staticConfig{cfg})).Handler()
+
+	rec := get(t, h, "/v1/config", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	body := rec.Body.String()
+	for _, secret := range []string{"redis-pass", "hmac-secret", "user-key", "admin-t0ken"} {
+		assert.NotContains(t, body, secret)
+	}
+	assert.Contains(t, body, `"addr": "localhost:6379"`)
+	assert.Contains(t, body, `"timeout": "5s"`)
+	assert.Contains(t, body, `"user-service": "******"`)
+}
+
+func TestHandler_RequiresToken(t *testing.T) {
+	h := NewServer(nil, WithToken("t0ken")).Handler()
+
+	assert.Equal(t, http.StatusUnauthorized, get(t, h, "/v1/build", "").Code)
+	assert.Equal(t, http.StatusUnauthorized, get(t, h, "/v1/build", "wrong").Code)
+	assert.Equal(t, http.StatusOK, get(t, h, "/v1/build", "t0ken").Code)
+}
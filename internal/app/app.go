@@ -6,19 +6,20 @@ import (
 	"log/slog"
 	"net"
 	"net/http"
-	"path/filepath"
-	"strings"
-	"time"
 
+	"github.com/nhutphuongasasa/loadbalancer/internal/admin"
 	"github.com/nhutphuongasasa/loadbalancer/internal/cache"
 	"github.com/nhutphuongasasa/loadbalancer/internal/config"
+	"github.com/nhutphuongasasa/loadbalancer/internal/grpc"
 	"github.com/nhutphuongasasa/loadbalancer/internal/middleware"
 	"github.com/nhutphuongasasa/loadbalancer/internal/model"
+	"github.com/nhutphuongasasa/loadbalancer/internal/registry"
 	"github.com/nhutphuongasasa/loadbalancer/internal/registry/memory"
 	"github.com/nhutphuongasasa/loadbalancer/internal/registry/provider"
 	"github.com/nhutphuongasasa/loadbalancer/internal/resilience"
 	"github.com/nhutphuongasasa/loadbalancer/internal/router"
 	"github.com/nhutphuongasasa/loadbalancer/internal/server"
+	"github.com/nhutphuongasasa/loadbalancer/internal/tcpproxy"
 	"github.com/nhutphuongasasa/loadbalancer/internal/tls"
 	"github.com/nhutphuongasasa/loadbalancer/internal/utils"
 )
@@ -29,8 +30,14 @@ type App struct {
 	registry       *memory.InMemoryRegistry
 	chainSecurity  *middleware.SecuritySuite
 	tlsManager     *tls.ManagerSTL
+	hsts           *middleware.HSTS
+	clientCert     *middleware.ClientCertHeaders
+	streaming      *middleware.Streaming
+	upstreams      *registry.Upstreams
 	router         *router.PathRouter
 	providerServer *provider.ProviderServer
+	adminServer    *admin.Server
+	tcpProxy       *tcpproxy.Proxy
 	resilience     *resilience.ResilientTransport
 	cacheShared    *cache.CacheClient
 	logger         *slog.Logger
@@ -38,16 +45,63 @@ type App struct {
 	cancel         context.CancelFunc
 }
 
-func NewApp(rootDir string) (*App, error) {
-	cfgManager := initConfigManager(rootDir)
+func NewApp(rootDir string, opts ...Option) (*App, error) {
+	o := newOptions(rootDir, opts)
+
+	cfgManager, err := initConfigManager(o.configFile)
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range o.overrides {
+		if err := cfgManager.Override(key, value); err != nil {
+			return nil, fmt.Errorf("override %s: %w", key, err)
+		}
+	}
 
 	logger := utils.GetLogger(cfgManager)
 
-	providerServer := provider.NewProviderServer(logger)
+	events := registry.NewEventHub(0)
+	upstreams := registry.NewUpstreams(certDirOf(rootDir, cfgManager.GetConfig().TLS), cfgManager.GetConfig().Upstreams)
+	// truoc khi registry nap snapshot va backend tinh de instance cua service tcp duoc danh dau ngay
+	if t := cfgManager.GetConfig().TCP; t != nil {
+		upstreams.SetTCPServices(t.Listeners)
+	}
+
+	providerServer := provider.NewProviderServer(
+		logger,
+		provider.WithRegistryConfig(cfgManager.GetConfig().Registry),
+		provider.WithEventHub(events),
+		provider.WithResilience(cfgManager.GetConfig().Resilience),
+		provider.WithUpstreams(upstreams),
+	)
 
 	cache, err := cache.NewCacheClient(cfgManager.GetConfig().RedisConfig)
+	if err != nil {
+		logger.Error("Failed to connect to Redis", "err", err)
+	}
+
+	regOpts := []memory.Option{
+		memory.WithEventHub(events),
+		memory.WithResilience(cfgManager.GetConfig().Resilience),
+		memory.WithUpstreams(upstreams),
+	}
+	if regCfg := cfgManager.GetConfig().Registry; regCfg != nil {
+		regOpts = append(regOpts,
+			memory.WithInstanceTTL(regCfg.InstanceTTL),
+			memory.WithMaxInstancesPerService(regCfg.MaxInstancesPerService),
+		)
+		if regCfg.Snapshot != nil && regCfg.Snapshot.Path != "" {
+			regOpts = append(regOpts, memory.WithSnapshot(regCfg.Snapshot.Path, regCfg.Snapshot.Interval))
+		}
+	}
 
-	reg := memory.NewInMemoryRegistry(logger, 10*time.Second, providerServer.GetProviderChannel())
+	reg := memory.NewInMemoryRegistry(
+		logger,
+		cfgManager.GetHealthCheckInterval(),
+		providerServer.GetProviderChannel(),
+		regOpts...,
+	)
+	providerServer.BindRegistry(reg)
 
 	cfg := cfgManager.GetConfig()
 	strategy, err := initStrategy(cfg.Strategy.Strategy, logger)
@@ -55,55 +109,118 @@ func NewApp(rootDir string) (*App, error) {
 		return nil, fmt.Errorf("init strategy failed: %w", err)
 	}
 
-	rt, err := router.NewPathRouter(rootDir, logger)
+	rt, err := router.NewPathRouterFromFile(o.routingFile, logger)
 	if err != nil {
 		logger.Error("Failed to init router", "err", err)
 		return nil, err
 	}
 
+	if err := rt.SetTCPServices(cfg.TCP); err != nil {
+		return nil, err
+	}
+
+	history := config.NewHistory(0)
+	if err := cfgManager.SetHistory(history); err != nil {
+		logger.Warn("Config history disabled for config file", "err", err)
+	}
+	if err := rt.SetHistory(history); err != nil {
+		logger.Warn("Config history disabled for routing file", "err", err)
+	}
+
 	pool := server.NewServerPool(
 		logger,
 		reg.GetUpdateChan(),
 		strategy,
 	)
+	//rule doi thi bo tap con cua selector khong con dung
+	rt.Subscribe(func(rules []router.RouteRule) {
+		pool.SetSelectors(selectorsOf(rules))
+	})
 
-	suite := initSecuritySuite(logger, cache)
+	suite := initSecuritySuite(logger, cache, cfg)
+	tcpProxy := initTCPProxy(cfg.TCP, pool, logger)
 
-	certDir := filepath.Join(rootDir, "keys")
-	tlsMgr := initTLSManager(certDir, logger)
+	tlsMgr, err := initTLSManager(certDirOf(rootDir, cfg.TLS), cfg.TLS, cache, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	adminOpts := []admin.Option{
+		admin.WithRegistry(reg),
+		admin.WithServerPool(pool),
+		admin.WithRouter(rt),
+		admin.WithConfig(cfgManager),
+		admin.WithRateLimiter(suite.Limiter()),
+		admin.WithCertificates(tlsMgr),
+		admin.WithMetrics(tlsMgr),
+		admin.WithConfigHistory(history),
+	}
+	if cfg.Admin != nil {
+		adminOpts = append(adminOpts, admin.WithToken(cfg.Admin.Token))
+	}
+	if tcpProxy != nil {
+		adminOpts = append(adminOpts, admin.WithMetrics(tcpProxy))
+	}
+	adminServer := admin.NewServer(logger, adminOpts...)
 
 	ctx, cancel := context.WithCancel(context.Background())
 
-	return &App{
+	a := &App{
 		configManager:  cfgManager,
 		serverPool:     pool,
 		registry:       reg,
 		router:         rt,
 		chainSecurity:  suite,
 		tlsManager:     tlsMgr,
+		hsts:           middleware.NewHSTS(hstsOf(cfg.TLS)),
+		clientCert:     middleware.NewClientCertHeaders(forwardClientCertOf(cfg.TLS)),
+		streaming:      middleware.NewStreaming(cfg.Server.StreamIdleTimeout),
+		upstreams:      upstreams,
 		logger:         logger,
 		ctx:            ctx,
 		cancel:         cancel,
 		cacheShared:    cache,
 		providerServer: providerServer,
-	}, nil
+		adminServer:    adminServer,
+		tcpProxy:       tcpProxy,
+	}
+
+	for _, be := range cfg.BackEnds {
+		a.registerStaticBackend(be)
+	}
+	cfgManager.Subscribe(a.applyConfig)
+
+	return a, nil
 }
 
-func (a *App) StartSubService() {
+/*
+*Chay cac service nen. Chi tra ve loi khi HTTPS da bat ma khong nap duoc chung chi
+ */
+func (a *App) StartSubService() error {
 	a.logger.Info("Starting Load Balancer services...")
 
-	if a.chainSecurity.Limiter() != nil {
-		a.chainSecurity.Limiter().Start()
+	if a.tlsManager != nil {
+		if err := a.tlsManager.Start(); err != nil {
+			if a.TLSEnabled() {
+				return fmt.Errorf("start TLS manager: %w", err)
+			}
+			a.logger.Warn("TLS certificate not loaded, HTTPS is disabled anyway", "err", err)
+		}
 	}
 
-	if a.tlsManager != nil {
-		a.tlsManager.Start()
+	if a.chainSecurity.Limiter() != nil {
+		a.chainSecurity.Limiter().Start()
 	}
 
 	if a.registry != nil {
 		a.registry.Start()
 	}
 
+	if err := a.configManager.Start(); err != nil {
+		a.logger.Error("Failed to start config watcher", "err", err)
+	}
+
+	return nil
 }
 
 func (a *App) StopSubService() {
@@ -111,6 +228,8 @@ func (a *App) StopSubService() {
 
 	a.cancel()
 
+	a.configManager.Stop()
+
 	if a.chainSecurity.Limiter() != nil {
 		a.chainSecurity.Limiter().Stop()
 	}
@@ -131,21 +250,50 @@ func (a *App) StopSubService() {
 func (a *App) GetHandler() http.Handler {
 	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		//ap dung rule  duoc cung cap de lay server name
-		serviceName := a.router.MatchService(r.URL.Path)
+		rule, matched := a.router.MatchRule(r.URL.Path)
+		serviceName := rule.Service
+		if !matched {
+			serviceName = a.router.MatchService(r.URL.Path)
+		}
 		if serviceName == "" {
-			http.Error(w, "No matching service", http.StatusNotFound)
+			writeError(w, r, http.StatusNotFound, "No matching service")
 			a.logger.Warn("No service matched", "path", r.URL.Path)
 			return
 		}
 
-		//lay thong tin cac server name instanceId tu cache nho sessionId
-		var backend *model.Server
-		serverPair, ok := a.chainSecurity.Stickier().GetBackendFromContext(r)
+		//service sau listener tcp nhan byte tho, ReverseProxy khong gui toi tcp:// duoc
+		if a.upstreams.IsTCPService(serviceName) {
+			writeError(w, r, http.StatusBadGateway, "Service only accepts TCP connections")
+			a.logger.Warn("HTTP request routed to TCP service", "path", r.URL.Path, "service", serviceName)
+			return
+		}
 
-		//khong co thong tin thi set cookie moi
-		if !ok {
-			backend = a.serverPool.PickBackend(serviceName, getClientIP(r))
-			a.chainSecurity.Stickier().SetStickySession(w, serviceName, backend.InstanceID)
+		//route yeu cau danh tinh client cu the
+		if c := rule.ClientCert; matched && c != nil {
+			id := tls.IdentityOf(r.TLS)
+			if !id.Matches(c.Subjects, c.SANs, c.Fingerprints) {
+				writeError(w, r, http.StatusForbidden, "Client certificate required")
+				a.logger.Warn("Client certificate rejected by route", "path", r.URL.Path, "service", serviceName, "client", identityName(id))
+				return
+			}
+		}
+
+		//lay thong tin cac server name instanceId tu cache nho sessionId, middleware sticky da dien vao context
+		var backend *model.Server
+		stickier := a.chainSecurity.Stickier()
+
+		//gRPC can bang theo tung call, khong gan client vao 1 instance bang session
+		grpcCall := grpc.IsRequest(r)
+		if grpcCall || stickier == nil {
+			backend = a.serverPool.PickBackendWithSelector(serviceName, rule.Selector, getClientIP(r))
+		} else if serverPair, ok := stickier.GetBackendFromContext(r); !ok {
+			//khong co thong tin thi set cookie moi
+			backend = a.serverPool.PickBackendWithSelector(serviceName, rule.Selector, getClientIP(r))
+			if backend != nil {
+				if err := stickier.SetStickySession(w, serviceName, backend.InstanceID); err != nil {
+					a.logger.Warn("Failed to create sticky session", "service", serviceName, "err", err)
+				}
+			}
 		} else {
 			//cache co chua thong tin ve server name va instanceId
 			for _, v := range serverPair {
@@ -155,33 +303,48 @@ func (a *App) GetHandler() http.Handler {
 				}
 			}
 
+			//instance trong session khong con khop selector cua rule thi chon lai
+			if backend != nil && !backend.MatchesSelector(rule.Selector) {
+				backend = nil
+			}
+
 			if backend == nil {
-				//cache khong co thong tin ghi them vao cache
-				cacheKey := a.chainSecurity.Stickier().GetCacheKeyFromContext(r)
-				backend = a.serverPool.PickBackend(serviceName, getClientIP(r))
-
-				a.cacheShared.SetArray(a.ctx, cacheKey, append(serverPair, &model.ServerPair{
-					ServerName: serviceName,
-					InstanceId: backend.InstanceID,
-				}), 0)
+				//backend cu khong con nhan traffic (down, draining) hoac chua co, chuyen session sang backend moi
+				cacheKey := stickier.GetCacheKeyFromContext(r)
+				backend = a.serverPool.PickBackendWithSelector(serviceName, rule.Selector, getClientIP(r))
+
+				if backend != nil {
+					if err := stickier.MigrateSession(a.ctx, cacheKey, serverPair, serviceName, backend.InstanceID); err != nil {
+						a.logger.Warn("Failed to migrate sticky session", "service", serviceName, "err", err)
+					}
+				}
 			}
 		}
 
 		if backend == nil {
-			http.Error(w, "No healthy backend available", http.StatusServiceUnavailable)
+			writeError(w, r, http.StatusServiceUnavailable, "No healthy backend available")
 			a.logger.Warn("No healthy backend", "service", serviceName)
 			return
 		}
 
+		if backend.IsTCP() {
+			writeError(w, r, http.StatusBadGateway, "Service only accepts TCP connections")
+			a.logger.Warn("HTTP request routed to TCP backend", "service", serviceName, "backend", backend.HostPort())
+			return
+		}
+
+		//gRPC chi chay tren HTTP/2, backend HTTP/1.1 khong tra loi duoc
+		if grpcCall && !backend.Multiplexed() {
+			writeError(w, r, http.StatusServiceUnavailable, "Backend does not support HTTP/2")
+			a.logger.Warn("gRPC call routed to HTTP/1.1 backend, set upstreams protocol to h2c or http2", "service", serviceName, "backend", backend.GetAddr())
+			return
+		}
+
 		if a.router.GetStripPrefix(r.URL.Path) {
-			r.URL.Path = strings.TrimPrefix(r.URL.Path, "/"+serviceName)
+			r.URL.Path = router.ForwardPath(r.URL.Path, serviceName, true)
 			r.RequestURI = r.URL.RequestURI()
 		}
 
-		// if a.chainSecurity.Tracer() != nil {
-		// a.chainSecurity.Tracer().PropagateTraceHeaders(r.Context(), r)
-		// }
-
 		a.logger.Debug("Routed request",
 			"trace_id", middleware.TraceContextFromContext(r.Context()).TraceID,
 			"backend", backend.GetAddr(),
@@ -191,19 +354,48 @@ func (a *App) GetHandler() http.Handler {
 		a.logger.Debug("Routed request", "path", r.URL.Path, "service", serviceName, "backend", backend.GetAddr())
 	})
 
-	return handler
+	//tracer, log, sticky va rate limit boc ngoai de request bi chan khong di tiep vao proxy
+	return a.chainSecurity.Wrap(a.clientCert.Middleware(a.streaming.Middleware(handler)))
+}
 
-	// return a.chainSecurity.Wrap(handler)
+/*
+*Loi do balancer tu tra loi, call gRPC nhan trang thai gRPC tuong ung thay vi body text
+ */
+func writeError(w http.ResponseWriter, r *http.Request, status int, message string) {
+	if grpc.IsRequest(r) {
+		grpc.WriteError(w, grpc.CodeFromHTTP(status), message)
+		return
+	}
+	http.Error(w, message, status)
 }
 
 func (a *App) GetTLSManager() *tls.ManagerSTL {
 	return a.tlsManager
 }
 
+/*
+*HSTS bao quanh handler cua listener HTTPS, policy cap nhat theo hot reload
+ */
+func (a *App) GetHSTS() *middleware.HSTS {
+	return a.hsts
+}
+
+func (a *App) TLSEnabled() bool {
+	t := a.configManager.GetConfig().TLS
+	return t != nil && t.Enabled
+}
+
 func (a *App) GetConfigManager() *config.ConfigManager {
 	return a.configManager
 }
 
+func identityName(id *tls.ClientIdentity) string {
+	if id == nil {
+		return ""
+	}
+	return id.Subject
+}
+
 func getClientIP(r *http.Request) string {
 	ip, _, err := net.SplitHostPort(r.RemoteAddr)
 	if err != nil {
@@ -215,3 +407,14 @@ func getClientIP(r *http.Request) string {
 func (a *App) GetProviderServer() *provider.ProviderServer {
 	return a.providerServer
 }
+
+/*
+*nil khi config khong co listener tcp nao
+ */
+func (a *App) GetTCPProxy() *tcpproxy.Proxy {
+	return a.tcpProxy
+}
+
+func (a *App) GetAdminServer() *admin.Server {
+	return a.adminServer
+}
@@ -0,0 +1,221 @@
+package app
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nhutphuongasasa/loadbalancer/internal/balancer/strategies"
+	"github.com/nhutphuongasasa/loadbalancer/internal/middleware"
+	"github.com/nhutphuongasasa/loadbalancer/internal/model"
+	"github.com/nhutphuongasasa/loadbalancer/internal/registry"
+	"github.com/nhutphuongasasa/loadbalancer/internal/router"
+	"github.com/nhutphuongasasa/loadbalancer/internal/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+/*
+*Sticky session giu trong bo nho thay redis, context dien giong stickyManager
+ */
+type memoryStickier struct {
+	mu       sync.Mutex
+	sessions map[string][]*model.ServerPair
+	created  int
+}
+
+func newMemoryStickier() *memoryStickier {
+	return &memoryStickier{sessions: make(map[string][]*model.ServerPair)}
+}
+
+func (s *memoryStickier) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cookie, err := r.Cookie(middleware.StickyCookieName)
+		if err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		key := middleware.RedisKeyPrefix + cookie.Value
+		s.mu.Lock()
+		pairs, ok := s.sessions[key]
+		s.mu.Unlock()
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), middleware.StickyBackendKey, pairs)
+		ctx = context.WithValue(ctx, middleware.CacheKey, key)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func (s *memoryStickier) SetStickySession(w http.ResponseWriter, serverName, backendId string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.created++
+	id := "s" + strconv.Itoa(s.created)
+	s.sessions[middleware.RedisKeyPrefix+id] = []*model.ServerPair{{ServerName: serverName, InstanceId: backendId}}
+	http.SetCookie(w, &http.Cookie{Name: middleware.StickyCookieName, Value: id})
+	return nil
+}
+
+func (s *memoryStickier) GetBackendFromContext(r *http.Request) ([]*model.ServerPair, bool) {
+	pairs, ok := r.Context().Value(middleware.StickyBackendKey).([]*model.ServerPair)
+	return pairs, ok
+}
+
+func (s *memoryStickier) GetCacheKeyFromContext(r *http.Request) string {
+	key, _ := r.Context().Value(middleware.CacheKey).(string)
+	return key
+}
+
+func (s *memoryStickier) MigrateSession(ctx context.Context, cacheKey string, pairs []*model.ServerPair, serverName, backendId string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	updated := []*model.ServerPair{{ServerName: serverName, InstanceId: backendId}}
+	for _, p := range pairs {
+		if p.ServerName != serverName {
+			updated = append(updated, p)
+		}
+	}
+	s.sessions[cacheKey] = updated
+	return nil
+}
+
+func (s *memoryStickier) session(key string) []*model.ServerPair {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sessions[middleware.RedisKeyPrefix+key]
+}
+
+/*
+*App toi thieu cho GetHandler: moi request di toi service api
+ */
+func newTestApp(t *testing.T, suite *middleware.SecuritySuite) (*App, chan *model.Server) {
+	t.Helper()
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	routing := filepath.Join(t.TempDir(), "routing.yml")
+	require.NoError(t, os.WriteFile(routing, []byte("rules: []\ndefault_service: api\n"), 0644))
+	rt, err := router.LoadPathRouter(routing, logger)
+	require.NoError(t, err)
+
+	updates := make(chan *model.Server, 16)
+	pool := server.NewServerPool(logger, updates, func(string) strategies.Strategy { return strategies.NewWeightedRoundRobin() })
+	t.Cleanup(pool.Close)
+
+	return &App{
+		serverPool:    pool,
+		router:        rt,
+		chainSecurity: suite,
+		clientCert:    middleware.NewClientCertHeaders(false),
+		streaming:     middleware.NewStreaming(time.Minute),
+		upstreams:     registry.NewUpstreams("", nil),
+		logger:        logger,
+		ctx:           context.Background(),
+	}, updates
+}
+
+/*
+*Backend tra ve instance id cua no
+ */
+func newTestBackend(t *testing.T, id string) *model.Server {
+	t.Helper()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, id)
+	}))
+	t.Cleanup(ts.Close)
+
+	host, port, err := net.SplitHostPort(ts.Listener.Addr().String())
+	require.NoError(t, err)
+	p, _ := strconv.Atoi(port)
+	return model.NewServer(id, "api", host, p, 1, nil, nil)
+}
+
+func serve(t *testing.T, h http.Handler, cookie string) (int, string) {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodGet, "/orders", nil)
+	if cookie != "" {
+		req.AddCookie(&http.Cookie{Name: middleware.StickyCookieName, Value: cookie})
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec.Code, rec.Body.String()
+}
+
+func TestGetHandler_MigratesStickySessionOffDrainingInstance(t *testing.T) {
+	sticky := newMemoryStickier()
+	a, updates := newTestApp(t, middleware.NewSecuritySuit(nil, nil, sticky, nil))
+
+	b1, b2 := newTestBackend(t, "api-1"), newTestBackend(t, "api-2")
+	updates <- b1
+	updates <- b2
+	require.Eventually(t, func() bool { return a.serverPool.GetInstanceServer("api", "api-2") != nil }, time.Second, 5*time.Millisecond)
+
+	sticky.sessions[middleware.RedisKeyPrefix+"abc"] = []*model.ServerPair{
+		{ServerName: "api", InstanceId: "api-1"},
+		{ServerName: "web", InstanceId: "web-1"},
+	}
+	h := a.GetHandler()
+
+	// session con hieu luc thi giu instance cu
+	for i := 0; i < 3; i++ {
+		code, body := serve(t, h, "abc")
+		require.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "api-1", body)
+	}
+
+	b1.SetDraining(true)
+	updates <- b1
+	// cho pool bo instance draining khoi danh sach chon
+	require.Eventually(t, func() bool {
+		return a.serverPool.PickBackend("api", "") == b2 && a.serverPool.PickBackend("api", "") == b2
+	}, time.Second, 5*time.Millisecond)
+
+	code, body := serve(t, h, "abc")
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "api-2", body)
+
+	// session cu duoc chuyen sang instance moi, khong tao session moi, service khac giu nguyen
+	assert.ElementsMatch(t, []*model.ServerPair{
+		{ServerName: "api", InstanceId: "api-2"},
+		{ServerName: "web", InstanceId: "web-1"},
+	}, sticky.session("abc"))
+	assert.Zero(t, sticky.created)
+
+	_, body = serve(t, h, "abc")
+	assert.Equal(t, "api-2", body)
+}
+
+func TestGetHandler_CreatesStickySession(t *testing.T) {
+	sticky := newMemoryStickier()
+	a, updates := newTestApp(t, middleware.NewSecuritySuit(nil, nil, sticky, nil))
+
+	updates <- newTestBackend(t, "api-1")
+	require.Eventually(t, func() bool { return a.serverPool.GetInstanceServer("api", "api-1") != nil }, time.Second, 5*time.Millisecond)
+
+	h := a.GetHandler()
+	code, _ := serve(t, h, "")
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, 1, sticky.created)
+
+	// request sau co cookie dung session da tao
+	serve(t, h, "s1")
+	assert.Equal(t, 1, sticky.created)
+}
@@ -1,50 +1,219 @@
 package app
 
 import (
+	cryptotls "crypto/tls"
 	"fmt"
 	"log/slog"
-	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/nhutphuongasasa/loadbalancer/internal/balancer/strategies"
 	"github.com/nhutphuongasasa/loadbalancer/internal/cache"
 	"github.com/nhutphuongasasa/loadbalancer/internal/config"
 	"github.com/nhutphuongasasa/loadbalancer/internal/middleware"
 	"github.com/nhutphuongasasa/loadbalancer/internal/middleware/rate_limit"
+	"github.com/nhutphuongasasa/loadbalancer/internal/tcpproxy"
 	"github.com/nhutphuongasasa/loadbalancer/internal/tls"
+	"golang.org/x/crypto/acme/autocert"
 )
 
-func initSecuritySuite(logger *slog.Logger, cache *cache.CacheClient) *middleware.SecuritySuite {
+func initSecuritySuite(logger *slog.Logger, cache *cache.CacheClient, cfg *config.Config) *middleware.SecuritySuite {
 	trafficLogger := logger.With("module", "TRAFFIC")
 	securityLogger := logger.With("module", "SECURITY")
 
-	limiter := rate_limit.NewIPRateLimiter(5, 50, logger)
+	rps, burst := rateLimitOf(cfg.RateLimit)
+	limiter := rate_limit.NewIPRateLimiter(rps, burst, logger)
 	loggerMid := middleware.NewLogger(trafficLogger)
-	sticky := middleware.NewStickyManager(securityLogger, cache)
+	var sessionTTL time.Duration
+	if cfg.Session != nil {
+		sessionTTL = cfg.Session.TTL
+	}
+	// session luu tren redis, khong ket noi duoc thi can bang theo strategy binh thuong
+	var sticky middleware.IStickier
+	if cache != nil {
+		sticky = middleware.NewStickyManager(securityLogger, cache, sessionTTL)
+	} else {
+		securityLogger.Warn("Redis is unavailable, sticky sessions are disabled")
+	}
 	tracer := middleware.NewTracer(logger)
 
 	return middleware.NewSecuritySuit(limiter, loggerMid, sticky, tracer)
 }
 
-func initTLSManager(certDir string, logger *slog.Logger) *tls.ManagerSTL {
-	m, err := tls.NewManagerSTL(certDir, tls.WithLogger(logger))
+func initTLSManager(certDir string, t *config.TLSConfig, cacheClient *cache.CacheClient, logger *slog.Logger) (*tls.ManagerSTL, error) {
+	opts := []tls.Option{tls.WithLogger(logger)}
+	if t != nil && len(t.Certificates) > 0 {
+		pairs := make([]tls.CertPair, 0, len(t.Certificates))
+		for _, c := range t.Certificates {
+			pairs = append(pairs, tls.CertPair{CertFile: c.CertFile, KeyFile: c.KeyFile, Default: c.Default})
+		}
+		opts = append(opts, tls.WithCertificates(pairs))
+	}
+
+	if t != nil && t.ClientAuth != nil {
+		opt, err := clientAuthOf(certDir, t.ClientAuth)
+		if err != nil {
+			return nil, fmt.Errorf("init client auth: %w", err)
+		}
+		if opt != nil {
+			opts = append(opts, opt)
+		}
+	}
+
+	opts = append(opts, tls.WithNextProtos(nextProtosOf(t)...))
+
+	if t != nil && t.ACME != nil && t.ACME.Enabled {
+		acmeCfg, err := acmeConfigOf(certDir, t.ACME, cacheClient)
+		if err != nil {
+			return nil, fmt.Errorf("init ACME: %w", err)
+		}
+		opts = append(opts, tls.WithACME(acmeCfg))
+	}
+
+	m, err := tls.NewManagerSTL(certDir, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("init TLS manager: %w", err)
+	}
+	return m, nil
+}
+
+func acmeConfigOf(certDir string, a *config.ACMEConfig, cacheClient *cache.CacheClient) (tls.ACMEConfig, error) {
+	cfg := tls.ACMEConfig{
+		Domains:      a.Domains,
+		Email:        a.Email,
+		DirectoryURL: a.DirectoryURL,
+		RenewBefore:  a.RenewBefore,
+	}
+
+	switch a.Storage {
+	case config.ACMEStorageRedis:
+		if cacheClient == nil {
+			return cfg, fmt.Errorf("redis storage requires a working cache connection")
+		}
+		cfg.Cache = tls.NewRedisCache(cacheClient)
+	default:
+		dir := a.CacheDir
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(certDir, dir)
+		}
+		cfg.Cache = autocert.DirCache(dir)
+	}
+
+	if a.CARoot != "" {
+		pool, err := tls.LoadCAPool(a.CARoot)
+		if err != nil {
+			return cfg, err
+		}
+		cfg.RootCAs = pool
+	}
+
+	return cfg, nil
+}
+
+/*
+*Che do client auth cua listener HTTPS, nil khi tat
+ */
+func clientAuthOf(certDir string, a *config.ClientAuthConfig) (tls.Option, error) {
+	var auth cryptotls.ClientAuthType
+	switch a.Mode {
+	case config.ClientAuthOptional:
+		auth = cryptotls.RequestClientCert
+	case config.ClientAuthVerifyIfGiven:
+		auth = cryptotls.VerifyClientCertIfGiven
+	case config.ClientAuthRequire:
+		auth = cryptotls.RequireAndVerifyClientCert
+	default:
+		return nil, nil
+	}
+
+	if a.CAFile == "" {
+		return tls.WithClientAuth(nil, auth), nil
+	}
+
+	caFile := a.CAFile
+	if !filepath.IsAbs(caFile) {
+		caFile = filepath.Join(certDir, caFile)
+	}
+	pool, err := tls.LoadCAPool(caFile)
 	if err != nil {
-		logger.Error("Failed to init TLS manager", "err", err)
-		os.Exit(1)
+		return nil, err
+	}
+	return tls.WithClientAuth(pool, auth), nil
+}
+
+/*
+*ALPN cua listener HTTPS, h2 dung truoc de client ho tro HTTP/2 chon no
+ */
+func nextProtosOf(t *config.TLSConfig) []string {
+	if t != nil && t.HTTP2 {
+		return []string{"h2", "http/1.1"}
+	}
+	return []string{"http/1.1"}
+}
+
+func forwardClientCertOf(t *config.TLSConfig) bool {
+	return t != nil && t.ClientAuth != nil && t.ClientAuth.ForwardHeaders
+}
+
+/*
+*cert_dir tuong doi thi tinh tu rootDir, mac dinh <rootDir>/keys
+ */
+func certDirOf(rootDir string, t *config.TLSConfig) string {
+	dir := "keys"
+	if t != nil && t.CertDir != "" {
+		dir = t.CertDir
+	}
+	if filepath.IsAbs(dir) {
+		return dir
+	}
+	return filepath.Join(rootDir, dir)
+}
+
+func hstsOf(t *config.TLSConfig) (time.Duration, bool, bool) {
+	if t == nil || t.HSTS == nil {
+		return 0, false, false
+	}
+	return t.HSTS.MaxAge, t.HSTS.IncludeSubdomains, t.HSTS.Preload
+}
+
+/*
+*Proxy L4 chon instance qua ServerPool nhu request HTTP, nil khi khong co listener
+ */
+func initTCPProxy(t *config.TCPConfig, picker tcpproxy.Picker, logger *slog.Logger) *tcpproxy.Proxy {
+	if t == nil || len(t.Listeners) == 0 {
+		return nil
+	}
+
+	listeners := make([]tcpproxy.Listener, 0, len(t.Listeners))
+	for _, l := range t.Listeners {
+		listeners = append(listeners, tcpproxy.Listener{Port: l.Port, Service: l.Service})
+	}
+	return tcpproxy.NewProxy(picker, listeners,
+		tcpproxy.WithLogger(logger),
+		tcpproxy.WithTimeouts(t.ConnectTimeout, t.IdleTimeout),
+	)
+}
+
+/*
+*Selector cua cac rule theo service, rule khong co selector thi bo qua
+ */
+func selectorsOf(rules []config.RouteRule) map[string][]map[string]string {
+	selectors := make(map[string][]map[string]string)
+	for _, rule := range rules {
+		if len(rule.Selector) > 0 {
+			selectors[rule.Service] = append(selectors[rule.Service], rule.Selector)
+		}
 	}
-	return m
+	return selectors
 }
 
-func initConfigManager(rootDir string) *config.ConfigManager {
-	configDir := filepath.Join(rootDir, "config")
-	cfgManager, err := config.NewConfigManager(configDir, func(c *config.Config) {
-		slog.Info("Config reloaded")
-	})
+func initConfigManager(configFile string) (*config.ConfigManager, error) {
+	cfgManager, err := config.NewConfigManagerFromFile(configFile, nil)
 	if err != nil {
-		slog.Error("Failed to init config manager", "err", err)
-		os.Exit(1)
+		return nil, fmt.Errorf("init config manager: %w", err)
 	}
-	return cfgManager
+	return cfgManager, nil
 }
 
 func initStrategy(strategyName string, logger *slog.Logger) (func(string) strategies.Strategy, error) {
@@ -65,6 +234,6 @@ func initStrategy(strategyName string, logger *slog.Logger) (func(string) strate
 			return strategies.NewIPHash()
 		}, nil
 	default:
-		return nil, fmt.Errorf("invalid strategy: %s. Supported: [round_robin, weighted_round_robin]", strategyName)
+		return nil, fmt.Errorf("invalid strategy: %s (supported: %s)", strategyName, strings.Join(config.Strategies(), ", "))
 	}
 }
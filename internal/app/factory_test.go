@@ -0,0 +1,42 @@
+package app
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/nhutphuongasasa/loadbalancer/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// validate va serve phai dong y voi nhau: strategy nao config nhan thi app dung duoc
+func TestInitStrategy_AcceptsEveryValidStrategy(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	strategyErrors := func(name string) []config.Problem {
+		cfg := config.Defaults()
+		cfg.Strategy.Strategy = name
+
+		var out []config.Problem
+		for _, p := range config.ValidateConfig(cfg).Errors() {
+			if p.Path == "load_balancer.strategy" {
+				out = append(out, p)
+			}
+		}
+		return out
+	}
+
+	require.NotEmpty(t, config.Strategies())
+	for _, name := range config.Strategies() {
+		assert.Empty(t, strategyErrors(name), name)
+
+		factory, err := initStrategy(name, logger)
+		require.NoError(t, err, name)
+		assert.NotNil(t, factory("svc"), name)
+	}
+
+	assert.NotEmpty(t, strategyErrors("weight_round_robin"))
+	_, err := initStrategy("weight_round_robin", logger)
+	assert.Error(t, err)
+}
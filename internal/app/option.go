@@ -0,0 +1,55 @@
+package app
+
+import (
+	"path/filepath"
+
+	"github.com/nhutphuongasasa/loadbalancer/internal/config"
+)
+
+type options struct {
+	configFile  string
+	routingFile string
+	overrides   map[string]any
+}
+
+type Option func(*options)
+
+/*
+*Thu muc chua config.yml va routing.yml, mac dinh la <rootDir>/config
+ */
+func WithConfigDir(dir string) Option {
+	return func(o *options) {
+		o.configFile = filepath.Join(dir, "config.yml")
+		o.routingFile = filepath.Join(dir, "routing.yml")
+	}
+}
+
+/*
+*Dung vi tri config tim duoc boi config.Discover (ho tro file gop)
+ */
+func WithConfigLocation(loc *config.Location) Option {
+	return func(o *options) {
+		o.configFile = loc.ConfigFile
+		o.routingFile = loc.RoutingFile
+	}
+}
+
+/*
+*Ghi de 1 key config (vd server.port, log.level) tu flag CLI
+ */
+func WithOverride(key string, value any) Option {
+	return func(o *options) {
+		o.overrides[key] = value
+	}
+}
+
+func newOptions(rootDir string, opts []Option) *options {
+	o := &options{overrides: make(map[string]any)}
+	WithConfigDir(filepath.Join(rootDir, "config"))(o)
+
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return o
+}
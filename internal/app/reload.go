@@ -0,0 +1,211 @@
+package app
+
+import (
+	"net"
+	"reflect"
+	"slices"
+	"strconv"
+
+	"golang.org/x/time/rate"
+
+	"github.com/nhutphuongasasa/loadbalancer/internal/config"
+	"github.com/nhutphuongasasa/loadbalancer/internal/model"
+	"github.com/nhutphuongasasa/loadbalancer/internal/utils"
+)
+
+/*
+*Ap dung thay doi config.yml vao balancer dang chay, moi subsystem chi nhan phan cua minh.
+*Loi o 1 phan chi ghi log, cac phan khac van duoc ap dung
+ */
+func (a *App) applyConfig(d *config.Diff) {
+	a.logger.Info("Applying config changes", "sections", d.Sections())
+
+	if d.LogLevel != nil {
+		utils.SetLogLevel(d.LogLevel.To)
+		a.logger.Info("Log level changed", "from", d.LogLevel.From, "to", d.LogLevel.To)
+	}
+
+	if d.Strategy != nil {
+		factory, err := initStrategy(d.Strategy.To, a.logger)
+		if err != nil {
+			a.logger.Error("Failed to apply strategy", "strategy", d.Strategy.To, "err", err)
+		} else {
+			a.serverPool.SetStrategyFactory(factory)
+			a.logger.Info("Load balancing strategy changed", "from", d.Strategy.From, "to", d.Strategy.To)
+		}
+	}
+
+	if d.HealthCheckInterval != nil && a.registry != nil {
+		a.registry.SetCheckInterval(d.HealthCheckInterval.To)
+	}
+
+	if d.StreamIdleTimeout != nil {
+		a.streaming.SetIdleTimeout(d.StreamIdleTimeout.To)
+		a.logger.Info("Stream idle timeout changed, applied to new streams", "from", d.StreamIdleTimeout.From, "to", d.StreamIdleTimeout.To)
+	}
+
+	if d.RateLimit != nil && a.chainSecurity.Limiter() != nil {
+		rps, burst := rateLimitOf(d.RateLimit.To)
+		a.chainSecurity.Limiter().SetLimit(rps, burst)
+		a.logger.Info("Rate limit changed", "requests_per_second", rps, "burst", burst)
+	}
+
+	if d.Cache != nil {
+		a.applyCache(d.Cache)
+	}
+
+	if d.Backends != nil {
+		a.applyBackends(d.Backends)
+	}
+
+	if d.TLS != nil {
+		a.applyTLS(d.TLS)
+	}
+
+	if d.Upstreams != nil {
+		a.applyUpstreams(d)
+	}
+
+	if d.TCP != nil {
+		a.applyTCP(d.TCP)
+	}
+}
+
+/*
+*Timeout ap dung cho ket noi moi, listener chi mo luc khoi dong
+ */
+func (a *App) applyTCP(change *config.TCPChange) {
+	from, to := change.From, change.To
+	if from == nil {
+		from = &config.TCPConfig{}
+	}
+	if to == nil {
+		to = &config.TCPConfig{}
+	}
+
+	if !reflect.DeepEqual(from.Listeners, to.Listeners) {
+		a.logger.Warn("TCP listeners changed, restart required to apply")
+	}
+
+	if a.tcpProxy != nil && (from.ConnectTimeout != to.ConnectTimeout || from.IdleTimeout != to.IdleTimeout) {
+		a.tcpProxy.SetTimeouts(to.ConnectTimeout, to.IdleTimeout)
+		a.logger.Info("TCP timeouts changed, applied to new connections", "connect_timeout", to.ConnectTimeout, "idle_timeout", to.IdleTimeout)
+	}
+}
+
+/*
+*Instance dang ky qua API giu transport cu den lan dang ky tiep theo,
+*backend tinh cua service bi doi duoc dang ky lai ngay
+ */
+func (a *App) applyUpstreams(d *config.Diff) {
+	a.upstreams.Set(d.Upstreams.To)
+
+	for _, be := range d.New.BackEnds {
+		if slices.Contains(d.Upstreams.Services, be.Service) {
+			a.registerStaticBackend(be)
+		}
+	}
+	a.logger.Info("Upstream settings changed, applied to new registrations", "services", d.Upstreams.Services)
+}
+
+/*
+*Chi HSTS doi duoc luc chay, listener va danh sach chung chi can restart
+*(noi dung file chung chi van duoc TLS manager nap lai)
+ */
+func (a *App) applyTLS(change *config.TLSChange) {
+	a.hsts.SetPolicy(hstsOf(change.To))
+	a.clientCert.SetForward(forwardClientCertOf(change.To))
+	a.logger.Info("HSTS policy changed", "header", a.hsts.Header())
+
+	if !reflect.DeepEqual(listenerOf(change.From), listenerOf(change.To)) {
+		a.logger.Warn("TLS listener, HTTP/2, certificate list, ACME or client auth settings changed, restart required to apply")
+	}
+}
+
+/*
+*Phan config tls chi ap dung khi khoi dong
+ */
+func listenerOf(t *config.TLSConfig) config.TLSConfig {
+	if t == nil {
+		return config.TLSConfig{}
+	}
+	// forward_headers doi duoc luc chay
+	var clientAuth *config.ClientAuthConfig
+	if t.ClientAuth != nil {
+		c := *t.ClientAuth
+		c.ForwardHeaders = false
+		clientAuth = &c
+	}
+	return config.TLSConfig{Enabled: t.Enabled, Port: t.Port, CertDir: t.CertDir, HTTP: t.HTTP, HTTP2: t.HTTP2, Certificates: t.Certificates, ACME: t.ACME, ClientAuth: clientAuth}
+}
+
+func (a *App) applyCache(change *config.CacheChange) {
+	if a.cacheShared == nil {
+		a.logger.Warn("Cache config changed but cache client was never initialized, restart required")
+		return
+	}
+
+	if err := a.cacheShared.Reconfigure(change.To); err != nil {
+		// client cu van hoat dong
+		a.logger.Error("Failed to reconnect cache, keeping previous connection", "err", err)
+		return
+	}
+	a.logger.Info("Cache connection reconfigured", "addr", change.To.Addr)
+}
+
+/*
+*Backend tinh trong config duoc dang ky vao registry voi instance id = host:port
+ */
+func (a *App) applyBackends(change *config.BackendsChange) {
+	for _, be := range change.Removed {
+		host, port, err := be.HostPort()
+		if err != nil {
+			continue
+		}
+		if err := a.registry.Deregister(be.Service, staticInstanceID(host, port)); err != nil {
+			a.logger.Warn("Failed to remove static backend", "service", be.Service, "url", be.Url, "err", err)
+		}
+	}
+
+	for _, be := range change.Added {
+		a.registerStaticBackend(be)
+	}
+
+	for _, be := range change.Updated {
+		host, port, err := be.HostPort()
+		if err != nil {
+			continue
+		}
+		if _, err := a.registry.SetWeight(be.Service, staticInstanceID(host, port), be.Weight, 0); err != nil {
+			a.logger.Warn("Failed to update static backend weight", "service", be.Service, "url", be.Url, "err", err)
+		}
+	}
+}
+
+func (a *App) registerStaticBackend(be *config.BackEndConfig) {
+	host, port, err := be.HostPort()
+	if err != nil {
+		a.logger.Error("Invalid static backend", "service", be.Service, "url", be.Url, "err", err)
+		return
+	}
+
+	var upstream *model.UpstreamTLS
+	if be.Scheme() == "https" {
+		upstream = &model.UpstreamTLS{Enabled: true}
+	}
+
+	if err := a.registry.RegisterStatic(be.Service, staticInstanceID(host, port), host, port, be.Weight, upstream); err != nil {
+		a.logger.Error("Failed to register static backend", "service", be.Service, "url", be.Url, "err", err)
+	}
+}
+
+func staticInstanceID(host string, port int) string {
+	return net.JoinHostPort(host, strconv.Itoa(port))
+}
+
+func rateLimitOf(cfg *config.RateLimitConfig) (rate.Limit, int) {
+	if cfg == nil {
+		cfg = config.Defaults().RateLimit
+	}
+	return rate.Limit(cfg.RequestsPerSecond), cfg.Burst
+}
@@ -0,0 +1,48 @@
+package app
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/nhutphuongasasa/loadbalancer/internal/config"
+	"github.com/nhutphuongasasa/loadbalancer/internal/middleware"
+	"github.com/nhutphuongasasa/loadbalancer/internal/middleware/rate_limit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyConfig_RateLimitThrottlesRequests(t *testing.T) {
+	limiter := rate_limit.NewIPRateLimiter(1000, 1000, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	a, updates := newTestApp(t, middleware.NewSecuritySuit(limiter, nil, nil, nil))
+
+	updates <- newTestBackend(t, "api-1")
+	require.Eventually(t, func() bool { return a.serverPool.GetInstanceServer("api", "api-1") != nil }, time.Second, 5*time.Millisecond)
+
+	h := a.GetHandler()
+	request := func(ip string) int {
+		req := httptest.NewRequest(http.MethodGet, "/orders", nil)
+		req.RemoteAddr = ip + ":40000"
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	for i := 0; i < 5; i++ {
+		require.Equal(t, http.StatusOK, request("10.0.0.1"))
+	}
+
+	a.applyConfig(&config.Diff{RateLimit: &config.RateLimitChange{
+		From: &config.RateLimitConfig{RequestsPerSecond: 1000, Burst: 1000},
+		To:   &config.RateLimitConfig{RequestsPerSecond: 0.01, Burst: 2},
+	}})
+
+	// gioi han moi co hieu luc ngay tren request tiep theo
+	assert.Equal(t, http.StatusOK, request("10.0.0.2"))
+	assert.Equal(t, http.StatusOK, request("10.0.0.2"))
+	assert.Equal(t, http.StatusTooManyRequests, request("10.0.0.2"))
+	assert.Equal(t, uint64(1), limiter.Stats().Rejected)
+}
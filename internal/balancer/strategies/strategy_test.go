@@ -0,0 +1,62 @@
+package strategies
+
+import (
+	"testing"
+
+	"github.com/nhutphuongasasa/loadbalancer/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func countPicks(s Strategy, backends []*model.Server, n int) map[string]int {
+	counts := make(map[string]int)
+	for i := 0; i < n; i++ {
+		counts[s.Pick(backends, "").InstanceID]++
+	}
+	return counts
+}
+
+func TestWeightedRoundRobin_FollowsRuntimeWeights(t *testing.T) {
+	a := model.NewServer("a", "svc", "10.0.0.1", 80, 3, nil, nil)
+	b := model.NewServer("b", "svc", "10.0.0.2", 80, 1, nil, nil)
+	backends := []*model.Server{a, b}
+
+	wrr := NewWeightedRoundRobin()
+	counts := countPicks(wrr, backends, 40)
+	assert.Equal(t, 30, counts["a"])
+	assert.Equal(t, 10, counts["b"])
+
+	// doi weight luc runtime co hieu luc ngay
+	a.SetWeight(1)
+	counts = countPicks(wrr, backends, 40)
+	assert.Equal(t, 20, counts["a"])
+	assert.Equal(t, 20, counts["b"])
+
+	b.SetWeight(0)
+	counts = countPicks(wrr, backends, 10)
+	assert.Equal(t, 10, counts["a"])
+}
+
+func TestIPHash_SkipsZeroWeight(t *testing.T) {
+	a := model.NewServer("a", "svc", "10.0.0.1", 80, 1, nil, nil)
+	b := model.NewServer("b", "svc", "10.0.0.2", 80, 1, nil, nil)
+	backends := []*model.Server{a, b}
+
+	ih := NewIPHash()
+	first := ih.Pick(backends, "192.168.1.10:5555")
+	assert.Equal(t, first, ih.Pick(backends, "192.168.1.10:6666"), "same client IP must map to same backend")
+
+	first.SetWeight(0)
+	assert.NotEqual(t, first.InstanceID, ih.Pick(backends, "192.168.1.10").InstanceID)
+}
+
+func TestLeastConn_SkipsZeroWeight(t *testing.T) {
+	a := model.NewServer("a", "svc", "10.0.0.1", 80, 1, nil, nil)
+	b := model.NewServer("b", "svc", "10.0.0.2", 80, 1, nil, nil)
+	b.IncConn()
+
+	lc := NewWeightedLeastConnections()
+	assert.Equal(t, "a", lc.Pick([]*model.Server{a, b}, "").InstanceID)
+
+	a.SetWeight(0)
+	assert.Equal(t, "b", lc.Pick([]*model.Server{a, b}, "").InstanceID)
+}
@@ -14,6 +14,8 @@ func NewIPHash() *IPHash {
 	return &IPHash{}
 }
 
+// Pick chọn server theo hash của IP, phân bổ theo weight:
+// server weight 2 chiếm gấp đôi vùng hash so với server weight 1
 func (ih *IPHash) Pick(servers []*model.Server, clientIP string) *model.Server {
 	if len(servers) == 0 {
 		return nil
@@ -26,21 +28,36 @@ func (ih *IPHash) Pick(servers []*model.Server, clientIP string) *model.Server {
 
 	hash := crc32.ChecksumIEEE([]byte(ip))
 
-	// Chỉ xét healthy servers
+	// Chỉ xét healthy servers có weight > 0
 	var healthy []*model.Server
+	var weights []int
+	totalWeight := 0
 	for _, srv := range servers {
-		if srv.IsHealthy() {
-			healthy = append(healthy, srv)
+		if !srv.IsHealthy() {
+			continue
 		}
+		weight := srv.GetWeight()
+		if weight <= 0 {
+			continue
+		}
+		healthy = append(healthy, srv)
+		weights = append(weights, weight)
+		totalWeight += weight
 	}
 
 	if len(healthy) == 0 {
 		return ih.fallback(servers)
 	}
 
-	index := int(hash % uint32(len(healthy)))
+	point := int(hash % uint32(totalWeight))
+	for i, weight := range weights {
+		if point < weight {
+			return healthy[i]
+		}
+		point -= weight
+	}
 
-	return healthy[index]
+	return healthy[len(healthy)-1]
 }
 
 func (ih *IPHash) fallback(servers []*model.Server) *model.Server {
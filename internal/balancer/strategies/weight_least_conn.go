@@ -27,7 +27,7 @@ func (w *WeightedLeastConnections) Pick(servers []*model.Server, _ string) *mode
 
 		weight := float64(srv.GetWeight())
 		if weight <= 0 {
-			weight = 1
+			continue // weight 0 = không nhận traffic
 		}
 
 		conns := float64(srv.GetActiveConns())
@@ -7,28 +7,31 @@ import (
 	"github.com/nhutphuongasasa/loadbalancer/internal/model"
 )
 
-type WeightedServer struct {
-	server          *model.Server
-	effectiveWeight int // điều chỉnh theo health
-	currentWeight   int // biến đổi trong quá trình chọn
-}
-
 type WeightedRoundRobin struct {
-	mu sync.Mutex // bảo vệ danh sách weighted servers nếu cần rebuild
+	mu             sync.Mutex     // bảo vệ currentWeights
+	currentWeights map[string]int // instanceID -> current weight, giữ lại giữa các lần chọn
 }
 
 func NewWeightedRoundRobin() Strategy {
-	return &WeightedRoundRobin{}
+	return &WeightedRoundRobin{
+		currentWeights: make(map[string]int),
+	}
 }
 
-// Pick chọn server theo smooth weighted round-robin
+// Pick chọn server theo smooth weighted round-robin.
+// Weight được đọc lại mỗi lần chọn nên thay đổi weight lúc runtime có hiệu lực ngay
 func (w *WeightedRoundRobin) Pick(backends []*model.Server, _ string) *model.Server {
 	if len(backends) == 0 {
 		return nil
 	}
 
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
 	var totalWeight int
-	weighted := make([]*WeightedServer, 0, len(backends))
+	var best *model.Server
+	bestCurrentWeight := math.MinInt
+	seen := make(map[string]struct{}, len(backends))
 
 	for _, s := range backends {
 		if !s.IsHealthy() {
@@ -37,48 +40,36 @@ func (w *WeightedRoundRobin) Pick(backends []*model.Server, _ string) *model.Ser
 
 		weight := s.GetWeight()
 		if weight <= 0 {
-			weight = 1
+			continue // weight 0 = không nhận traffic
 		}
 
-		ws := &WeightedServer{
-			server:          s,
-			effectiveWeight: weight,
-			currentWeight:   weight, // khởi tạo ban đầu = weight
-		}
-		weighted = append(weighted, ws)
-		totalWeight += ws.effectiveWeight
-	}
+		id := s.GetID()
+		seen[id] = struct{}{}
 
-	if len(weighted) == 0 || totalWeight == 0 {
-		// fallback: chọn server đầu tiên hoặc random, tùy bạn
-		if len(backends) > 0 {
-			return backends[0]
+		// Tăng currentWeight cho tất cả và tìm max
+		w.currentWeights[id] += weight
+		totalWeight += weight
+
+		if w.currentWeights[id] > bestCurrentWeight {
+			best = s
+			bestCurrentWeight = w.currentWeights[id]
 		}
-		return nil
 	}
 
-	var best *WeightedServer
-	var bestCurrentWeight = math.MinInt
-
-	w.mu.Lock()
-	defer w.mu.Unlock()
-
-	// Tăng currentWeight cho tất cả và tìm max
-	for _, ws := range weighted {
-		ws.currentWeight += ws.effectiveWeight
-
-		if ws.currentWeight > bestCurrentWeight {
-			best = ws
-			bestCurrentWeight = ws.currentWeight
+	// Dọn state của instance không còn trong danh sách
+	for id := range w.currentWeights {
+		if _, ok := seen[id]; !ok {
+			delete(w.currentWeights, id)
 		}
 	}
 
 	if best == nil {
-		return nil
+		// fallback: chọn server đầu tiên
+		return backends[0]
 	}
 
 	// Chọn và trừ tổng weight để "smooth"
-	best.currentWeight -= totalWeight
+	w.currentWeights[best.GetID()] -= totalWeight
 
-	return best.server
+	return best
 }
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/nhutphuongasasa/loadbalancer/internal/config"
@@ -12,7 +13,7 @@ import (
 )
 
 type CacheClient struct {
-	client *redis.Client
+	client atomic.Pointer[redis.Client] // doi duoc luc chay khi config cache thay doi
 	config *config.CacheConfig
 	mu     sync.Mutex
 	closed bool
@@ -28,6 +29,17 @@ func NewCacheClient(cfg *config.CacheConfig) (*CacheClient, error) {
 		return nil, fmt.Errorf("redis config is required")
 	}
 
+	client, err := dialRedis(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &CacheClient{config: cfg}
+	c.client.Store(client)
+	return c, nil
+}
+
+func dialRedis(cfg *config.CacheConfig) (*redis.Client, error) {
 	client := redis.NewClient(&redis.Options{
 		Addr:         cfg.Addr,
 		Password:     cfg.Password,
@@ -48,10 +60,43 @@ func NewCacheClient(cfg *config.CacheConfig) (*CacheClient, error) {
 		return nil, fmt.Errorf("cannot connect to redis: %w", err)
 	}
 
-	return &CacheClient{
-		client: client,
-		config: cfg,
-	}, nil
+	return client, nil
+}
+
+/*
+*Ket noi toi redis theo config moi, chi thay client cu khi ket noi moi thanh cong.
+*Client cu duoc dong sau khi thay de request dang chay khong bi loi
+ */
+func (r *CacheClient) Reconfigure(cfg *config.CacheConfig) error {
+	if cfg == nil {
+		return fmt.Errorf("redis config is required")
+	}
+
+	client, err := dialRedis(cfg)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		client.Close()
+		return fmt.Errorf("cache client is closed")
+	}
+
+	old := r.client.Swap(client)
+	r.config = cfg
+
+	if old != nil {
+		go func() {
+			// cho cac lenh dang dung client cu ket thuc
+			time.Sleep(cfg.Timeout + time.Second)
+			old.Close()
+		}()
+	}
+
+	return nil
 }
 
 func GetInstance(cfg *config.CacheConfig) *CacheClient {
@@ -66,7 +111,7 @@ func GetInstance(cfg *config.CacheConfig) *CacheClient {
 }
 
 func (r *CacheClient) Client() *redis.Client {
-	return r.client
+	return r.client.Load()
 }
 
 func (r *CacheClient) Close() error {
@@ -78,5 +123,5 @@ func (r *CacheClient) Close() error {
 	}
 
 	r.closed = true
-	return r.client.Close()
+	return r.client.Load().Close()
 }
@@ -10,11 +10,11 @@ import (
 )
 
 func (r *CacheClient) SetString(ctx context.Context, key string, value string, expiration time.Duration) error {
-	return r.client.Set(ctx, key, value, expiration).Err()
+	return r.Client().Set(ctx, key, value, expiration).Err()
 }
 
 func (r *CacheClient) GetString(ctx context.Context, key string) (string, error) {
-	val, err := r.client.Get(ctx, key).Result()
+	val, err := r.Client().Get(ctx, key).Result()
 	if err == redis.Nil {
 		return "", nil
 	}
@@ -22,11 +22,11 @@ func (r *CacheClient) GetString(ctx context.Context, key string) (string, error)
 }
 
 func (r *CacheClient) SetStruct(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
-	return r.client.Set(ctx, key, value, expiration).Err()
+	return r.Client().Set(ctx, key, value, expiration).Err()
 }
 
 func (r *CacheClient) GetStruct(ctx context.Context, key string, dest interface{}) error {
-	err := r.client.Get(ctx, key).Scan(dest)
+	err := r.Client().Get(ctx, key).Scan(dest)
 	if err == redis.Nil {
 		return nil
 	}
@@ -34,23 +34,23 @@ func (r *CacheClient) GetStruct(ctx context.Context, key string, dest interface{
 }
 
 func (r *CacheClient) Del(ctx context.Context, keys ...string) (int64, error) {
-	return r.client.Del(ctx, keys...).Result()
+	return r.Client().Del(ctx, keys...).Result()
 }
 
 func (r *CacheClient) Incr(ctx context.Context, key string) (int64, error) {
-	return r.client.Incr(ctx, key).Result()
+	return r.Client().Incr(ctx, key).Result()
 }
 
 func (r *CacheClient) Expire(ctx context.Context, key string, expiration time.Duration) (bool, error) {
-	return r.client.Expire(ctx, key, expiration).Result()
+	return r.Client().Expire(ctx, key, expiration).Result()
 }
 
 func (r *CacheClient) TTL(ctx context.Context, key string) (time.Duration, error) {
-	return r.client.TTL(ctx, key).Result()
+	return r.Client().TTL(ctx, key).Result()
 }
 
 func (r *CacheClient) Exists(ctx context.Context, key string) (bool, error) {
-	n, err := r.client.Exists(ctx, key).Result()
+	n, err := r.Client().Exists(ctx, key).Result()
 	return n == 1, err
 }
 
@@ -72,7 +72,7 @@ func (r *CacheClient) keyExists(ctx context.Context, key string) bool {
 }
 
 func (r *CacheClient) setNewArray(ctx context.Context, key string, arr interface{}, ttl time.Duration) error {
-	return r.client.Set(ctx, key, arr, ttl).Err()
+	return r.Client().Set(ctx, key, arr, ttl).Err()
 }
 
 func (r *CacheClient) appendAndExtendTTL(ctx context.Context, key string, arr interface{}) error {
@@ -93,7 +93,7 @@ func (r *CacheClient) appendAndExtendTTL(ctx context.Context, key string, arr in
 		return err
 	}
 
-	pipe := r.client.Pipeline()
+	pipe := r.Client().Pipeline()
 	pipe.Set(ctx, key, updated, 0)
 	pipe.Expire(ctx, key, 15*time.Minute)
 	_, err = pipe.Exec(ctx)
@@ -103,7 +103,7 @@ func (r *CacheClient) appendAndExtendTTL(ctx context.Context, key string, arr in
 
 func (r *CacheClient) getCurrentArray(ctx context.Context, key string) ([]json.RawMessage, error) {
 	var current []json.RawMessage
-	err := r.client.Get(ctx, key).Scan(&current)
+	err := r.Client().Get(ctx, key).Scan(&current)
 	if err == redis.Nil {
 		return []json.RawMessage{}, nil
 	}
@@ -129,7 +129,7 @@ func (r *CacheClient) GetArray(ctx context.Context, key string, dest interface{}
 		return errors.New("dest must be a pointer to slice (e.g. &[]string)")
 	}
 
-	err := r.client.Get(ctx, key).Scan(dest)
+	err := r.Client().Get(ctx, key).Scan(dest)
 	if err == redis.Nil {
 		return nil
 	}
@@ -138,3 +138,23 @@ func (r *CacheClient) GetArray(ctx context.Context, key string, dest interface{}
 	}
 	return nil
 }
+
+/*
+*Ghi de toan bo mang (dang JSON), ttl = 0 thi giu nguyen TTL hien tai cua key
+ */
+func (r *CacheClient) ReplaceArray(ctx context.Context, key string, arr interface{}, ttl time.Duration) error {
+	if arr == nil {
+		return errors.New("arr cannot be nil")
+	}
+
+	data, err := json.Marshal(arr)
+	if err != nil {
+		return err
+	}
+
+	if ttl == 0 {
+		ttl = redis.KeepTTL
+	}
+
+	return r.Client().Set(ctx, key, data, ttl).Err()
+}
@@ -1,44 +1,338 @@
 package config
 
 import (
+	"fmt"
+	"net/url"
+	"strconv"
 	"time"
 
 	"github.com/spf13/viper"
 )
 
+const defaultHealthCheckInterval = 10 * time.Second
+
+/*
+*Schema config thong nhat. Cac section co the tach ra file rieng qua include,
+*routing co the nam trong section routing hoac file routing.yml rieng.
+*Tag desc duoc dung de sinh JSON Schema
+ */
 type Config struct {
-	Server      *ServerConfig    `mapstructure:"server"`
-	BackEnds    []*BackEndConfig `mapstructure:"backends"`
-	Strategy    *StrategyConfig  `mapstructure:"load_balancer"`
-	LogConfig   *LogConfig       `mapstructure:"log"`
-	RedisConfig *CacheConfig     `mapstructure:"cache"`
+	Version     int               `mapstructure:"version" desc:"Schema version of this file"`
+	Include     []string          `mapstructure:"include" desc:"Extra files merged before this one, relative to this file; globs allowed"`
+	Server      *ServerConfig     `mapstructure:"server" desc:"Public proxy listener"`
+	TLS         *TLSConfig        `mapstructure:"tls" desc:"HTTPS listener for the public proxy"`
+	BackEnds    []*BackEndConfig  `mapstructure:"backends" desc:"Static backends registered without heartbeats"`
+	Strategy    *StrategyConfig   `mapstructure:"load_balancer" desc:"Load balancing strategy"`
+	LogConfig   *LogConfig        `mapstructure:"log" desc:"Logging"`
+	RedisConfig *CacheConfig      `mapstructure:"cache" desc:"Redis connection used for sticky sessions"`
+	Registry    *RegistryConfig   `mapstructure:"registry" desc:"Service registry API"`
+	Admin       *AdminConfig      `mapstructure:"admin" desc:"Admin API"`
+	RateLimit   *RateLimitConfig  `mapstructure:"rate_limit" desc:"Per client IP rate limit"`
+	Session     *SessionConfig    `mapstructure:"session" desc:"Sticky sessions"`
+	Resilience  *ResilienceConfig `mapstructure:"resilience" desc:"Circuit breaker and retry applied to every upstream instance"`
+	Upstreams   []*UpstreamConfig `mapstructure:"upstreams" desc:"Per service settings for connections to backends"`
+	TCP         *TCPConfig        `mapstructure:"tcp" desc:"Layer 4 listeners balancing raw TCP connections across a service"`
+	Routing     *RoutingConfig    `mapstructure:"routing" desc:"Routing rules; may live in a separate routing.yml instead"`
 }
 
 type LogConfig struct {
-	Level  string `mapstructure:"level"`
-	Format string `mapstructure:"format"`
+	Level  string `mapstructure:"level" desc:"debug, info, warn or error"`
+	Format string `mapstructure:"format" desc:"text or json"`
 }
 
+/*
+*Backend tinh khai bao trong config, duoc dang ky vao registry nhu 1 instance khong can heartbeat
+ */
 type BackEndConfig struct {
-	Url    string `mapstructure:"url"`
-	Weight int    `mapstructure:"weight"`
+	Service string `mapstructure:"service" desc:"Service name the backend belongs to"`
+	Url     string `mapstructure:"url" desc:"Backend base url, e.g. http://10.0.0.1:8080, or tcp://10.0.0.1:5432 for services behind tcp listeners"`
+	Weight  int    `mapstructure:"weight" desc:"Relative weight, 0 means 1"`
+}
+
+func (b *BackEndConfig) Key() string {
+	return b.Service + "|" + b.Url
+}
+
+func (b *BackEndConfig) Scheme() string {
+	if u, err := url.Parse(b.Url); err == nil && u.Scheme != "" {
+		return u.Scheme
+	}
+	return "http"
+}
+
+/*
+*Tach host va port tu url cua backend, thieu port thi lay theo scheme
+ */
+func (b *BackEndConfig) HostPort() (string, int, error) {
+	u, err := url.Parse(b.Url)
+	if err != nil {
+		return "", 0, err
+	}
+	if u.Hostname() == "" {
+		return "", 0, fmt.Errorf("url %q has no host", b.Url)
+	}
+
+	portStr := u.Port()
+	if portStr == "" {
+		switch u.Scheme {
+		case "https":
+			portStr = "443"
+		case "tcp":
+			return "", 0, fmt.Errorf("url %q needs a port", b.Url)
+		default:
+			portStr = "80"
+		}
+	}
+
+	port, err := strconv.Atoi(portStr)
+	if err != nil || port <= 0 || port > 65535 {
+		return "", 0, fmt.Errorf("url %q has invalid port", b.Url)
+	}
+
+	return u.Hostname(), port, nil
+}
+
+type RateLimitConfig struct {
+	RequestsPerSecond float64 `mapstructure:"requests_per_second" desc:"Sustained requests per second per client IP"`
+	Burst             int     `mapstructure:"burst" desc:"Maximum burst per client IP"`
 }
 
 type ServerConfig struct {
-	Port                int    `mapstructure:"port"`
-	HealthCheckInterval string `mapstructure:"health_check_interval"`
+	Port                int    `mapstructure:"port" desc:"Public proxy port"`
+	HealthCheckInterval string `mapstructure:"health_check_interval" desc:"Interval between active health checks" schema:"duration"`
+	H2C                 bool   `mapstructure:"h2c" desc:"Also accept cleartext HTTP/2 on this port, with prior knowledge or Upgrade: h2c"`
+
+	ReadTimeout       time.Duration `mapstructure:"read_timeout" desc:"Max time to read a request, streams use stream_idle_timeout instead"`
+	WriteTimeout      time.Duration `mapstructure:"write_timeout" desc:"Max time to write a response, streams use stream_idle_timeout instead"`
+	IdleTimeout       time.Duration `mapstructure:"idle_timeout" desc:"Keep-alive connections without a request are closed after this long"`
+	StreamIdleTimeout time.Duration `mapstructure:"stream_idle_timeout" desc:"WebSocket, SSE, gRPC and streaming uploads are closed after this long without traffic, 0 never"`
 }
 
+/*
+*HTTPS cho listener public. Khi bat, server.port chi con redirect (hoac van phuc vu, hoac tat) theo http
+ */
+type TLSConfig struct {
+	Enabled bool        `mapstructure:"enabled" desc:"Serve the public proxy over HTTPS"`
+	Port    int         `mapstructure:"port" desc:"HTTPS port"`
+	CertDir string      `mapstructure:"cert_dir" desc:"Directory scanned for certificate pairs (cert.pem/key.pem, name.crt/name.key, name.pem/name-key.pem), relative to the install root; reloaded on change"`
+	HTTP    string      `mapstructure:"http" desc:"What server.port does when TLS is enabled: redirect, serve or off"` // redirect | serve | off
+	HTTP2   bool        `mapstructure:"http2" desc:"Offer HTTP/2 to clients through ALPN"`
+	HSTS    *HSTSConfig `mapstructure:"hsts" desc:"Strict-Transport-Security header on HTTPS responses"`
+
+	Certificates []*CertificateConfig `mapstructure:"certificates" desc:"Explicit certificate pairs selected by SNI; when set, cert_dir is not scanned"`
+	ACME         *ACMEConfig          `mapstructure:"acme" desc:"Automatic certificate issuance and renewal through ACME"`
+	ClientAuth   *ClientAuthConfig    `mapstructure:"client_auth" desc:"Client certificate authentication (mTLS) on the HTTPS listener"`
+}
+
+/*
+*Kiem tra client cert tren listener HTTPS. Route co the doi hoi danh tinh cu the qua client_cert
+ */
+type ClientAuthConfig struct {
+	Mode           string `mapstructure:"mode" desc:"none, optional (request without verifying), verify_if_given or require"` // none | optional | verify_if_given | require
+	CAFile         string `mapstructure:"ca_file" desc:"PEM bundle of CAs allowed to sign client certificates, relative to cert_dir"`
+	ForwardHeaders bool   `mapstructure:"forward_headers" desc:"Forward the certificate identity to backends as X-Client-Cert-* headers"`
+}
+
+const (
+	ClientAuthNone          = "none"
+	ClientAuthOptional      = "optional"
+	ClientAuthVerifyIfGiven = "verify_if_given"
+	ClientAuthRequire       = "require"
+)
+
+/*
+*Cap chung chi tu dong qua ACME, domain o day uu tien hon chung chi tinh
+ */
+type ACMEConfig struct {
+	Enabled      bool          `mapstructure:"enabled"`
+	Email        string        `mapstructure:"email" desc:"Contact address for expiry notices from the CA"`
+	Domains      []string      `mapstructure:"domains" desc:"Domains to obtain certificates for; wildcards are not supported"`
+	DirectoryURL string        `mapstructure:"directory_url" desc:"ACME directory, e.g. https://localhost:14000/dir for Pebble"`
+	AcceptTOS    bool          `mapstructure:"accept_tos" desc:"Accept the terms of service of the CA, required"`
+	Storage      string        `mapstructure:"storage" desc:"Where account keys and certificates are kept: dir or redis (shared by replicas)"` // dir | redis
+	CacheDir     string        `mapstructure:"cache_dir" desc:"Directory used by dir storage, relative to cert_dir"`
+	RenewBefore  time.Duration `mapstructure:"renew_before" desc:"Renew certificates this long before they expire"`
+	CARoot       string        `mapstructure:"ca_root" desc:"PEM file trusted when talking to the ACME server, for test CAs like Pebble"`
+}
+
+const (
+	ACMEStorageDir   = "dir"
+	ACMEStorageRedis = "redis"
+)
+
+/*
+*1 cap cert/key, duong dan tuong doi tinh tu cert_dir
+ */
+type CertificateConfig struct {
+	CertFile string `mapstructure:"cert_file"`
+	KeyFile  string `mapstructure:"key_file"`
+	Default  bool   `mapstructure:"default" desc:"Served when the client sends no SNI or an unknown name"`
+}
+
+type HSTSConfig struct {
+	MaxAge            time.Duration `mapstructure:"max_age" desc:"max-age of the header, 0 disables it"`
+	IncludeSubdomains bool          `mapstructure:"include_subdomains"`
+	Preload           bool          `mapstructure:"preload"`
+}
+
+const (
+	HTTPModeRedirect = "redirect"
+	HTTPModeServe    = "serve"
+	HTTPModeOff      = "off"
+)
+
 type StrategyConfig struct {
-	Strategy string `mapstructure:"strategy"`
+	Strategy string `mapstructure:"strategy" desc:"Load balancing strategy"`
 }
 
 type CacheConfig struct {
-	Addr     string        `mapstructure:"addr"`
+	Addr     string        `mapstructure:"addr" desc:"Redis address host:port"`
 	Password string        `mapstructure:"password"`
 	DB       int           `mapstructure:"db"`
 	PoolSize int           `mapstructure:"pool_size"`
-	Timeout  time.Duration `mapstructure:"timeout"`
+	Timeout  time.Duration `mapstructure:"timeout" desc:"Dial, read and write timeout"`
+}
+
+type SessionConfig struct {
+	TTL time.Duration `mapstructure:"ttl" desc:"Lifetime of a sticky session"`
+}
+
+type ResilienceConfig struct {
+	CircuitBreaker *CircuitBreakerConfig `mapstructure:"circuit_breaker"`
+	Retry          *RetryConfig          `mapstructure:"retry"`
+}
+
+type CircuitBreakerConfig struct {
+	MaxFailures uint32        `mapstructure:"max_failures" desc:"Consecutive failures before the breaker opens"`
+	Timeout     time.Duration `mapstructure:"timeout" desc:"How long the breaker stays open before probing"`
+	Interval    time.Duration `mapstructure:"interval" desc:"Period after which closed-state counts are reset"`
+}
+
+type RetryConfig struct {
+	MaxRetries int           `mapstructure:"max_retries"`
+	BaseDelay  time.Duration `mapstructure:"base_delay"`
+	MaxDelay   time.Duration `mapstructure:"max_delay"`
+	Jitter     float64       `mapstructure:"jitter" desc:"Random jitter factor between 0 and 1"`
+	MaxBody    int64         `mapstructure:"max_body_size" desc:"Largest request body buffered for retries in bytes; larger, unknown-length (except gRPC) and upgrade requests are sent once"`
+}
+
+type RegistryConfig struct {
+	Port                   int                     `mapstructure:"port" desc:"Registry API port"`
+	InstanceTTL            time.Duration           `mapstructure:"instance_ttl" desc:"Default TTL of a registered instance without heartbeat"`
+	MaxInstancesPerService int                     `mapstructure:"max_instances_per_service"`
+	Auth                   *RegistryAuthConfig     `mapstructure:"auth"`
+	Policies               []*RegistryPolicyConfig `mapstructure:"policies" desc:"Host and port allow-lists per service"`
+	AuditLog               string                  `mapstructure:"audit_log" desc:"File receiving registration audit records"`
+	Snapshot               *SnapshotConfig         `mapstructure:"snapshot"`
+}
+
+type SnapshotConfig struct {
+	Path     string        `mapstructure:"path"`
+	Interval time.Duration `mapstructure:"interval"`
+}
+
+type RegistryAuthConfig struct {
+	Mode         string            `mapstructure:"mode" desc:"none, hmac or api_key"` // none | hmac | api_key
+	SharedSecret string            `mapstructure:"shared_secret"`
+	APIKeys      map[string]string `mapstructure:"api_keys" desc:"Service name to API key"` // service name -> api key
+	MaxClockSkew time.Duration     `mapstructure:"max_clock_skew"`
+	AdminToken   string            `mapstructure:"admin_token" desc:"Bearer token for registry admin endpoints"` // bao ve cac endpoint quan tri (weight, ...)
+}
+
+type AdminConfig struct {
+	Enabled bool   `mapstructure:"enabled"`
+	Addr    string `mapstructure:"addr" desc:"Listen address, e.g. 127.0.0.1:9090"`                  // vd 127.0.0.1:9090
+	Socket  string `mapstructure:"socket" desc:"Unix socket path, takes precedence over addr"`       // unix socket, uu tien hon addr
+	Token   string `mapstructure:"token" desc:"Bearer token, empty allows read-only endpoints only"` // Bearer token, de trong = chi cho doc, tat rollback
+}
+
+type RegistryPolicyConfig struct {
+	Service string   `mapstructure:"service"`
+	CIDRs   []string `mapstructure:"cidrs"`
+	Ports   []int    `mapstructure:"ports"`
+}
+
+/*
+*Cau hinh ket noi toi backend cua 1 service, ap dung cho ca instance dang ky va backend tinh
+ */
+type UpstreamConfig struct {
+	Service         string             `mapstructure:"service"`
+	Protocol        string             `mapstructure:"protocol" desc:"auto, http1, http2 (over TLS) or h2c (cleartext HTTP/2 with prior knowledge); auto negotiates HTTP/2 through ALPN on TLS"` // auto | http1 | http2 | h2c
+	MaxConnsPerHost int                `mapstructure:"max_conns_per_host" desc:"Connection limit per backend instance, 0 is unlimited; with HTTP/2 requests are multiplexed over these connections"`
+	TLS             *UpstreamTLSConfig `mapstructure:"tls" desc:"HTTPS to the backends of this service"`
+}
+
+/*
+*Proxy TCP (L4): moi listener nhan ket noi tren 1 port va chuyen nguyen byte toi 1 instance cua service
+ */
+type TCPConfig struct {
+	Listeners      []*TCPListenerConfig `mapstructure:"listeners" desc:"Ports accepting TCP connections, each mapped to a service"`
+	ConnectTimeout time.Duration        `mapstructure:"connect_timeout" desc:"Timeout of the connection to the chosen backend"`
+	IdleTimeout    time.Duration        `mapstructure:"idle_timeout" desc:"Connections without traffic in either direction are closed after this long, 0 never"`
+	DrainTimeout   time.Duration        `mapstructure:"drain_timeout" desc:"How long open connections may finish on shutdown before they are closed, 0 waits until they end"`
+}
+
+type TCPListenerConfig struct {
+	Port    int    `mapstructure:"port" desc:"Port accepting client connections"`
+	Service string `mapstructure:"service" desc:"Service whose instances receive the connections"`
+}
+
+const (
+	UpstreamProtocolAuto  = "auto"
+	UpstreamProtocolHTTP1 = "http1"
+	UpstreamProtocolHTTP2 = "http2"
+	UpstreamProtocolH2C   = "h2c"
+)
+
+/*
+*Duong dan file tuong doi tinh tu tls.cert_dir. Gia tri o day uu tien hon gia tri gui luc dang ky
+ */
+type UpstreamTLSConfig struct {
+	Enabled            bool     `mapstructure:"enabled" desc:"Connect to the backends over HTTPS"`
+	ServerName         string   `mapstructure:"server_name" desc:"SNI and name verified against the backend certificate, defaults to the instance host"`
+	CAFile             string   `mapstructure:"ca_file" desc:"PEM bundle trusted for backend certificates instead of the system roots"`
+	CertFile           string   `mapstructure:"cert_file" desc:"Client certificate presented to the backends (mTLS)"`
+	KeyFile            string   `mapstructure:"key_file"`
+	InsecureSkipVerify bool     `mapstructure:"insecure_skip_verify" desc:"Skip chain and name verification; only sensible together with pinned_sha256"`
+	PinnedSHA256       []string `mapstructure:"pinned_sha256" desc:"Accepted public keys as sha256/<base64 of the SPKI digest>; any certificate in the chain may match"`
+}
+
+type RouteRule struct {
+	Prefix      string            `mapstructure:"prefix" desc:"Path prefix, must start with /"`
+	Service     string            `mapstructure:"service_name"`
+	StripPrefix bool              `mapstructure:"strip_prefix,omitempty"`
+	Selector    map[string]string `mapstructure:"selector,omitempty" desc:"Only route to instances whose metadata matches"` // loc instance theo metadata, vd version: v2
+	ClientCert  *ClientCertRule   `mapstructure:"client_cert,omitempty" desc:"Only accept requests carrying a verified client certificate"`
+}
+
+/*
+*Danh tinh client duoc phep vao route, khop 1 muc bat ky la du.
+*Ca 3 danh sach rong thi moi cert da verify deu duoc
+ */
+type ClientCertRule struct {
+	Subjects     []string `mapstructure:"subjects" desc:"Common name or full subject DN"`
+	SANs         []string `mapstructure:"sans" desc:"DNS name, email, URI or IP from the subject alternative names"`
+	Fingerprints []string `mapstructure:"fingerprints" desc:"SHA-256 fingerprint of the certificate in hex, colons allowed"`
+}
+
+type RoutingConfig struct {
+	Rules          []RouteRule `mapstructure:"rules"`
+	DefaultService string      `mapstructure:"default_service,omitempty" desc:"Service for requests matching no rule"`
+}
+
+/*
+*Chu ky health check, gia tri loi hoac thieu thi dung 10s
+ */
+func (c *Config) HealthInterval() time.Duration {
+	if c.Server == nil || c.Server.HealthCheckInterval == "" {
+		return defaultHealthCheckInterval
+	}
+
+	d, err := time.ParseDuration(c.Server.HealthCheckInterval)
+	if err != nil || d <= 0 {
+		return defaultHealthCheckInterval
+	}
+	return d
 }
 
 func unMarshalConfig(v *viper.Viper) (*Config, error) {
@@ -0,0 +1,196 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// Phien ban schema hien tai, tang len khi doi ten hoac bo key
+const SchemaVersion = 1
+
+const includeKey = "include"
+
+/*
+*Gia tri mac dinh cua toan bo schema, la nguon duy nhat thay cho cac hang so rai rac.
+*Cung duoc dua vao JSON Schema
+ */
+var defaults = map[string]any{
+	"server.port":                  8080,
+	"server.health_check_interval": defaultHealthCheckInterval.String(),
+	"server.read_timeout":          15 * time.Second,
+	"server.write_timeout":         15 * time.Second,
+	"server.idle_timeout":          60 * time.Second,
+	"server.stream_idle_timeout":   5 * time.Minute,
+
+	"tls.port":     8443,
+	"tls.cert_dir": "keys",
+	"tls.http":     HTTPModeRedirect,
+	"tls.http2":    true,
+
+	"tls.acme.directory_url": "https://acme-v02.api.letsencrypt.org/directory",
+	"tls.acme.storage":       ACMEStorageDir,
+	"tls.acme.cache_dir":     "acme",
+	"tls.acme.renew_before":  30 * 24 * time.Hour,
+
+	"tls.client_auth.mode":            ClientAuthNone,
+	"tls.client_auth.forward_headers": true,
+
+	"load_balancer.strategy": "round_robin",
+
+	"log.level":  "info",
+	"log.format": "text",
+
+	"cache.addr":      "localhost:6379",
+	"cache.pool_size": 10,
+	"cache.timeout":   5 * time.Second,
+
+	"rate_limit.requests_per_second": 5.0,
+	"rate_limit.burst":               50,
+
+	"session.ttl": time.Hour,
+
+	"resilience.circuit_breaker.max_failures": 3,
+	"resilience.circuit_breaker.timeout":      5 * time.Second,
+	"resilience.circuit_breaker.interval":     10 * time.Second,
+	"resilience.retry.max_retries":            3,
+	"resilience.retry.base_delay":             200 * time.Millisecond,
+	"resilience.retry.max_delay":              3 * time.Second,
+	"resilience.retry.jitter":                 0.2,
+	"resilience.retry.max_body_size":          1 << 20,
+
+	"tcp.connect_timeout": 5 * time.Second,
+	"tcp.idle_timeout":    10 * time.Minute,
+	"tcp.drain_timeout":   30 * time.Second,
+
+	"registry.port":                      8000,
+	"registry.instance_ttl":              30 * time.Second,
+	"registry.max_instances_per_service": 50,
+	"registry.auth.max_clock_skew":       5 * time.Minute,
+
+	"admin.addr": "127.0.0.1:9090",
+}
+
+func setDefaults(v *viper.Viper) {
+	for key, value := range defaults {
+		v.SetDefault(key, value)
+	}
+}
+
+/*
+*Config chi gom gia tri mac dinh, dung khi 1 section khong duoc cau hinh
+ */
+func Defaults() *Config {
+	v := viper.New()
+	setDefaults(v)
+
+	cfg, err := unMarshalConfig(v)
+	if err != nil {
+		// bang defaults sai kieu la loi lap trinh
+		panic(fmt.Sprintf("config: invalid defaults: %v", err))
+	}
+	return cfg
+}
+
+/*
+*Tra ve gia tri mac dinh cua 1 key (vd registry.port), dung cho flag CLI va JSON Schema
+ */
+func Default(key string) (any, bool) {
+	value, ok := defaults[key]
+	return value, ok
+}
+
+/*
+*Doc cac file trong include (tuong doi voi file chinh, ho tro glob) va gop vao v.
+*Key trong file chinh ghi de file include, list bi thay the chu khong noi them.
+*Chi ho tro 1 cap include
+ */
+func MergeIncludes(v *viper.Viper) error {
+	includes := v.GetStringSlice(includeKey)
+	if len(includes) == 0 {
+		return nil
+	}
+
+	baseDir := filepath.Dir(v.ConfigFileUsed())
+
+	// doc lai file chinh bang viper rieng de khong lay kem gia tri mac dinh va env
+	main, err := readRawSettings(v.ConfigFileUsed())
+	if err != nil {
+		return err
+	}
+
+	files, err := resolveIncludes(baseDir, includes)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		settings, err := readRawSettings(file)
+		if err != nil {
+			return fmt.Errorf("include %q: %w", file, err)
+		}
+		if _, nested := settings[includeKey]; nested {
+			return fmt.Errorf("include %q: nested includes are not supported", file)
+		}
+		if err := v.MergeConfigMap(settings); err != nil {
+			return fmt.Errorf("include %q: %w", file, err)
+		}
+	}
+
+	// file chinh duoc uu tien
+	return v.MergeConfigMap(main)
+}
+
+/*
+*Cac file ma file chinh include theo thu tu gop, dung de ghi lich su va rollback ca file include
+ */
+func IncludeFiles(file string) ([]string, error) {
+	raw := viper.New()
+	raw.SetConfigFile(file)
+	if err := raw.ReadInConfig(); err != nil {
+		return nil, err
+	}
+	return resolveIncludes(filepath.Dir(file), raw.GetStringSlice(includeKey))
+}
+
+func resolveIncludes(baseDir string, patterns []string) ([]string, error) {
+	var files []string
+	for _, pattern := range patterns {
+		if !filepath.IsAbs(pattern) {
+			pattern = filepath.Join(baseDir, pattern)
+		}
+
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("include %q: %w", pattern, err)
+		}
+		if len(matches) == 0 && !hasGlobMeta(pattern) {
+			return nil, fmt.Errorf("include %q: file not found", pattern)
+		}
+		sort.Strings(matches)
+		files = append(files, matches...)
+	}
+	return files, nil
+}
+
+func readRawSettings(file string) (map[string]any, error) {
+	raw := viper.New()
+	raw.SetConfigFile(file)
+	if err := raw.ReadInConfig(); err != nil {
+		return nil, err
+	}
+	return raw.AllSettings(), nil
+}
+
+func hasGlobMeta(pattern string) bool {
+	for _, c := range pattern {
+		switch c {
+		case '*', '?', '[':
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,62 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadConfig_IncludesAndDefaults(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "conf.d"), 0755))
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "conf.d", "registry.yml"), []byte(`registry:
+  port: 9100
+  instance_ttl: 1m
+server:
+  port: 9999
+`), 0644))
+
+	main := filepath.Join(dir, "config.yml")
+	require.NoError(t, os.WriteFile(main, []byte(`version: 1
+include:
+  - conf.d/*.yml
+server:
+  port: 8081
+`), 0644))
+
+	cfg, err := LoadConfig(main)
+	require.NoError(t, err)
+
+	// file chinh ghi de include
+	assert.Equal(t, 8081, cfg.Server.Port)
+	assert.Equal(t, 9100, cfg.Registry.Port)
+	assert.Equal(t, time.Minute, cfg.Registry.InstanceTTL)
+
+	// key khong khai bao lay tu defaults
+	assert.Equal(t, 50, cfg.Registry.MaxInstancesPerService)
+	assert.Equal(t, time.Hour, cfg.Session.TTL)
+	assert.Equal(t, "round_robin", cfg.Strategy.Strategy)
+}
+
+func TestLoadConfig_MissingIncludeFails(t *testing.T) {
+	main := filepath.Join(t.TempDir(), "config.yml")
+	require.NoError(t, os.WriteFile(main, []byte("include:\n  - missing.yml\n"), 0644))
+
+	_, err := LoadConfig(main)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "file not found")
+}
+
+func TestValidateConfig_UnsupportedVersion(t *testing.T) {
+	cfg := Defaults()
+	cfg.Version = SchemaVersion + 1
+
+	errs := ValidateConfig(cfg).Errors()
+	require.Len(t, errs, 1)
+	assert.Equal(t, "version", errs[0].Path)
+}
@@ -0,0 +1,240 @@
+package config
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+type StringChange struct {
+	From string
+	To   string
+}
+
+type DurationChange struct {
+	From time.Duration
+	To   time.Duration
+}
+
+type RateLimitChange struct {
+	From *RateLimitConfig
+	To   *RateLimitConfig
+}
+
+type CacheChange struct {
+	From *CacheConfig
+	To   *CacheConfig
+}
+
+type TLSChange struct {
+	From *TLSConfig
+	To   *TLSConfig
+}
+
+type TCPChange struct {
+	From *TCPConfig
+	To   *TCPConfig
+}
+
+type UpstreamsChange struct {
+	From     []*UpstreamConfig
+	To       []*UpstreamConfig
+	Services []string // service co cau hinh them, bot hoac doi
+}
+
+type BackendsChange struct {
+	Added   []*BackEndConfig
+	Removed []*BackEndConfig
+	Updated []*BackEndConfig // cung service + url, weight khac
+}
+
+/*
+*Thay doi giua 2 lan nap config, moi truong nil nghia la phan do khong doi.
+*Moi subsystem chi doc phan cua minh
+ */
+type Diff struct {
+	Old *Config
+	New *Config
+
+	LogLevel            *StringChange
+	Strategy            *StringChange
+	HealthCheckInterval *DurationChange
+	StreamIdleTimeout   *DurationChange
+	RateLimit           *RateLimitChange
+	Cache               *CacheChange
+	Backends            *BackendsChange
+	TLS                 *TLSChange
+	Upstreams           *UpstreamsChange
+	TCP                 *TCPChange
+}
+
+func (d *Diff) Empty() bool {
+	return d.LogLevel == nil && d.Strategy == nil && d.HealthCheckInterval == nil && d.StreamIdleTimeout == nil &&
+		d.RateLimit == nil && d.Cache == nil && d.Backends == nil && d.TLS == nil && d.Upstreams == nil && d.TCP == nil
+}
+
+/*
+*Liet ke ten cac phan da thay doi de ghi log
+ */
+func (d *Diff) Sections() []string {
+	var sections []string
+	if d.LogLevel != nil {
+		sections = append(sections, "log.level")
+	}
+	if d.Strategy != nil {
+		sections = append(sections, "load_balancer.strategy")
+	}
+	if d.HealthCheckInterval != nil {
+		sections = append(sections, "server.health_check_interval")
+	}
+	if d.StreamIdleTimeout != nil {
+		sections = append(sections, "server.stream_idle_timeout")
+	}
+	if d.RateLimit != nil {
+		sections = append(sections, "rate_limit")
+	}
+	if d.Cache != nil {
+		sections = append(sections, "cache")
+	}
+	if d.Backends != nil {
+		sections = append(sections, "backends")
+	}
+	if d.TLS != nil {
+		sections = append(sections, "tls")
+	}
+	if d.Upstreams != nil {
+		sections = append(sections, "upstreams")
+	}
+	if d.TCP != nil {
+		sections = append(sections, "tcp")
+	}
+	return sections
+}
+
+func ComputeDiff(old, new *Config) *Diff {
+	d := &Diff{Old: old, New: new}
+	if old == nil || new == nil {
+		return d
+	}
+
+	if from, to := logLevelOf(old), logLevelOf(new); from != to {
+		d.LogLevel = &StringChange{From: from, To: to}
+	}
+
+	if from, to := strategyOf(old), strategyOf(new); from != to {
+		d.Strategy = &StringChange{From: from, To: to}
+	}
+
+	if from, to := old.HealthInterval(), new.HealthInterval(); from != to {
+		d.HealthCheckInterval = &DurationChange{From: from, To: to}
+	}
+
+	if from, to := streamIdleOf(old), streamIdleOf(new); from != to {
+		d.StreamIdleTimeout = &DurationChange{From: from, To: to}
+	}
+
+	if !reflect.DeepEqual(old.RateLimit, new.RateLimit) {
+		d.RateLimit = &RateLimitChange{From: old.RateLimit, To: new.RateLimit}
+	}
+
+	if !reflect.DeepEqual(old.RedisConfig, new.RedisConfig) {
+		d.Cache = &CacheChange{From: old.RedisConfig, To: new.RedisConfig}
+	}
+
+	d.Backends = diffBackends(old.BackEnds, new.BackEnds)
+
+	if !reflect.DeepEqual(old.TLS, new.TLS) {
+		d.TLS = &TLSChange{From: old.TLS, To: new.TLS}
+	}
+
+	if services := diffUpstreams(old.Upstreams, new.Upstreams); len(services) > 0 {
+		d.Upstreams = &UpstreamsChange{From: old.Upstreams, To: new.Upstreams, Services: services}
+	}
+
+	if !reflect.DeepEqual(old.TCP, new.TCP) {
+		d.TCP = &TCPChange{From: old.TCP, To: new.TCP}
+	}
+
+	return d
+}
+
+func diffUpstreams(old, new []*UpstreamConfig) []string {
+	byService := func(list []*UpstreamConfig) map[string]*UpstreamConfig {
+		m := make(map[string]*UpstreamConfig, len(list))
+		for _, u := range list {
+			m[u.Service] = u
+		}
+		return m
+	}
+	from, to := byService(old), byService(new)
+
+	var services []string
+	for name, u := range to {
+		if !reflect.DeepEqual(from[name], u) {
+			services = append(services, name)
+		}
+	}
+	for name := range from {
+		if _, ok := to[name]; !ok {
+			services = append(services, name)
+		}
+	}
+	sort.Strings(services)
+	return services
+}
+
+func logLevelOf(c *Config) string {
+	if c.LogConfig == nil {
+		return ""
+	}
+	return strings.ToLower(c.LogConfig.Level)
+}
+
+func streamIdleOf(c *Config) time.Duration {
+	if c.Server == nil {
+		return 0
+	}
+	return c.Server.StreamIdleTimeout
+}
+
+func strategyOf(c *Config) string {
+	if c.Strategy == nil {
+		return ""
+	}
+	return c.Strategy.Strategy
+}
+
+func diffBackends(old, new []*BackEndConfig) *BackendsChange {
+	oldByKey := make(map[string]*BackEndConfig, len(old))
+	for _, be := range old {
+		oldByKey[be.Key()] = be
+	}
+
+	change := &BackendsChange{}
+	seen := make(map[string]bool, len(new))
+
+	for _, be := range new {
+		key := be.Key()
+		seen[key] = true
+
+		prev, ok := oldByKey[key]
+		switch {
+		case !ok:
+			change.Added = append(change.Added, be)
+		case prev.Weight != be.Weight:
+			change.Updated = append(change.Updated, be)
+		}
+	}
+
+	for _, be := range old {
+		if !seen[be.Key()] {
+			change.Removed = append(change.Removed, be)
+		}
+	}
+
+	if len(change.Added) == 0 && len(change.Removed) == 0 && len(change.Updated) == 0 {
+		return nil
+	}
+	return change
+}
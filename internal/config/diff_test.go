@@ -0,0 +1,53 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComputeDiff(t *testing.T) {
+	old := &Config{
+		Server:    &ServerConfig{Port: 8080, HealthCheckInterval: "10s"},
+		Strategy:  &StrategyConfig{Strategy: "round_robin"},
+		LogConfig: &LogConfig{Level: "info"},
+		BackEnds: []*BackEndConfig{
+			{Service: "web", Url: "http://10.0.0.1:80", Weight: 1},
+			{Service: "web", Url: "http://10.0.0.2:80", Weight: 1},
+		},
+	}
+
+	same := *old
+	assert.True(t, ComputeDiff(old, &same).Empty())
+
+	changed := &Config{
+		Server:    &ServerConfig{Port: 8080, HealthCheckInterval: "5s", StreamIdleTimeout: time.Minute},
+		Strategy:  &StrategyConfig{Strategy: "least_conn"},
+		LogConfig: &LogConfig{Level: "DEBUG"},
+		RateLimit: &RateLimitConfig{RequestsPerSecond: 10, Burst: 20},
+		BackEnds: []*BackEndConfig{
+			{Service: "web", Url: "http://10.0.0.1:80", Weight: 3},
+			{Service: "web", Url: "http://10.0.0.3:80", Weight: 1},
+		},
+	}
+
+	d := ComputeDiff(old, changed)
+	require.False(t, d.Empty())
+
+	assert.Equal(t, &StringChange{From: "info", To: "debug"}, d.LogLevel)
+	assert.Equal(t, &StringChange{From: "round_robin", To: "least_conn"}, d.Strategy)
+	assert.Equal(t, &DurationChange{From: 10 * time.Second, To: 5 * time.Second}, d.HealthCheckInterval)
+	assert.Equal(t, &DurationChange{From: 0, To: time.Minute}, d.StreamIdleTimeout)
+	assert.NotNil(t, d.RateLimit)
+	assert.Nil(t, d.Cache)
+
+	require.NotNil(t, d.Backends)
+	require.Len(t, d.Backends.Added, 1)
+	assert.Equal(t, "http://10.0.0.3:80", d.Backends.Added[0].Url)
+	require.Len(t, d.Backends.Removed, 1)
+	assert.Equal(t, "http://10.0.0.2:80", d.Backends.Removed[0].Url)
+	require.Len(t, d.Backends.Updated, 1)
+	assert.Equal(t, 3, d.Backends.Updated[0].Weight)
+}
@@ -0,0 +1,164 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	EnvConfigDir = "LB_CONFIG_DIR"
+
+	configFileName   = "config.yml"
+	routingFileName  = "routing.yml"
+	combinedFileName = "lb.yml" // 1 file chua ca config va section routing
+)
+
+/*
+*Vi tri config da tim duoc. Khi dung file gop thi RoutingFile == ConfigFile
+*va router doc section routing trong file do
+ */
+type Location struct {
+	ConfigFile  string
+	RoutingFile string
+	Source      string // flag, env, xdg, system, workdir, executable
+}
+
+/*
+*Thu muc dung de giai quyet duong dan tuong doi (keys, ...): thu muc cha neu
+*config nam trong thu muc ten "config" (bo cuc repo), nguoc lai chinh thu muc chua config
+ */
+func (l *Location) BaseDir() string {
+	dir := filepath.Dir(l.ConfigFile)
+	if filepath.Base(dir) == "config" {
+		return filepath.Dir(dir)
+	}
+	return dir
+}
+
+func (l *Location) Combined() bool {
+	return l.ConfigFile == l.RoutingFile
+}
+
+type candidate struct {
+	path   string
+	source string
+}
+
+/*
+*Tim config theo thu tu: --config, LB_CONFIG_DIR, $XDG_CONFIG_HOME/lb, /etc/lb,
+*./config, <thu muc binary>/config. explicit co the la thu muc hoac 1 file gop.
+*Loi tra ve liet ke tat ca duong dan da tim
+ */
+func Discover(explicit string) (*Location, error) {
+	// flag va env la lua chon ro rang cua nguoi dung, khong tim tiep neu sai
+	if explicit != "" {
+		return resolveExplicit(explicit, "flag")
+	}
+	if env := os.Getenv(EnvConfigDir); env != "" {
+		return resolveExplicit(env, "env "+EnvConfigDir)
+	}
+
+	var searched []string
+	seen := make(map[string]bool)
+	for _, c := range defaultCandidates() {
+		if seen[c.path] {
+			continue
+		}
+		seen[c.path] = true
+
+		loc, tried := probeDir(c.path, c.source)
+		searched = append(searched, tried...)
+		if loc != nil {
+			return loc, nil
+		}
+	}
+
+	return nil, fmt.Errorf("no configuration found, searched:\n  %s\nuse --config or %s to point at a config directory or file",
+		strings.Join(searched, "\n  "), EnvConfigDir)
+}
+
+func resolveExplicit(path, source string) (*Location, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := os.Stat(abs)
+	if err != nil {
+		return nil, fmt.Errorf("config path from %s: %w", source, err)
+	}
+
+	if !info.IsDir() {
+		// file gop, hoac config.yml voi routing.yml nam canh ben
+		loc := &Location{ConfigFile: abs, RoutingFile: abs, Source: source}
+		if filepath.Base(abs) == configFileName {
+			if routing := filepath.Join(filepath.Dir(abs), routingFileName); fileExists(routing) {
+				loc.RoutingFile = routing
+			}
+		}
+		return loc, nil
+	}
+
+	loc, searched := probeDir(abs, source)
+	if loc == nil {
+		return nil, fmt.Errorf("no configuration found in %s (from %s), searched:\n  %s",
+			abs, source, strings.Join(searched, "\n  "))
+	}
+	return loc, nil
+}
+
+/*
+*Thu muc hop le khi co config.yml (routing.yml nam canh hoac section routing) hoac lb.yml
+ */
+func probeDir(dir, source string) (*Location, []string) {
+	configFile := filepath.Join(dir, configFileName)
+	combined := filepath.Join(dir, combinedFileName)
+	searched := []string{configFile, combined}
+
+	if fileExists(configFile) {
+		loc := &Location{ConfigFile: configFile, RoutingFile: configFile, Source: source}
+		if routing := filepath.Join(dir, routingFileName); fileExists(routing) {
+			loc.RoutingFile = routing
+		}
+		return loc, searched
+	}
+
+	if fileExists(combined) {
+		return &Location{ConfigFile: combined, RoutingFile: combined, Source: source}, searched
+	}
+
+	return nil, searched
+}
+
+func defaultCandidates() []candidate {
+	var list []candidate
+
+	xdg := os.Getenv("XDG_CONFIG_HOME")
+	if xdg == "" {
+		if home, err := os.UserHomeDir(); err == nil {
+			xdg = filepath.Join(home, ".config")
+		}
+	}
+	if xdg != "" {
+		list = append(list, candidate{filepath.Join(xdg, "lb"), "xdg"})
+	}
+
+	list = append(list, candidate{"/etc/lb", "system"})
+
+	if wd, err := os.Getwd(); err == nil {
+		list = append(list, candidate{filepath.Join(wd, "config"), "workdir"})
+	}
+
+	if exe, err := os.Executable(); err == nil {
+		list = append(list, candidate{filepath.Join(filepath.Dir(exe), "config"), "executable"})
+	}
+
+	return list
+}
+
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
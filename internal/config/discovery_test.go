@@ -0,0 +1,61 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, path string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte("server:\n  port: 8080\n"), 0644))
+}
+
+func TestDiscover_ExplicitDirectory(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "config")
+	writeFile(t, filepath.Join(dir, "config.yml"))
+	writeFile(t, filepath.Join(dir, "routing.yml"))
+
+	loc, err := Discover(dir)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "config.yml"), loc.ConfigFile)
+	assert.Equal(t, filepath.Join(dir, "routing.yml"), loc.RoutingFile)
+	assert.Equal(t, filepath.Dir(dir), loc.BaseDir())
+	assert.False(t, loc.Combined())
+}
+
+func TestDiscover_CombinedFileFromEnv(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "lb.yml"))
+	t.Setenv(EnvConfigDir, dir)
+
+	loc, err := Discover("")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "lb.yml"), loc.ConfigFile)
+	assert.True(t, loc.Combined())
+	assert.Equal(t, dir, loc.BaseDir())
+}
+
+func TestDiscover_XDGBeforeWorkdir(t *testing.T) {
+	xdg := t.TempDir()
+	writeFile(t, filepath.Join(xdg, "lb", "config.yml"))
+	t.Setenv(EnvConfigDir, "")
+	t.Setenv("XDG_CONFIG_HOME", xdg)
+
+	loc, err := Discover("")
+	require.NoError(t, err)
+	assert.Equal(t, "xdg", loc.Source)
+}
+
+func TestDiscover_ErrorListsSearchedPaths(t *testing.T) {
+	dir := t.TempDir()
+
+	_, err := Discover(dir)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), filepath.Join(dir, "config.yml"))
+	assert.Contains(t, err.Error(), filepath.Join(dir, "lb.yml"))
+}
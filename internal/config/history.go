@@ -0,0 +1,292 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	defaultHistorySize = 50
+
+	SourceStartup  = "startup"
+	SourceFile     = "file" // file thay doi tren dia (hot reload)
+	SourceRollback = "rollback"
+)
+
+var ErrRevisionNotFound = errors.New("revision not found")
+
+/*
+*1 phien ban file config da nap thanh cong
+ */
+type Revision struct {
+	Number  int       `json:"number"`
+	File    string    `json:"file"`
+	Kind    string    `json:"kind"` // config | routing
+	Hash    string    `json:"hash"` // sha256 cua noi dung file
+	Size    int       `json:"size"`
+	Time    time.Time `json:"time"`
+	Source  string    `json:"source"`
+	Parent  string    `json:"parent,omitempty"` // file chinh include file nay
+	Content string    `json:"content,omitempty"`
+}
+
+/*
+*Lich su co gioi han cac lan reload thanh cong cua config.yml va routing.yml.
+*Moi file dang ky ham reload de rollback di qua duong reload binh thuong
+ */
+type History struct {
+	mu        sync.Mutex
+	size      int
+	next      int
+	revisions []*Revision
+	reloaders map[string][]func() error // file gop co ca config va routing
+	pending   map[string]string         // file -> source cho lan Record tiep theo (rollback)
+}
+
+func NewHistory(size int) *History {
+	if size <= 0 {
+		size = defaultHistorySize
+	}
+
+	return &History{
+		size:      size,
+		next:      1,
+		reloaders: make(map[string][]func() error),
+		pending:   make(map[string]string),
+	}
+}
+
+/*
+*Dang ky file can theo doi, ghi revision startup va ham reload dung khi rollback
+ */
+func (h *History) Track(file, kind string, reload func() error) error {
+	h.mu.Lock()
+	h.reloaders[file] = append(h.reloaders[file], reload)
+	h.mu.Unlock()
+
+	_, err := h.RecordFile(file, kind, SourceStartup)
+	return err
+}
+
+/*
+*Doc file va ghi revision, bo qua neu noi dung trung voi revision moi nhat cua file.
+*Cac file include cung duoc ghi thanh revision rieng de rollback duoc tung file
+ */
+func (h *History) RecordFile(file, kind, source string) (*Revision, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	includes, err := IncludeFiles(file)
+	if err != nil {
+		return nil, err
+	}
+
+	rev := h.Record(file, kind, source, data)
+	for _, include := range includes {
+		content, err := os.ReadFile(include)
+		if err != nil {
+			return rev, err
+		}
+		h.record(include, file, kind, source, content)
+	}
+	return rev, nil
+}
+
+func (h *History) Record(file, kind, source string, content []byte) *Revision {
+	return h.record(file, "", kind, source, content)
+}
+
+func (h *History) record(file, parent, kind, source string, content []byte) *Revision {
+	sum := sha256.Sum256(content)
+	hash := hex.EncodeToString(sum[:])
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if latest := h.latestLocked(file); latest != nil && latest.Hash == hash {
+		return nil
+	}
+
+	if p, ok := h.pending[file]; ok {
+		source = p
+		delete(h.pending, file)
+	}
+
+	rev := &Revision{
+		Number:  h.next,
+		File:    file,
+		Kind:    kind,
+		Hash:    hash,
+		Size:    len(content),
+		Time:    time.Now().UTC(),
+		Source:  source,
+		Parent:  parent,
+		Content: string(content),
+	}
+	h.next++
+
+	h.revisions = append(h.revisions, rev)
+	if len(h.revisions) > h.size {
+		h.revisions = h.revisions[len(h.revisions)-h.size:]
+	}
+
+	return rev
+}
+
+func (h *History) latestLocked(file string) *Revision {
+	for i := len(h.revisions) - 1; i >= 0; i-- {
+		if h.revisions[i].File == file {
+			return h.revisions[i]
+		}
+	}
+	return nil
+}
+
+/*
+*Danh sach revision moi nhat truoc, khong kem noi dung
+ */
+func (h *History) List() []Revision {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	out := make([]Revision, 0, len(h.revisions))
+	for i := len(h.revisions) - 1; i >= 0; i-- {
+		rev := *h.revisions[i]
+		rev.Content = ""
+		out = append(out, rev)
+	}
+	return out
+}
+
+func (h *History) Get(number int) (*Revision, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, rev := range h.revisions {
+		if rev.Number == number {
+			cp := *rev
+			return &cp, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %d", ErrRevisionNotFound, number)
+}
+
+/*
+*Ghi lai noi dung revision vao file (atomic) roi reload qua duong binh thuong.
+*Revision cua file include reload qua file chinh da include no.
+*Tra ve revision hien tai cua file sau khi rollback
+ */
+func (h *History) Rollback(number int) (*Revision, error) {
+	rev, err := h.Get(number)
+	if err != nil {
+		return nil, err
+	}
+
+	h.mu.Lock()
+	reloaders := h.reloaders[rev.File]
+	if rev.Parent != "" {
+		reloaders = h.reloaders[rev.Parent]
+	}
+	if len(reloaders) == 0 {
+		h.mu.Unlock()
+		return nil, fmt.Errorf("file %s is not tracked", rev.File)
+	}
+	h.pending[rev.File] = fmt.Sprintf("%s:%d", SourceRollback, rev.Number)
+	h.mu.Unlock()
+
+	// giu noi dung hien tai de tra lai file khi reload that bai
+	current, err := os.ReadFile(rev.File)
+	if err != nil {
+		h.clearPending(rev.File)
+		return nil, err
+	}
+
+	if err := WriteFileAtomic(rev.File, []byte(rev.Content)); err != nil {
+		h.clearPending(rev.File)
+		return nil, fmt.Errorf("write %s: %w", rev.File, err)
+	}
+
+	for _, reload := range reloaders {
+		if err := reload(); err != nil {
+			h.clearPending(rev.File)
+			return nil, h.restore(rev.File, current, reloaders, fmt.Errorf("reload %s: %w", rev.File, err))
+		}
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.pending, rev.File)
+
+	latest := h.latestLocked(rev.File)
+	if latest == nil || latest.Hash != rev.Hash {
+		return nil, fmt.Errorf("rollback of %s was not applied", rev.File)
+	}
+	cp := *latest
+	cp.Content = ""
+	return &cp, nil
+}
+
+/*
+*Ghi lai noi dung truoc rollback va reload lai, de file tren dia khop config dang chay
+*va cac reloader da nhan noi dung rollback quay ve config cu
+ */
+func (h *History) restore(file string, content []byte, reloaders []func() error, cause error) error {
+	if err := WriteFileAtomic(file, content); err != nil {
+		return errors.Join(cause, fmt.Errorf("restore %s: %w", file, err))
+	}
+
+	errs := []error{cause}
+	for _, reload := range reloaders {
+		if err := reload(); err != nil {
+			errs = append(errs, fmt.Errorf("reload restored %s: %w", file, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (h *History) clearPending(file string) {
+	h.mu.Lock()
+	delete(h.pending, file)
+	h.mu.Unlock()
+}
+
+/*
+*Ghi file tam cung thu muc, fsync roi rename de watcher khong doc phai file do dang
+ */
+func WriteFileAtomic(path string, data []byte) error {
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(mode); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,130 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistory_RollbackReloadsPreviousRevision(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+	require.NoError(t, os.WriteFile(path, []byte("server:\n  port: 8080\nload_balancer:\n  strategy: round_robin\n"), 0644))
+
+	m, err := NewConfigManagerFromFile(path, nil)
+	require.NoError(t, err)
+
+	h := NewHistory(0)
+	require.NoError(t, m.SetHistory(h))
+
+	require.NoError(t, os.WriteFile(path, []byte("server:\n  port: 8081\nload_balancer:\n  strategy: round_robin\n"), 0644))
+	require.NoError(t, m.Reload())
+	// noi dung khong doi thi khong tao revision moi
+	require.NoError(t, m.Reload())
+
+	revisions := h.List()
+	require.Len(t, revisions, 2)
+	assert.Equal(t, SourceFile, revisions[0].Source)
+	assert.Equal(t, 8081, m.GetPortServer())
+
+	rev, err := h.Rollback(1)
+	require.NoError(t, err)
+	assert.Equal(t, 3, rev.Number)
+	assert.Equal(t, "rollback:1", rev.Source)
+	assert.Equal(t, 8080, m.GetPortServer())
+
+	_, err = h.Rollback(42)
+	assert.ErrorIs(t, err, ErrRevisionNotFound)
+}
+
+func TestHistory_RollbackIncludedFile(t *testing.T) {
+	dir := t.TempDir()
+	include := filepath.Join(dir, "registry.yml")
+	require.NoError(t, os.WriteFile(include, []byte("registry:\n  port: 9100\n"), 0644))
+	path := filepath.Join(dir, "config.yml")
+	require.NoError(t, os.WriteFile(path, []byte("include:\n  - registry.yml\nserver:\n  port: 8080\n"), 0644))
+
+	m, err := NewConfigManagerFromFile(path, nil)
+	require.NoError(t, err)
+
+	h := NewHistory(0)
+	require.NoError(t, m.SetHistory(h))
+
+	// chi sua file include, file chinh giu nguyen
+	require.NoError(t, os.WriteFile(include, []byte("registry:\n  port: 9200\n"), 0644))
+	require.NoError(t, m.Reload())
+	assert.Equal(t, 9200, m.GetConfig().Registry.Port)
+
+	revisions := h.List()
+	require.Len(t, revisions, 3)
+	assert.Equal(t, include, revisions[0].File)
+	assert.Equal(t, path, revisions[0].Parent)
+	assert.Equal(t, include, revisions[1].File)
+
+	rev, err := h.Rollback(revisions[1].Number)
+	require.NoError(t, err)
+	assert.Equal(t, include, rev.File)
+	assert.Equal(t, fmt.Sprintf("rollback:%d", revisions[1].Number), rev.Source)
+	assert.Equal(t, 9100, m.GetConfig().Registry.Port)
+}
+
+func TestHistory_RollbackRestoresFileWhenReloadFails(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lb.yml")
+	require.NoError(t, os.WriteFile(path, []byte("port: 8080\nrouting: ok\n"), 0644))
+
+	// file gop: phan config nhan moi noi dung, phan routing tu choi routing: bad
+	var applied []string
+	h := NewHistory(0)
+	require.NoError(t, h.Track(path, "config", func() error {
+		data, err := os.ReadFile(path)
+		applied = append(applied, string(data))
+		return err
+	}))
+	require.NoError(t, h.Track(path, "routing", func() error {
+		data, err := os.ReadFile(path)
+		if err == nil && strings.Contains(string(data), "routing: bad") {
+			return errors.New("invalid routing")
+		}
+		return err
+	}))
+
+	bad := h.Record(path, "routing", SourceFile, []byte("port: 9090\nrouting: bad\n"))
+	require.NotNil(t, bad)
+	h.Record(path, "config", SourceFile, []byte("port: 8080\nrouting: ok\n"))
+
+	_, err := h.Rollback(bad.Number)
+	assert.ErrorContains(t, err, "invalid routing")
+
+	// file tren dia va reloader da nhan noi dung rollback quay ve noi dung truoc
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "port: 8080\nrouting: ok\n", string(data))
+	assert.Equal(t, []string{"port: 9090\nrouting: bad\n", "port: 8080\nrouting: ok\n"}, applied)
+}
+
+func TestHistory_Bounded(t *testing.T) {
+	h := NewHistory(2)
+	for _, content := range []string{"a", "b", "c"} {
+		h.Record("config.yml", "config", SourceFile, []byte(content))
+	}
+
+	revisions := h.List()
+	require.Len(t, revisions, 2)
+	assert.Equal(t, 3, revisions[0].Number)
+	assert.Equal(t, 2, revisions[1].Number)
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	a := "server:\n  port: 8080\nlog:\n  level: info\n"
+	b := "server:\n  port: 8081\nlog:\n  level: info\n"
+
+	diff := UnifiedDiff("rev 1", a, "rev 2", b)
+	assert.Contains(t, diff, "--- rev 1\n+++ rev 2\n")
+	assert.Contains(t, diff, "-  port: 8080\n+  port: 8081\n")
+	assert.Empty(t, UnifiedDiff("rev 1", a, "rev 2", a))
+}
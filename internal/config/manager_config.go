@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"path/filepath"
 	"sync"
 	"time"
 
@@ -12,26 +13,39 @@ import (
 )
 
 type ConfigManager struct {
-	mux      sync.RWMutex
-	config   *Config
-	viper    *viper.Viper // ban nang cap cua fsnoify wacther
-	onChange func(*Config)
-	ctx      context.Context
-	cancel   context.CancelFunc
-	startOne sync.Once
-	stopOne  sync.Once
-	wg       sync.WaitGroup
+	mux         sync.RWMutex
+	config      *Config
+	viper       *viper.Viper // chi dung de watch file, moi lan reload doc bang viper moi
+	configFile  string
+	overrides   map[string]any // gia tri tu Override, ap lai moi lan reload
+	reloadMux   sync.Mutex
+	subscribers []func(*Diff)
+	history     *History
+	ctx         context.Context
+	cancel      context.CancelFunc
+	startOne    sync.Once
+	stopOne     sync.Once
+	wg          sync.WaitGroup
 }
 
-func NewConfigManager(configDir string, onChange func(*Config)) (*ConfigManager, error) {
+func NewConfigManager(configDir string, onChange func(*Diff)) (*ConfigManager, error) {
+	return NewConfigManagerFromFile(filepath.Join(configDir, configFileName), onChange)
+}
 
-	m := &ConfigManager{
-		onChange: onChange,
+/*
+*Giong NewConfigManager nhung nhan duong dan file config truc tiep (config.yml hoac file gop)
+ */
+func NewConfigManagerFromFile(configFile string, onChange func(*Diff)) (*ConfigManager, error) {
+	m := &ConfigManager{}
+	if onChange != nil {
+		m.Subscribe(onChange)
 	}
 
-	m.loadConfig(configDir)
+	if err := m.loadConfig(configFile); err != nil {
+		return nil, err
+	}
 
-	slog.Info("Config manager initialized with hot-reload")
+	slog.Info("Config manager initialized with hot-reload", "file", configFile)
 
 	return m, nil
 }
@@ -62,13 +76,17 @@ func (m *ConfigManager) Stop() {
 	})
 }
 
+/*
+*Dang ky ham nhan diff moi khi config thay doi va hop le
+ */
+func (c *ConfigManager) Subscribe(fn func(*Diff)) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	c.subscribers = append(c.subscribers, fn)
+}
+
 func (c *ConfigManager) GetHealthCheckInterval() time.Duration {
-	duration, err := time.ParseDuration(c.config.Server.HealthCheckInterval)
-	if err != nil {
-		slog.Error("Failed to parse health check interval", "error", err)
-		return 10 * time.Second
-	}
-	return duration
+	return c.GetConfig().HealthInterval()
 }
 
 func (c *ConfigManager) watchConfig() {
@@ -82,11 +100,8 @@ func (c *ConfigManager) watchConfig() {
 			return
 		default:
 			slog.Debug("Config file changed", "event", e.Op.String())
-			c.reloadConfig()
-			//khi co tahy doi va cann thuc hien su kien khi thay doi thi goi onchange
-			if c.onChange != nil {
-				c.onChange(c.config)
-			}
+			// loi da duoc ghi log, config cu van duoc giu
+			_ = c.reloadConfig()
 		}
 	})
 
@@ -97,47 +112,185 @@ func (c *ConfigManager) watchConfig() {
 	slog.Info("Watcher goroutine received signal and is exiting...")
 }
 
-func (c *ConfigManager) loadConfig(configDir string) {
+func (c *ConfigManager) loadConfig(configFile string) error {
+	c.configFile = configFile
+	c.viper = newConfigViper(configFile)
+
+	return c.reloadConfig()
+}
+
+/*
+*Viper moi cho 1 lan reload: viper cua watcher tu ReadInConfig khi file doi,
+*doc chung voi no se tranh chap map ben trong. Phai goi khi dang giu reloadMux
+ */
+func (c *ConfigManager) newViper() *viper.Viper {
+	v := newConfigViper(c.configFile)
+	for key, value := range c.overrides {
+		v.Set(key, value)
+	}
+	return v
+}
+
+func newConfigViper(configFile string) *viper.Viper {
 	v := viper.New()
-	v.SetConfigName("config")
+	v.SetConfigFile(configFile)
 	v.SetConfigType("yml")
-	v.AddConfigPath(configDir)
 
 	v.AutomaticEnv()                            //bat che do quet bien moi turong tu dong
 	v.SetEnvPrefix("app")                       //chi nhan cac bien moi turong co prefix la APP_
 	v.BindEnv("server.port", "APP_SERVER_PORT") //neu moi turong co bien APP_SERVER_PORT thi ghi de no len server.port
 
-	c.viper = v
+	setDefaults(v)
 
-	c.reloadConfig()
+	return v
 }
 
-func (c *ConfigManager) reloadConfig() {
-	if err := c.viper.ReadInConfig(); err != nil {
-		slog.Error("Failed to read config file, using defaults", "err", err)
-		return
+/*
+*Doc file chinh roi gop cac file include
+ */
+func readConfig(v *viper.Viper) error {
+	if err := v.ReadInConfig(); err != nil {
+		return err
 	}
+	return MergeIncludes(v)
+}
 
-	cfg, err := unMarshalConfig(c.viper)
-	if err != nil {
-		err = fmt.Errorf("initial config unmarshal failed: %w", err)
+/*
+*Doc lai file, config khong hop le bi tu choi va giu nguyen config cu.
+*Config hop le thi tinh diff va bao cho cac subscriber
+ */
+func (c *ConfigManager) reloadConfig() error {
+	c.reloadMux.Lock()
+	defer c.reloadMux.Unlock()
+
+	v := c.newViper()
+	if err := readConfig(v); err != nil {
+		slog.Error("Failed to read config file, keeping previous config", "err", err)
+		return fmt.Errorf("failed to read config file %s: %w", c.configFile, err)
 	}
 
-	if !validateConfig(cfg) {
-		slog.Error("Initial config validation failed")
+	cfg, problems := decodeAndValidate(v)
+	logProblems(LocateProblems(problems))
+
+	if problems.HasErrors() {
+		slog.Error("Config validation failed, keeping previous config",
+			"file", c.configFile, "errors", len(problems.Errors()))
+		return &ValidationError{Problems: problems}
 	}
 
 	c.mux.Lock()
+	old := c.config
 	c.config = cfg
+	subscribers := append([]func(*Diff){}, c.subscribers...)
+	history := c.history
+	c.mux.Unlock()
+
+	if history != nil {
+		if _, err := history.RecordFile(c.configFile, "config", SourceFile); err != nil {
+			slog.Warn("Failed to record config revision", "err", err)
+		}
+	}
+
+	if old == nil {
+		slog.Info("Config loaded successfully", "file", c.configFile)
+		return nil
+	}
+
+	diff := ComputeDiff(old, cfg)
+	if diff.Empty() {
+		slog.Debug("Config reloaded, no runtime changes")
+		return nil
+	}
+
+	slog.Info("Config reloaded successfully", "changed", diff.Sections())
+	for _, fn := range subscribers {
+		fn(diff)
+	}
+
+	return nil
+}
+
+/*
+*Ghi nhan cac lan reload thanh cong vao history, rollback se goi lai Reload
+ */
+func (c *ConfigManager) SetHistory(h *History) error {
+	c.mux.Lock()
+	c.history = h
+	file := c.configFile
 	c.mux.Unlock()
 
-	slog.Info("Config reloaded successfully")
+	return h.Track(file, "config", c.Reload)
+}
+
+/*
+*Doc lai file config ngay, khong doi watcher
+ */
+func (c *ConfigManager) Reload() error {
+	return c.reloadConfig()
+}
+
+/*
+*Ghi de 1 gia tri config (vd tu flag CLI), gia tri nay giu nguyen qua cac lan hot reload
+ */
+func (c *ConfigManager) Override(key string, value any) error {
+	c.reloadMux.Lock()
+	if c.overrides == nil {
+		c.overrides = make(map[string]any)
+	}
+	c.overrides[key] = value
+	c.reloadMux.Unlock()
+
+	return c.reloadConfig()
+}
+
+/*
+*Doc va giai ma config 1 lan, khong theo doi thay doi
+ */
+func LoadConfig(configFile string) (*Config, error) {
+	cfg, problems := ValidateFile(configFile)
+	if problems.HasErrors() {
+		return nil, &ValidationError{Problems: problems}
+	}
+	return cfg, nil
+}
+
+/*
+*Doc, giai ma va kiem tra file config, tra ve moi van de kem vi tri dong trong file.
+*cfg = nil khi file khong doc hoac giai ma duoc
+ */
+func ValidateFile(configFile string) (*Config, Problems) {
+	v := newConfigViper(configFile)
+
+	if err := readConfig(v); err != nil {
+		return nil, Problems{{File: configFile, Severity: SeverityError, Message: fmt.Sprintf("failed to read config file: %v", err)}}
+	}
+
+	cfg, problems := decodeAndValidate(v)
+	return cfg, LocateProblems(problems)
+}
+
+/*
+*Kiem tra config da giai ma, path cua van de tinh tu goc file
+ */
+func ValidateConfig(c *Config) Problems {
+	return validateConfig(c)
+}
+
+func decodeAndValidate(v *viper.Viper) (*Config, Problems) {
+	cfg, err := unMarshalConfig(v)
+	if err != nil {
+		return nil, decodeProblems(err).In(v.ConfigFileUsed(), "")
+	}
+
+	return cfg, validateConfig(cfg).In(v.ConfigFileUsed(), "")
 }
 
 func (c *ConfigManager) GetConfig() *Config {
+	c.mux.RLock()
+	defer c.mux.RUnlock()
 	return c.config
 }
 
 func (c *ConfigManager) GetPortServer() int {
-	return c.config.Server.Port
+	return c.GetConfig().Server.Port
 }
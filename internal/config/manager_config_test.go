@@ -0,0 +1,44 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigManager_ReloadWhileWatching(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+	require.NoError(t, os.WriteFile(path, []byte("server:\n  port: 8080\nlog:\n  level: info\n"), 0644))
+
+	m, err := NewConfigManagerFromFile(path, nil)
+	require.NoError(t, err)
+	require.NoError(t, m.Override("log.level", "debug"))
+	require.NoError(t, m.Start())
+	defer m.Stop()
+
+	// Reload (vd tu admin rollback) chay cung luc voi watcher doc file
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 20; i++ {
+			_ = m.Reload()
+		}
+	}()
+	for i := 1; i <= 20; i++ {
+		require.NoError(t, WriteFileAtomic(path, fmt.Appendf(nil, "server:\n  port: %d\nlog:\n  level: info\n", 8080+i)))
+		time.Sleep(2 * time.Millisecond)
+	}
+	wg.Wait()
+
+	require.NoError(t, m.Reload())
+	assert.Equal(t, 8100, m.GetPortServer())
+	// gia tri override giu nguyen qua cac lan reload
+	assert.Equal(t, "debug", m.GetConfig().LogConfig.Level)
+}
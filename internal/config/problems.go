@@ -0,0 +1,263 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-viper/mapstructure/v2"
+	"gopkg.in/yaml.v3"
+)
+
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+/*
+*1 van de trong file config, Path theo dang yaml (vd backends[1].url).
+*Line/Column = 0 khi khong tim duoc vi tri trong file
+ */
+type Problem struct {
+	File     string   `json:"file,omitempty"`
+	Path     string   `json:"path"`
+	Line     int      `json:"line,omitempty"`
+	Column   int      `json:"column,omitempty"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+}
+
+func (p Problem) String() string {
+	var b strings.Builder
+	if p.File != "" {
+		b.WriteString(p.File)
+		if p.Line > 0 {
+			fmt.Fprintf(&b, ":%d:%d", p.Line, p.Column)
+		}
+		b.WriteString(": ")
+	}
+	b.WriteString(string(p.Severity))
+	b.WriteString(": ")
+	if p.Path != "" {
+		b.WriteString(p.Path)
+		b.WriteString(": ")
+	}
+	b.WriteString(p.Message)
+	return b.String()
+}
+
+type Problems []Problem
+
+func (ps *Problems) errorf(path, format string, args ...any) {
+	*ps = append(*ps, Problem{Path: path, Severity: SeverityError, Message: fmt.Sprintf(format, args...)})
+}
+
+func (ps *Problems) warnf(path, format string, args ...any) {
+	*ps = append(*ps, Problem{Path: path, Severity: SeverityWarning, Message: fmt.Sprintf(format, args...)})
+}
+
+func (ps Problems) Errors() Problems {
+	return ps.filter(SeverityError)
+}
+
+func (ps Problems) Warnings() Problems {
+	return ps.filter(SeverityWarning)
+}
+
+func (ps Problems) HasErrors() bool {
+	return len(ps.Errors()) > 0
+}
+
+func (ps Problems) filter(sev Severity) Problems {
+	var out Problems
+	for _, p := range ps {
+		if p.Severity == sev {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+/*
+*Gan file va them tien to cho path, dung khi section nam trong file gop (vd routing.rules[0])
+ */
+func (ps Problems) In(file, prefix string) Problems {
+	out := make(Problems, len(ps))
+	for i, p := range ps {
+		p.File = file
+		if prefix != "" {
+			if p.Path == "" {
+				p.Path = prefix
+			} else {
+				p.Path = prefix + "." + p.Path
+			}
+		}
+		out[i] = p
+	}
+	return out
+}
+
+/*
+*Sap xep theo file, dong de doc ket qua theo thu tu trong file
+ */
+func (ps Problems) Sort() {
+	sort.SliceStable(ps, func(i, j int) bool {
+		if ps[i].File != ps[j].File {
+			return ps[i].File < ps[j].File
+		}
+		return ps[i].Line < ps[j].Line
+	})
+}
+
+/*
+*Tra ve qua error khi config co loi, chua toan bo danh sach van de
+ */
+type ValidationError struct {
+	Problems Problems
+}
+
+func (e *ValidationError) Error() string {
+	errs := e.Problems.Errors()
+	msgs := make([]string, len(errs))
+	for i, p := range errs {
+		msgs[i] = p.String()
+	}
+	return fmt.Sprintf("config validation failed with %d error(s): %s", len(errs), strings.Join(msgs, "; "))
+}
+
+/*
+*Chuyen loi decode cua mapstructure thanh danh sach van de theo path
+ */
+func decodeProblems(err error) Problems {
+	var ps Problems
+	collectDecodeErrors(err, &ps)
+	return ps
+}
+
+func collectDecodeErrors(err error, ps *Problems) {
+	switch e := err.(type) {
+	case *mapstructure.DecodeError:
+		if inner, ok := e.Unwrap().(interface{ Unwrap() []error }); ok {
+			for _, ie := range inner.Unwrap() {
+				collectDecodeErrors(ie, ps)
+			}
+			return
+		}
+		ps.errorf(e.Name(), "%v", e.Unwrap())
+	case interface{ Unwrap() []error }:
+		for _, inner := range e.Unwrap() {
+			collectDecodeErrors(inner, ps)
+		}
+	case interface{ Unwrap() error }:
+		collectDecodeErrors(e.Unwrap(), ps)
+	default:
+		ps.errorf("", "%v", err)
+	}
+}
+
+/*
+*Dien Line/Column cho cac van de bang cach tim path trong cay node yaml cua file.
+*Path khong ton tai (vd thieu key) thi lay vi tri cua node cha gan nhat
+ */
+func LocateProblems(ps Problems) Problems {
+	roots := make(map[string]*yaml.Node)
+
+	for i := range ps {
+		p := &ps[i]
+		if p.File == "" {
+			continue
+		}
+
+		root, ok := roots[p.File]
+		if !ok {
+			root = parseYAMLFile(p.File)
+			roots[p.File] = root
+		}
+		if root == nil {
+			continue
+		}
+
+		if node := lookupNode(root, p.Path); node != nil {
+			p.Line, p.Column = node.Line, node.Column
+		}
+	}
+
+	return ps
+}
+
+func parseYAMLFile(path string) *yaml.Node {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil || len(doc.Content) == 0 {
+		return nil
+	}
+	return doc.Content[0]
+}
+
+/*
+*Di theo path dang a.b[2].c, voi mapping thi tra ve node key de bao dung dong cua key
+ */
+func lookupNode(root *yaml.Node, path string) *yaml.Node {
+	if path == "" {
+		return root
+	}
+
+	current, found := root, root
+	for _, seg := range splitPath(path) {
+		next, key := childNode(current, seg)
+		if next == nil {
+			return found
+		}
+		current = next
+		found = next
+		if key != nil {
+			found = key
+		}
+	}
+	return found
+}
+
+func childNode(n *yaml.Node, seg string) (value, key *yaml.Node) {
+	if idx, err := strconv.Atoi(seg); err == nil {
+		if n.Kind == yaml.SequenceNode && idx >= 0 && idx < len(n.Content) {
+			return n.Content[idx], nil
+		}
+		return nil, nil
+	}
+
+	if n.Kind != yaml.MappingNode {
+		return nil, nil
+	}
+
+	// viper dua key ve chu thuong
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if strings.EqualFold(n.Content[i].Value, seg) {
+			return n.Content[i+1], n.Content[i]
+		}
+	}
+	return nil, nil
+}
+
+/*
+*"backends[1].url" -> ["backends", "1", "url"]
+ */
+func splitPath(path string) []string {
+	path = strings.ReplaceAll(path, "[", ".")
+	path = strings.ReplaceAll(path, "]", "")
+
+	var segs []string
+	for _, s := range strings.Split(path, ".") {
+		if s != "" {
+			segs = append(segs, s)
+		}
+	}
+	return segs
+}
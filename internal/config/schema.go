@@ -0,0 +1,156 @@
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	jsonSchemaDraft = "https://json-schema.org/draft/2020-12/schema"
+	schemaIDBase    = "https://github.com/nhutphuongasasa/loadbalancer/schema/"
+
+	// 1 hoac nhieu cap so + don vi, giong time.ParseDuration
+	durationPattern = `^([0-9]+(\.[0-9]+)?(ns|us|µs|ms|s|m|h))+$`
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// Gia tri hop le cua cac key dang enum, lay tu validator de khong bi lech
+var schemaEnums = map[string][]string{
+	"load_balancer.strategy": keysOf(validStrategies),
+	"log.level":              keysOf(validLogLevels),
+	"log.format":             {"json", "text"},
+	"registry.auth.mode":     keysOf(validRegistryAuthModes),
+	"tls.http":               keysOf(validHTTPModes),
+	"tls.acme.storage":       keysOf(validACMEStorages),
+	"tls.client_auth.mode":   keysOf(validClientAuthModes),
+	"upstreams.protocol":     keysOf(validUpstreamProtocols),
+}
+
+/*
+*Sinh JSON Schema cho config.yml (hoac file gop) tu struct Config, dung cho editor
+ */
+func JSONSchema() ([]byte, error) {
+	return marshalSchema(reflect.TypeOf(Config{}), "config.schema.json", "Load balancer configuration")
+}
+
+/*
+*JSON Schema cho file routing.yml tach rieng
+ */
+func RoutingJSONSchema() ([]byte, error) {
+	return marshalSchema(reflect.TypeOf(RoutingConfig{}), "routing.schema.json", "Load balancer routing rules")
+}
+
+func marshalSchema(t reflect.Type, id, title string) ([]byte, error) {
+	root := schemaFor(t, "", "")
+	root["$schema"] = jsonSchemaDraft
+	root["$id"] = schemaIDBase + id
+	root["title"] = title
+
+	data, err := json.MarshalIndent(root, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+/*
+*path la key dang a.b dung de tra default va enum, format lay tu tag schema
+ */
+func schemaFor(t reflect.Type, path, format string) map[string]any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	s := map[string]any{}
+
+	switch {
+	case t == durationType || format == "duration":
+		s["type"] = "string"
+		s["pattern"] = durationPattern
+	case t.Kind() == reflect.Struct:
+		s["type"] = "object"
+		s["additionalProperties"] = false
+		s["properties"] = structProperties(t, path)
+	case t.Kind() == reflect.Slice:
+		s["type"] = "array"
+		s["items"] = schemaFor(t.Elem(), "", "")
+	case t.Kind() == reflect.Map:
+		s["type"] = "object"
+		s["additionalProperties"] = schemaFor(t.Elem(), "", "")
+	case t.Kind() == reflect.String:
+		s["type"] = "string"
+	case t.Kind() == reflect.Bool:
+		s["type"] = "boolean"
+	case t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64:
+		s["type"] = "number"
+	case t.Kind() >= reflect.Int && t.Kind() <= reflect.Int64:
+		s["type"] = "integer"
+	case t.Kind() >= reflect.Uint && t.Kind() <= reflect.Uint64:
+		s["type"] = "integer"
+		s["minimum"] = 0
+	}
+
+	if enum, ok := schemaEnums[path]; ok {
+		s["enum"] = enum
+	}
+
+	if value, ok := defaults[path]; ok {
+		if d, isDuration := value.(time.Duration); isDuration {
+			value = d.String()
+		}
+		s["default"] = value
+	}
+
+	return s
+}
+
+func structProperties(t reflect.Type, path string) map[string]any {
+	props := make(map[string]any, t.NumField())
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+
+		name, _, _ := strings.Cut(f.Tag.Get("mapstructure"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+
+		key := name
+		if path != "" {
+			key = path + "." + name
+		}
+
+		prop := schemaFor(f.Type, key, f.Tag.Get("schema"))
+		if desc := f.Tag.Get("desc"); desc != "" {
+			prop["description"] = desc
+		}
+		props[name] = prop
+	}
+
+	if path == "" {
+		if version, ok := props["version"].(map[string]any); ok {
+			version["minimum"] = 1
+			version["maximum"] = SchemaVersion
+		}
+	}
+
+	return props
+}
+
+func keysOf(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		if k != "" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
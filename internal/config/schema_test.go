@@ -0,0 +1,53 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// File schema trong repo phai khop voi struct, chay lb schema de cap nhat
+func TestJSONSchema_MatchesCommittedFiles(t *testing.T) {
+	tests := []struct {
+		file     string
+		generate func() ([]byte, error)
+	}{
+		{"config.schema.json", JSONSchema},
+		{"routing.schema.json", RoutingJSONSchema},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			want, err := tt.generate()
+			require.NoError(t, err)
+
+			got, err := os.ReadFile(filepath.Join("..", "..", "config", tt.file))
+			require.NoError(t, err)
+			assert.Equal(t, string(want), string(got), "regenerate with: lb schema > config/%s", tt.file)
+		})
+	}
+}
+
+func TestJSONSchema_DefaultsAndEnums(t *testing.T) {
+	data, err := JSONSchema()
+	require.NoError(t, err)
+
+	var schema struct {
+		Properties map[string]struct {
+			Properties map[string]map[string]any `json:"properties"`
+		} `json:"properties"`
+	}
+	require.NoError(t, json.Unmarshal(data, &schema))
+
+	registry := schema.Properties["registry"].Properties
+	assert.Equal(t, float64(8000), registry["port"]["default"])
+	assert.Equal(t, "30s", registry["instance_ttl"]["default"])
+	assert.Equal(t, "string", registry["instance_ttl"]["type"])
+
+	strategy := schema.Properties["load_balancer"].Properties["strategy"]
+	assert.Contains(t, strategy["enum"], "least_conn")
+}
@@ -1,42 +1,565 @@
 package config
 
-import "log/slog"
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"log/slog"
+	"net"
+	"strings"
+	"time"
+)
 
+var validRegistryAuthModes = map[string]bool{
+	"":        true,
+	"none":    true,
+	"hmac":    true,
+	"api_key": true,
+}
+
+// round_robin da tinh theo weight cua instance
 var validStrategies = map[string]bool{
-	"round_robin":        true,
-	"weight_round_robin": true,
-	"least_conn":         true,
-	"ip_hash":            true,
+	"round_robin": true,
+	"least_conn":  true,
+	"ip_hash":     true,
+}
+
+var validHTTPModes = map[string]bool{
+	"":               true,
+	HTTPModeRedirect: true,
+	HTTPModeServe:    true,
+	HTTPModeOff:      true,
+}
+
+var validACMEStorages = map[string]bool{
+	"":               true,
+	ACMEStorageDir:   true,
+	ACMEStorageRedis: true,
+}
+
+var validClientAuthModes = map[string]bool{
+	ClientAuthNone:          true,
+	ClientAuthOptional:      true,
+	ClientAuthVerifyIfGiven: true,
+	ClientAuthRequire:       true,
+}
+
+var validUpstreamProtocols = map[string]bool{
+	"":                    true,
+	UpstreamProtocolAuto:  true,
+	UpstreamProtocolHTTP1: true,
+	UpstreamProtocolHTTP2: true,
+	UpstreamProtocolH2C:   true,
+}
+
+var validLogLevels = map[string]bool{
+	"":      true,
+	"debug": true,
+	"info":  true,
+	"warn":  true,
+	"error": true,
+}
+
+/*
+*Kiem tra toan bo config va tra ve tat ca van de tim thay thay vi dung o loi dau tien
+ */
+func validateConfig(c *Config) Problems {
+	var ps Problems
+
+	if c == nil {
+		ps.errorf("", "config is empty")
+		return ps
+	}
+
+	switch {
+	case c.Version == 0:
+		ps.warnf("version", "missing, assuming schema version %d", SchemaVersion)
+	case c.Version > SchemaVersion || c.Version < 0:
+		ps.errorf("version", "unsupported schema version %d (this build supports %d)", c.Version, SchemaVersion)
+	}
+
+	validateServerConfig(c.Server, &ps)
+	validateTLSConfig(c.TLS, c.Server, &ps)
+	if c.TLS != nil {
+		validateACMEConfig(c.TLS, c.RedisConfig, &ps)
+		validateClientAuthConfig(c.TLS, &ps)
+	}
+
+	if c.Strategy == nil {
+		ps.errorf("load_balancer", "section is required")
+	} else if !validStrategies[c.Strategy.Strategy] {
+		ps.errorf("load_balancer.strategy", "unknown strategy %q (supported: %s)", c.Strategy.Strategy, strings.Join(Strategies(), ", "))
+	}
+
+	if c.LogConfig != nil && !validLogLevels[strings.ToLower(c.LogConfig.Level)] {
+		ps.errorf("log.level", "unknown log level %q (supported: debug, info, warn, error)", c.LogConfig.Level)
+	}
+
+	if c.RateLimit != nil {
+		if c.RateLimit.RequestsPerSecond <= 0 {
+			ps.errorf("rate_limit.requests_per_second", "must be positive, got %v", c.RateLimit.RequestsPerSecond)
+		}
+		if c.RateLimit.Burst <= 0 {
+			ps.errorf("rate_limit.burst", "must be positive, got %d", c.RateLimit.Burst)
+		}
+	}
+
+	if c.Session != nil && c.Session.TTL <= 0 {
+		ps.errorf("session.ttl", "must be positive, got %s", c.Session.TTL)
+	}
+
+	validateCacheConfig(c.RedisConfig, &ps)
+	validateBackends(c.BackEnds, &ps)
+	validateResilienceConfig(c.Resilience, &ps)
+	validateUpstreams(c.Upstreams, &ps)
+	validateTCPConfig(c, &ps)
+	validateTCPBackends(c, &ps)
+
+	if c.Registry != nil {
+		validateRegistryConfig(c.Registry, &ps)
+	}
+
+	if c.Admin != nil {
+		validateAdminConfig(c.Admin, &ps)
+	}
+
+	return ps
+}
+
+func validateServerConfig(s *ServerConfig, ps *Problems) {
+	if s == nil {
+		ps.errorf("server", "section is required")
+		return
+	}
+
+	if s.Port <= 0 || s.Port > 65535 {
+		ps.errorf("server.port", "must be between 1 and 65535, got %d", s.Port)
+	}
+
+	if s.HealthCheckInterval != "" {
+		d, err := time.ParseDuration(s.HealthCheckInterval)
+		switch {
+		case err != nil:
+			ps.errorf("server.health_check_interval", "invalid duration %q", s.HealthCheckInterval)
+		case d <= 0:
+			ps.errorf("server.health_check_interval", "must be positive, got %s", d)
+		}
+	}
+
+	// 0 nghia la khong gioi han, giong net/http
+	timeouts := []struct {
+		path  string
+		value time.Duration
+	}{
+		{"server.read_timeout", s.ReadTimeout},
+		{"server.write_timeout", s.WriteTimeout},
+		{"server.idle_timeout", s.IdleTimeout},
+		{"server.stream_idle_timeout", s.StreamIdleTimeout},
+	}
+	for _, t := range timeouts {
+		if t.value < 0 {
+			ps.errorf(t.path, "must not be negative, got %s", t.value)
+		}
+	}
+}
+
+func validateTLSConfig(t *TLSConfig, s *ServerConfig, ps *Problems) {
+	if t == nil || !t.Enabled {
+		return
+	}
+
+	if t.Port <= 0 || t.Port > 65535 {
+		ps.errorf("tls.port", "must be between 1 and 65535, got %d", t.Port)
+	} else if s != nil && s.Port == t.Port && t.HTTP != HTTPModeOff {
+		ps.errorf("tls.port", "must differ from server.port (%d) unless tls.http is off", s.Port)
+	}
+
+	if !validHTTPModes[t.HTTP] {
+		ps.errorf("tls.http", "unknown mode %q (supported: redirect, serve, off)", t.HTTP)
+	}
+
+	defaults := 0
+	for i, c := range t.Certificates {
+		path := fmt.Sprintf("tls.certificates[%d]", i)
+		if c.CertFile == "" {
+			ps.errorf(path+".cert_file", "is required")
+		}
+		if c.KeyFile == "" {
+			ps.errorf(path+".key_file", "is required")
+		}
+		if c.Default {
+			defaults++
+			if defaults > 1 {
+				ps.errorf(path+".default", "only one certificate can be the default")
+			}
+		}
+	}
+
+	if t.HSTS != nil {
+		if t.HSTS.MaxAge < 0 {
+			ps.errorf("tls.hsts.max_age", "must not be negative, got %s", t.HSTS.MaxAge)
+		}
+		if t.HSTS.Preload && (t.HSTS.MaxAge < 365*24*time.Hour || !t.HSTS.IncludeSubdomains) {
+			ps.warnf("tls.hsts.preload", "preload lists require max_age of at least 1 year and include_subdomains")
+		}
+	}
+}
+
+func validateACMEConfig(t *TLSConfig, cache *CacheConfig, ps *Problems) {
+	a := t.ACME
+	if a == nil || !a.Enabled {
+		return
+	}
+
+	if !t.Enabled {
+		ps.warnf("tls.acme.enabled", "ACME has no effect while tls.enabled is false")
+	}
+
+	if len(a.Domains) == 0 {
+		ps.errorf("tls.acme.domains", "at least one domain is required")
+	}
+	for i, d := range a.Domains {
+		path := fmt.Sprintf("tls.acme.domains[%d]", i)
+		switch {
+		case strings.Contains(d, "*"):
+			ps.errorf(path, "wildcard %q needs DNS-01, which is not supported", d)
+		case d == "" || strings.ContainsAny(d, "/: ") || net.ParseIP(d) != nil:
+			ps.errorf(path, "invalid domain %q", d)
+		}
+	}
+
+	if !a.AcceptTOS {
+		ps.errorf("tls.acme.accept_tos", "must be true to register with the CA")
+	}
+
+	if !validACMEStorages[a.Storage] {
+		ps.errorf("tls.acme.storage", "unknown storage %q (supported: dir, redis)", a.Storage)
+	} else if a.Storage == ACMEStorageRedis && cache == nil {
+		ps.errorf("tls.acme.storage", "redis storage requires the cache section")
+	}
+
+	if a.RenewBefore < 0 {
+		ps.errorf("tls.acme.renew_before", "must not be negative, got %s", a.RenewBefore)
+	}
+
+	if t.HTTP == HTTPModeOff {
+		ps.warnf("tls.http", "HTTP-01 challenges need the HTTP listener, only TLS-ALPN-01 on port 443 will work")
+	}
+}
+
+func validateClientAuthConfig(t *TLSConfig, ps *Problems) {
+	a := t.ClientAuth
+	if a == nil || a.Mode == "" || a.Mode == ClientAuthNone {
+		return
+	}
+
+	if !validClientAuthModes[a.Mode] {
+		ps.errorf("tls.client_auth.mode", "unknown mode %q (supported: %s)", a.Mode, strings.Join(keysOf(validClientAuthModes), ", "))
+		return
+	}
+	if !t.Enabled {
+		ps.warnf("tls.client_auth", "has no effect while tls.enabled is false")
+	}
+	if a.Mode != ClientAuthOptional && a.CAFile == "" {
+		ps.errorf("tls.client_auth.ca_file", "is required when mode is %s", a.Mode)
+	}
+	if a.Mode == ClientAuthOptional && a.ForwardHeaders {
+		ps.warnf("tls.client_auth.mode", "optional does not verify certificates, backends must check X-Client-Cert-Verified")
+	}
+}
+
+func validateCacheConfig(c *CacheConfig, ps *Problems) {
+	if c == nil {
+		ps.warnf("cache", "no cache configured, sticky sessions will not work")
+		return
+	}
+
+	if c.Addr == "" {
+		ps.errorf("cache.addr", "is required")
+	}
+	if c.PoolSize < 0 {
+		ps.errorf("cache.pool_size", "must not be negative, got %d", c.PoolSize)
+	}
+	if c.Timeout < 0 {
+		ps.errorf("cache.timeout", "must not be negative, got %s", c.Timeout)
+	}
+}
+
+func validateBackends(backends []*BackEndConfig, ps *Problems) {
+	if len(backends) == 0 {
+		ps.warnf("backends", "no static backends configured, services must register through the registry")
+		return
+	}
+
+	seen := make(map[string]int, len(backends))
+	for i, be := range backends {
+		path := fmt.Sprintf("backends[%d]", i)
+
+		if be.Service == "" {
+			ps.errorf(path+".service", "is required")
+		}
+
+		if be.Url == "" {
+			ps.errorf(path+".url", "is required")
+		} else if _, _, err := be.HostPort(); err != nil {
+			ps.errorf(path+".url", "%v", err)
+		}
+
+		if be.Weight < 0 {
+			ps.errorf(path+".weight", "must not be negative, got %d", be.Weight)
+		} else if be.Weight == 0 {
+			be.Weight = 1
+		}
+
+		if first, dup := seen[be.Key()]; dup {
+			ps.errorf(path, "duplicate of backends[%d] (same service and url)", first)
+		} else {
+			seen[be.Key()] = i
+		}
+	}
+}
+
+func validateResilienceConfig(r *ResilienceConfig, ps *Problems) {
+	if r == nil {
+		return
+	}
+
+	if cb := r.CircuitBreaker; cb != nil {
+		if cb.MaxFailures == 0 {
+			ps.errorf("resilience.circuit_breaker.max_failures", "must be positive")
+		}
+		if cb.Timeout <= 0 {
+			ps.errorf("resilience.circuit_breaker.timeout", "must be positive, got %s", cb.Timeout)
+		}
+		if cb.Interval < 0 {
+			ps.errorf("resilience.circuit_breaker.interval", "must not be negative, got %s", cb.Interval)
+		}
+	}
+
+	if rt := r.Retry; rt != nil {
+		if rt.MaxRetries < 0 {
+			ps.errorf("resilience.retry.max_retries", "must not be negative, got %d", rt.MaxRetries)
+		}
+		if rt.BaseDelay <= 0 {
+			ps.errorf("resilience.retry.base_delay", "must be positive, got %s", rt.BaseDelay)
+		}
+		if rt.MaxDelay < rt.BaseDelay {
+			ps.errorf("resilience.retry.max_delay", "must not be lower than base_delay (%s)", rt.BaseDelay)
+		}
+		if rt.Jitter < 0 || rt.Jitter > 1 {
+			ps.errorf("resilience.retry.jitter", "must be between 0 and 1, got %v", rt.Jitter)
+		}
+		if rt.MaxBody < 0 {
+			ps.errorf("resilience.retry.max_body_size", "must not be negative, got %d", rt.MaxBody)
+		}
+	}
+}
+
+func validateUpstreams(upstreams []*UpstreamConfig, ps *Problems) {
+	seen := make(map[string]int, len(upstreams))
+	for i, u := range upstreams {
+		path := fmt.Sprintf("upstreams[%d]", i)
+
+		if u.Service == "" {
+			ps.errorf(path+".service", "is required")
+		} else if first, dup := seen[u.Service]; dup {
+			ps.errorf(path+".service", "duplicate of upstreams[%d]", first)
+		} else {
+			seen[u.Service] = i
+		}
+
+		tlsEnabled := u.TLS != nil && u.TLS.Enabled
+		if !validUpstreamProtocols[u.Protocol] {
+			ps.errorf(path+".protocol", "unknown protocol %q (supported: %s)", u.Protocol, strings.Join(keysOf(validUpstreamProtocols), ", "))
+		} else if u.Protocol == UpstreamProtocolHTTP2 && !tlsEnabled {
+			ps.errorf(path+".protocol", "http2 needs tls.enabled, use h2c for cleartext backends")
+		} else if u.Protocol == UpstreamProtocolH2C && tlsEnabled {
+			ps.errorf(path+".protocol", "h2c is cleartext, use http2 with tls.enabled")
+		}
+		if u.MaxConnsPerHost < 0 {
+			ps.errorf(path+".max_conns_per_host", "must not be negative, got %d", u.MaxConnsPerHost)
+		}
+
+		t := u.TLS
+		if t == nil {
+			continue
+		}
+		if !t.Enabled {
+			ps.warnf(path+".tls", "settings are ignored while enabled is false")
+		}
+		if (t.CertFile == "") != (t.KeyFile == "") {
+			ps.errorf(path+".tls", "cert_file and key_file must be set together")
+		}
+		for j, pin := range t.PinnedSHA256 {
+			raw, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(pin, "sha256/"))
+			if err != nil || len(raw) != sha256.Size {
+				ps.errorf(fmt.Sprintf("%s.tls.pinned_sha256[%d]", path, j), "invalid pin %q, expected sha256/<base64>", pin)
+			}
+		}
+		if t.InsecureSkipVerify && len(t.PinnedSHA256) == 0 {
+			ps.warnf(path+".tls.insecure_skip_verify", "backend certificates are not verified at all, consider pinned_sha256")
+		}
+	}
+}
+
+func validateTCPConfig(c *Config, ps *Problems) {
+	t := c.TCP
+	if t == nil {
+		return
+	}
+
+	if t.ConnectTimeout <= 0 {
+		ps.errorf("tcp.connect_timeout", "must be positive, got %s", t.ConnectTimeout)
+	}
+	if t.IdleTimeout < 0 {
+		ps.errorf("tcp.idle_timeout", "must not be negative, got %s", t.IdleTimeout)
+	}
+	if t.DrainTimeout < 0 {
+		ps.errorf("tcp.drain_timeout", "must not be negative, got %s", t.DrainTimeout)
+	}
+
+	// port cua cac listener HTTP, trung thi listener sau khong mo duoc
+	taken := map[int]string{}
+	if c.Server != nil {
+		taken[c.Server.Port] = "server.port"
+	}
+	if c.TLS != nil && c.TLS.Enabled {
+		taken[c.TLS.Port] = "tls.port"
+	}
+	if c.Registry != nil {
+		taken[c.Registry.Port] = "registry.port"
+	}
+
+	for i, l := range t.Listeners {
+		path := fmt.Sprintf("tcp.listeners[%d]", i)
+
+		if l.Service == "" {
+			ps.errorf(path+".service", "is required")
+		}
+		if l.Port <= 0 || l.Port > 65535 {
+			ps.errorf(path+".port", "must be between 1 and 65535, got %d", l.Port)
+			continue
+		}
+		if other, dup := taken[l.Port]; dup {
+			ps.errorf(path+".port", "port %d is already used by %s", l.Port, other)
+			continue
+		}
+		taken[l.Port] = path
+	}
 }
 
-func validateConfig(c *Config) bool {
-	if c.Server.Port <= 0 || c.Server.Port > 65535 {
-		slog.Error("Invalid server port", "port", c.Server.Port)
-		return false
+/*
+*Backend tcp:// chi nhan ket noi tu listener tcp, service khong co listener thi khong ai dung toi
+ */
+func validateTCPBackends(c *Config, ps *Problems) {
+	listened := map[string]bool{}
+	if c.TCP != nil {
+		for _, l := range c.TCP.Listeners {
+			listened[l.Service] = true
+		}
+	}
+
+	for i, be := range c.BackEnds {
+		if be.Scheme() == "tcp" && !listened[be.Service] {
+			ps.warnf(fmt.Sprintf("backends[%d].url", i), "service %q has no tcp listener, the backend will never receive connections", be.Service)
+		}
 	}
+}
 
-	if !validStrategies[c.Strategy.Strategy] {
-		slog.Error("Invalid load balancing strategy", "strategy", c.Strategy.Strategy)
-		return false
+func validateRegistryConfig(r *RegistryConfig, ps *Problems) {
+	if r.Port < 0 || r.Port > 65535 {
+		ps.errorf("registry.port", "must be between 1 and 65535, got %d", r.Port)
 	}
+	if r.InstanceTTL < 0 {
+		ps.errorf("registry.instance_ttl", "must not be negative, got %s", r.InstanceTTL)
+	}
+	if r.MaxInstancesPerService < 0 {
+		ps.errorf("registry.max_instances_per_service", "must not be negative, got %d", r.MaxInstancesPerService)
+	}
+
+	if r.Auth != nil {
+		if !validRegistryAuthModes[r.Auth.Mode] {
+			ps.errorf("registry.auth.mode", "unknown mode %q (supported: none, hmac, api_key)", r.Auth.Mode)
+		}
+
+		if r.Auth.Mode == "hmac" && r.Auth.SharedSecret == "" {
+			ps.errorf("registry.auth.shared_secret", "is required when mode is hmac")
+		}
 
-	if c.RedisConfig == nil {
-		slog.Error("Can not be start load balancer without cache")
+		if r.Auth.Mode == "api_key" && len(r.Auth.APIKeys) == 0 {
+			ps.errorf("registry.auth.api_keys", "at least one key is required when mode is api_key")
+		}
+
+		if r.Auth.MaxClockSkew < 0 {
+			ps.errorf("registry.auth.max_clock_skew", "must not be negative, got %s", r.Auth.MaxClockSkew)
+		}
 	}
 
-	if len(c.BackEnds) == 0 {
-		slog.Warn("No backend servers configured")
-	} else {
-		for i, be := range c.BackEnds {
-			if be.Url == "" {
-				slog.Error("Backend missing URL", "index", i)
-				return false
+	for i, p := range r.Policies {
+		path := fmt.Sprintf("registry.policies[%d]", i)
+
+		if p.Service == "" {
+			ps.errorf(path+".service", "is required")
+		}
+		for j, cidr := range p.CIDRs {
+			if _, _, err := net.ParseCIDR(cidr); err != nil {
+				ps.errorf(fmt.Sprintf("%s.cidrs[%d]", path, j), "invalid CIDR %q", cidr)
 			}
-			if be.Weight <= 0 {
-				be.Weight = 1
+		}
+		for j, port := range p.Ports {
+			if port <= 0 || port > 65535 {
+				ps.errorf(fmt.Sprintf("%s.ports[%d]", path, j), "must be between 1 and 65535, got %d", port)
 			}
 		}
 	}
 
-	return true
+	if r.Snapshot != nil && r.Snapshot.Path != "" && r.Snapshot.Interval <= 0 {
+		ps.errorf("registry.snapshot.interval", "must be positive when snapshot.path is set")
+	}
+}
+
+func validateAdminConfig(a *AdminConfig, ps *Problems) {
+	if !a.Enabled || a.Socket != "" {
+		return
+	}
+
+	if a.Addr == "" {
+		ps.errorf("admin.addr", "addr or socket is required when admin is enabled")
+		return
+	}
+
+	host, _, err := net.SplitHostPort(a.Addr)
+	if err != nil {
+		ps.errorf("admin.addr", "invalid address %q: %v", a.Addr, err)
+		return
+	}
+
+	if ip := net.ParseIP(host); (host == "" || (ip != nil && !ip.IsLoopback())) && a.Token == "" {
+		ps.warnf("admin.addr", "listening on non-loopback address %q without admin.token", a.Addr)
+	}
+}
+
+/*
+*Cac strategy config chap nhan, app phai dung duoc moi gia tri trong danh sach
+ */
+func Strategies() []string {
+	return keysOf(validStrategies)
+}
+
+/*
+*Ghi tung van de ra log, dung khi nap config trong luc chay
+ */
+func logProblems(ps Problems) {
+	for _, p := range ps {
+		attrs := []any{"path", p.Path}
+		if p.File != "" {
+			attrs = append(attrs, "file", p.File, "line", p.Line)
+		}
+
+		if p.Severity == SeverityError {
+			slog.Error("Config error: "+p.Message, attrs...)
+		} else {
+			slog.Warn("Config warning: "+p.Message, attrs...)
+		}
+	}
 }
@@ -0,0 +1,171 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateFile_ReportsAllProblemsWithLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+	require.NoError(t, os.WriteFile(path, []byte(`server:
+  port: 0
+  health_check_interval: soon
+load_balancer:
+  strategy: fastest
+cache:
+  addr: "localhost:6379"
+backends:
+  - service: web
+    url: "http://10.0.0.1"
+  - service: web
+    url: ""
+`), 0644))
+
+	_, problems := ValidateFile(path)
+
+	errs := problems.Errors()
+	require.Len(t, errs, 4)
+
+	byPath := make(map[string]Problem, len(errs))
+	for _, p := range errs {
+		assert.Equal(t, path, p.File)
+		byPath[p.Path] = p
+	}
+
+	assert.Equal(t, 2, byPath["server.port"].Line)
+	assert.Equal(t, 3, byPath["server.health_check_interval"].Line)
+	assert.Equal(t, 5, byPath["load_balancer.strategy"].Line)
+	assert.Equal(t, 12, byPath["backends[1].url"].Line)
+}
+
+func TestValidateFile_DecodeErrors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+	require.NoError(t, os.WriteFile(path, []byte("server:\n  port: abc\ncache:\n  timeout: 5x\n"), 0644))
+
+	cfg, problems := ValidateFile(path)
+	assert.Nil(t, cfg)
+
+	paths := make([]string, 0, len(problems))
+	for _, p := range problems {
+		paths = append(paths, p.Path)
+	}
+	assert.ElementsMatch(t, []string{"server.port", "cache.timeout"}, paths)
+}
+
+func TestReloadConfig_InvalidKeepsPrevious(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+	require.NoError(t, os.WriteFile(path, []byte("server:\n  port: 8080\nload_balancer:\n  strategy: round_robin\n"), 0644))
+
+	m, err := NewConfigManagerFromFile(path, nil)
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(path, []byte("server:\n  port: 70000\nload_balancer:\n  strategy: round_robin\n"), 0644))
+
+	var verr *ValidationError
+	require.ErrorAs(t, m.reloadConfig(), &verr)
+	assert.Equal(t, "server.port", verr.Problems.Errors()[0].Path)
+	assert.Equal(t, 8080, m.GetPortServer())
+}
+
+func TestValidateConfig_TLS(t *testing.T) {
+	cfg := Defaults()
+	cfg.TLS.Enabled = true
+	cfg.TLS.Port = cfg.Server.Port
+	cfg.TLS.HTTP = "upgrade"
+
+	paths := make([]string, 0, 2)
+	for _, p := range ValidateConfig(cfg).Errors() {
+		paths = append(paths, p.Path)
+	}
+	assert.ElementsMatch(t, []string{"tls.port", "tls.http"}, paths)
+
+	cfg.TLS.HTTP = HTTPModeOff
+	assert.False(t, ValidateConfig(cfg).HasErrors())
+}
+
+func TestValidateConfig_ACME(t *testing.T) {
+	cfg := Defaults()
+	cfg.TLS.Enabled = true
+	cfg.TLS.ACME.Enabled = true
+	cfg.TLS.ACME.Domains = []string{"*.example.com", "api.example.com"}
+	cfg.TLS.ACME.Storage = ACMEStorageRedis
+	cfg.RedisConfig = nil
+
+	paths := make([]string, 0, 3)
+	for _, p := range ValidateConfig(cfg).Errors() {
+		paths = append(paths, p.Path)
+	}
+	assert.ElementsMatch(t, []string{"tls.acme.domains[0]", "tls.acme.accept_tos", "tls.acme.storage"}, paths)
+}
+
+func TestValidateConfig_Upstreams(t *testing.T) {
+	cfg := Defaults()
+	cfg.Upstreams = []*UpstreamConfig{
+		{Service: "pay", TLS: &UpstreamTLSConfig{Enabled: true, CertFile: "client.crt", PinnedSHA256: []string{"sha256/bm90LWEtaGFzaA=="}}},
+		{Service: "pay"},
+	}
+
+	paths := make([]string, 0, 3)
+	for _, p := range ValidateConfig(cfg).Errors() {
+		paths = append(paths, p.Path)
+	}
+	assert.ElementsMatch(t, []string{"upstreams[0].tls", "upstreams[0].tls.pinned_sha256[0]", "upstreams[1].service"}, paths)
+}
+
+func TestValidateConfig_UpstreamProtocol(t *testing.T) {
+	cfg := Defaults()
+	cfg.Upstreams = []*UpstreamConfig{
+		{Service: "grpc", Protocol: UpstreamProtocolH2C, MaxConnsPerHost: 4},
+		{Service: "api", Protocol: UpstreamProtocolHTTP2},
+		{Service: "pay", Protocol: UpstreamProtocolH2C, TLS: &UpstreamTLSConfig{Enabled: true}},
+		{Service: "web", Protocol: "spdy", MaxConnsPerHost: -1},
+	}
+
+	paths := make([]string, 0, 4)
+	for _, p := range ValidateConfig(cfg).Errors() {
+		paths = append(paths, p.Path)
+	}
+	assert.ElementsMatch(t, []string{
+		"upstreams[1].protocol",
+		"upstreams[2].protocol",
+		"upstreams[3].protocol",
+		"upstreams[3].max_conns_per_host",
+	}, paths)
+}
+
+func TestValidateConfig_TCPListeners(t *testing.T) {
+	cfg := Defaults()
+	cfg.TCP.Listeners = []*TCPListenerConfig{
+		{Port: 5432, Service: "postgres"},
+		{Port: cfg.Server.Port, Service: "mqtt"},
+		{Port: 5432, Service: "redis"},
+		{Port: 70000},
+	}
+	cfg.BackEnds = []*BackEndConfig{
+		{Service: "postgres", Url: "tcp://10.0.0.1:5432", Weight: 1},
+		{Service: "amqp", Url: "tcp://10.0.0.2:5672", Weight: 1},
+		{Service: "postgres", Url: "tcp://10.0.0.3", Weight: 1},
+	}
+
+	ps := ValidateConfig(cfg)
+
+	var errs, warns []string
+	for _, p := range ps.Errors() {
+		errs = append(errs, p.Path)
+	}
+	for _, p := range ps.Warnings() {
+		warns = append(warns, p.Path)
+	}
+	assert.ElementsMatch(t, []string{
+		"tcp.listeners[1].port",
+		"tcp.listeners[2].port",
+		"tcp.listeners[3].service",
+		"tcp.listeners[3].port",
+		"backends[2].url",
+	}, errs)
+	assert.Contains(t, warns, "backends[1].url")
+}
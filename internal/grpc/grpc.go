@@ -0,0 +1,146 @@
+package grpc
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+/*
+*Ma trang thai gRPC (google.golang.org/grpc/codes), chi giu cac ma balancer dung toi
+ */
+type Code int
+
+const (
+	OK                Code = 0
+	Unknown           Code = 2
+	DeadlineExceeded  Code = 4
+	PermissionDenied  Code = 7
+	ResourceExhausted Code = 8
+	Unimplemented     Code = 12
+	Internal          Code = 13
+	Unavailable       Code = 14
+	Unauthenticated   Code = 16
+)
+
+const (
+	HeaderStatus  = "Grpc-Status"
+	HeaderMessage = "Grpc-Message"
+	contentType   = "application/grpc"
+)
+
+var codeNames = map[Code]string{
+	OK:                "OK",
+	Unknown:           "UNKNOWN",
+	DeadlineExceeded:  "DEADLINE_EXCEEDED",
+	PermissionDenied:  "PERMISSION_DENIED",
+	ResourceExhausted: "RESOURCE_EXHAUSTED",
+	Unimplemented:     "UNIMPLEMENTED",
+	Internal:          "INTERNAL",
+	Unavailable:       "UNAVAILABLE",
+	Unauthenticated:   "UNAUTHENTICATED",
+}
+
+func (c Code) String() string {
+	if name, ok := codeNames[c]; ok {
+		return name
+	}
+	return "CODE(" + strconv.Itoa(int(c)) + ")"
+}
+
+/*
+*Chi UNAVAILABLE duoc retry: backend chua xu ly call, giong retry mac dinh cua client gRPC
+ */
+func Retryable(c Code) bool {
+	return c == Unavailable
+}
+
+/*
+*application/grpc va cac bien the +proto, +json. gRPC-Web chay tren HTTP/1.1 nen khong tinh
+ */
+func IsGRPC(contentTypeValue string) bool {
+	rest, ok := strings.CutPrefix(contentTypeValue, contentType)
+	return ok && (rest == "" || rest[0] == '+' || rest[0] == ';')
+}
+
+func IsRequest(r *http.Request) bool {
+	return r.ProtoMajor == 2 && IsGRPC(r.Header.Get("Content-Type"))
+}
+
+func IsResponse(resp *http.Response) bool {
+	return IsGRPC(resp.Header.Get("Content-Type"))
+}
+
+/*
+*Path dang /package.Service hoac /package.Service/Method, dung de nhan ra rule cho service gRPC
+ */
+func IsServicePath(path string) bool {
+	service, _, _ := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+	return strings.HasPrefix(path, "/") && strings.Contains(service, ".")
+}
+
+/*
+*Trang thai trong header, chi co khi backend tra loi trailers-only (loi truoc khi gui message).
+*Call binh thuong de trang thai trong trailer, doc duoc sau khi het body
+ */
+func StatusOf(h http.Header) (Code, bool) {
+	v := h.Get(HeaderStatus)
+	if v == "" {
+		return 0, false
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return Unknown, true
+	}
+	return Code(n), true
+}
+
+/*
+*Ma gRPC tuong ung voi ma HTTP khi balancer tu tra loi (khong co backend, bi chan, ...)
+ */
+func CodeFromHTTP(status int) Code {
+	switch status {
+	case http.StatusBadRequest:
+		return Internal
+	case http.StatusUnauthorized:
+		return Unauthenticated
+	case http.StatusForbidden:
+		return PermissionDenied
+	case http.StatusNotFound:
+		return Unimplemented
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return Unavailable
+	default:
+		return Unknown
+	}
+}
+
+/*
+*Tra loi trailers-only: HTTP 200, trang thai nam trong header, khong co body
+ */
+func WriteError(w http.ResponseWriter, code Code, message string) {
+	h := w.Header()
+	h.Set("Content-Type", contentType)
+	h.Set(HeaderStatus, strconv.Itoa(int(code)))
+	if message != "" {
+		h.Set(HeaderMessage, encodeMessage(message))
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+/*
+*Grpc-Message ma hoa percent cac byte ngoai ASCII in duoc va dau %
+ */
+func encodeMessage(msg string) string {
+	var b strings.Builder
+	for i := 0; i < len(msg); i++ {
+		c := msg[i]
+		if c >= 0x20 && c <= 0x7e && c != '%' {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
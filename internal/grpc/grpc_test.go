@@ -0,0 +1,34 @@
+package grpc
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsGRPC(t *testing.T) {
+	assert.True(t, IsGRPC("application/grpc"))
+	assert.True(t, IsGRPC("application/grpc+proto"))
+	assert.False(t, IsGRPC("application/grpc-web+proto"))
+	assert.False(t, IsGRPC("application/json"))
+
+	assert.True(t, IsServicePath("/helloworld.Greeter/SayHello"))
+	assert.True(t, IsServicePath("/helloworld.Greeter"))
+	assert.False(t, IsServicePath("/api/v1.2/users"))
+}
+
+func TestWriteError(t *testing.T) {
+	rec := httptest.NewRecorder()
+	WriteError(rec, CodeFromHTTP(http.StatusServiceUnavailable), "100% down\n")
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/grpc", rec.Header().Get("Content-Type"))
+	assert.Equal(t, "100%25 down%0A", rec.Header().Get(HeaderMessage))
+
+	code, ok := StatusOf(rec.Header())
+	assert.True(t, ok)
+	assert.Equal(t, Unavailable, code)
+	assert.Equal(t, "UNAVAILABLE", code.String())
+}
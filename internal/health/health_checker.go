@@ -2,7 +2,9 @@ package health
 
 import (
 	"context"
+	"crypto/tls"
 	"log/slog"
+	"net"
 	"net/http"
 	"sync"
 	"time"
@@ -13,11 +15,26 @@ import (
 
 const (
 	maxConcurrent = 5
+	// client HTTPS khong duoc dung lai trong khoang nay thi dong (instance da bi xoa)
+	tlsClientIdle = 10 * time.Minute
 )
 
 type HeathChecker struct {
 	client *http.Client
 	logger *slog.Logger
+
+	tlsMux     sync.Mutex
+	tlsClients map[clientKey]*tlsClient // backend HTTPS co CA, SNI, client cert rieng hoac dung h2c
+}
+
+type clientKey struct {
+	tlsConfig *tls.Config
+	protocol  string
+}
+
+type tlsClient struct {
+	client   *http.Client
+	lastUsed time.Time
 }
 
 func NewHeathChecker(logger *slog.Logger) *HeathChecker {
@@ -31,6 +48,7 @@ func NewHeathChecker(logger *slog.Logger) *HeathChecker {
 				IdleConnTimeout:     90 * time.Second,
 			},
 		},
+		tlsClients: make(map[clientKey]*tlsClient),
 	}
 }
 
@@ -59,7 +77,12 @@ func (h *HeathChecker) CheckServers(servers []*model.Server, opts func(srv *mode
 			}
 			defer sem.Release(1)
 
-			alive := h.ping(s.GetAddr())
+			var alive bool
+			if s.IsTCP() {
+				alive = h.dial(s.HostPort())
+			} else {
+				alive = h.ping(h.clientFor(s), s.GetAddr())
+			}
 
 			changed := alive != s.IsHealthy()
 
@@ -73,11 +96,65 @@ func (h *HeathChecker) CheckServers(servers []*model.Server, opts func(srv *mode
 	}
 
 	wg.Wait()
+	h.evictIdleClients()
+}
+
+/*
+*Backend HTTPS dung cung tls.Config va giao thuc voi proxy de health check giong luc proxy,
+*backend chi noi h2c khong tra loi HTTP/1.1
+ */
+func (h *HeathChecker) clientFor(s *model.Server) *http.Client {
+	key := clientKey{tlsConfig: s.TLSConfig(), protocol: s.Protocol()}
+	if key.tlsConfig == nil && !s.Multiplexed() {
+		return h.client
+	}
+
+	h.tlsMux.Lock()
+	defer h.tlsMux.Unlock()
+
+	c, ok := h.tlsClients[key]
+	if !ok {
+		base := h.client.Transport.(*http.Transport)
+		transport := base.Clone()
+		transport.TLSClientConfig = key.tlsConfig
+		transport.Protocols = s.Protocols()
+		c = &tlsClient{client: &http.Client{Timeout: h.client.Timeout, Transport: transport}}
+		h.tlsClients[key] = c
+	}
+	c.lastUsed = time.Now()
+	return c.client
+}
+
+func (h *HeathChecker) evictIdleClients() {
+	h.tlsMux.Lock()
+	defer h.tlsMux.Unlock()
+
+	for key, c := range h.tlsClients {
+		if time.Since(c.lastUsed) > tlsClientIdle {
+			c.client.CloseIdleConnections()
+			delete(h.tlsClients, key)
+		}
+	}
+}
+
+/*
+*Backend TCP song khi mo duoc ket noi, khong gui du lieu gi
+ */
+func (h *HeathChecker) dial(addr string) bool {
+	conn, err := net.DialTimeout("tcp", addr, h.client.Timeout)
+	if err != nil {
+		h.logger.Warn("Server is down", "addr", addr, "err", err)
+		return false
+	}
+	conn.Close()
+
+	h.logger.Debug("Health check OK", "addr", addr)
+	return true
 }
 
 // ping cơ bản (private) - không retry, chỉ 1 lần ping
-func (h *HeathChecker) ping(addr string) bool {
-	ctx, cancel := context.WithTimeout(context.Background(), h.client.Timeout)
+func (h *HeathChecker) ping(client *http.Client, addr string) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), client.Timeout)
 	defer cancel()
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, addr, nil)
@@ -86,7 +163,7 @@ func (h *HeathChecker) ping(addr string) bool {
 		return false
 	}
 
-	resp, err := h.client.Do(req)
+	resp, err := client.Do(req)
 
 	if err != nil {
 		h.logger.Warn("Server is down", "addr", addr, "err", err)
@@ -0,0 +1,15 @@
+package httputil
+
+import (
+	"net/http"
+
+	"golang.org/x/net/http/httpguts"
+)
+
+/*
+*Request xin doi giao thuc (WebSocket, ...), ket noi duoc giu lai sau phan hoi 101.
+*Middleware va transport cung dung de nhan dien request dai han
+ */
+func IsUpgrade(r *http.Request) bool {
+	return r.Header.Get("Upgrade") != "" && httpguts.HeaderValuesContainsToken(r.Header["Connection"], "Upgrade")
+}
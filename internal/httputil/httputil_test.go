@@ -0,0 +1,18 @@
+package httputil
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsUpgrade(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/ws", nil)
+	r.Header.Set("Upgrade", "websocket")
+	assert.False(t, IsUpgrade(r))
+
+	r.Header.Set("Connection", "keep-alive, Upgrade")
+	assert.True(t, IsUpgrade(r))
+}
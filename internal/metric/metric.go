@@ -0,0 +1,85 @@
+package metric
+
+import (
+	"bufio"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+const (
+	TypeGauge   = "gauge"
+	TypeCounter = "counter"
+)
+
+/*
+*1 ho metric theo text format cua Prometheus, tu viet de khong phu thuoc client library
+ */
+type Family struct {
+	Name    string
+	Help    string
+	Type    string
+	samples []sample
+}
+
+type sample struct {
+	labels string
+	value  float64
+}
+
+func NewGauge(name, help string) *Family {
+	return &Family{Name: name, Help: help, Type: TypeGauge}
+}
+
+func NewCounter(name, help string) *Family {
+	return &Family{Name: name, Help: help, Type: TypeCounter}
+}
+
+/*
+*Them 1 gia tri, labels la cap key, value lien tiep
+ */
+func (f *Family) Add(value float64, labels ...string) {
+	f.samples = append(f.samples, sample{labels: formatLabels(labels), value: value})
+}
+
+func formatLabels(labels []string) string {
+	if len(labels) < 2 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteByte('{')
+	for i := 0; i+1 < len(labels); i += 2 {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(labels[i])
+		b.WriteString(`="`)
+		b.WriteString(labelEscaper.Replace(labels[i+1]))
+		b.WriteByte('"')
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+/*
+*Ghi cac ho metric theo thu tu ten, ho khong co gia tri nao van ghi HELP va TYPE
+ */
+func Write(w io.Writer, families []*Family) error {
+	sort.SliceStable(families, func(i, j int) bool { return families[i].Name < families[j].Name })
+
+	bw := bufio.NewWriter(w)
+	for _, f := range families {
+		bw.WriteString("# HELP " + f.Name + " " + f.Help + "\n")
+		bw.WriteString("# TYPE " + f.Name + " " + f.Type + "\n")
+		for _, s := range f.samples {
+			bw.WriteString(f.Name + s.labels + " " + strconv.FormatFloat(s.value, 'g', -1, 64) + "\n")
+		}
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,22 @@
+package metric
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWrite(t *testing.T) {
+	g := NewGauge("lb_b", "Second family.")
+	g.Add(1.5, "name", `a"b\c`)
+	c := NewCounter("lb_a", "First family.")
+
+	var out strings.Builder
+	require.NoError(t, Write(&out, []*Family{g, c}))
+
+	assert.Equal(t, "# HELP lb_a First family.\n# TYPE lb_a counter\n"+
+		"# HELP lb_b Second family.\n# TYPE lb_b gauge\n"+
+		`lb_b{name="a\"b\\c"} 1.5`+"\n", out.String())
+}
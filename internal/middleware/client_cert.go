@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/nhutphuongasasa/loadbalancer/internal/tls"
+)
+
+const (
+	HeaderClientCertSubject     = "X-Client-Cert-Subject"
+	HeaderClientCertIssuer      = "X-Client-Cert-Issuer"
+	HeaderClientCertSAN         = "X-Client-Cert-SAN"
+	HeaderClientCertFingerprint = "X-Client-Cert-Fingerprint"
+	HeaderClientCertSerial      = "X-Client-Cert-Serial"
+	HeaderClientCertVerified    = "X-Client-Cert-Verified"
+)
+
+var clientCertHeaders = []string{
+	HeaderClientCertSubject,
+	HeaderClientCertIssuer,
+	HeaderClientCertSAN,
+	HeaderClientCertFingerprint,
+	HeaderClientCertSerial,
+	HeaderClientCertVerified,
+}
+
+/*
+*Chuyen danh tinh client cert sang backend qua header X-Client-Cert-*.
+*Header cung ten do client tu gui luon bi xoa de backend co the tin gia tri nay
+ */
+type ClientCertHeaders struct {
+	forward atomic.Bool
+}
+
+func NewClientCertHeaders(forward bool) *ClientCertHeaders {
+	h := &ClientCertHeaders{}
+	h.SetForward(forward)
+	return h
+}
+
+func (h *ClientCertHeaders) SetForward(forward bool) {
+	h.forward.Store(forward)
+}
+
+func (h *ClientCertHeaders) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, name := range clientCertHeaders {
+			r.Header.Del(name)
+		}
+
+		if id := tls.IdentityOf(r.TLS); id != nil && h.forward.Load() {
+			r.Header.Set(HeaderClientCertSubject, id.Subject)
+			r.Header.Set(HeaderClientCertIssuer, id.Issuer)
+			if len(id.SANs) > 0 {
+				r.Header.Set(HeaderClientCertSAN, strings.Join(id.SANs, ","))
+			}
+			r.Header.Set(HeaderClientCertFingerprint, id.Fingerprint)
+			r.Header.Set(HeaderClientCertSerial, id.Serial)
+			r.Header.Set(HeaderClientCertVerified, strconv.FormatBool(id.Verified))
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
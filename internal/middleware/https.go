@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+/*
+*Gan header Strict-Transport-Security cho response HTTPS, policy doi duoc luc chay
+ */
+type HSTS struct {
+	header atomic.Pointer[string]
+}
+
+func NewHSTS(maxAge time.Duration, includeSubdomains, preload bool) *HSTS {
+	h := &HSTS{}
+	h.SetPolicy(maxAge, includeSubdomains, preload)
+	return h
+}
+
+/*
+*maxAge <= 0 thi khong gui header
+ */
+func (h *HSTS) SetPolicy(maxAge time.Duration, includeSubdomains, preload bool) {
+	value := ""
+	if maxAge > 0 {
+		value = "max-age=" + strconv.FormatInt(int64(maxAge/time.Second), 10)
+		if includeSubdomains {
+			value += "; includeSubDomains"
+		}
+		if preload {
+			value += "; preload"
+		}
+	}
+	h.header.Store(&value)
+}
+
+func (h *HSTS) Header() string {
+	return *h.header.Load()
+}
+
+func (h *HSTS) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// trinh duyet bo qua header nay tren HTTP thuong
+		if value := h.Header(); value != "" && r.TLS != nil {
+			w.Header().Set("Strict-Transport-Security", value)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+/*
+*Chuyen moi request HTTP sang HTTPS cung host, giu nguyen path va query.
+*Dung 308 de client khong doi method va body
+ */
+func RedirectHTTPS(httpsPort int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if host == "" {
+			http.Error(w, "Missing Host header", http.StatusBadRequest)
+			return
+		}
+
+		if httpsPort != 443 {
+			host = net.JoinHostPort(host, strconv.Itoa(httpsPort))
+		} else if ip := net.ParseIP(host); ip != nil && ip.To4() == nil {
+			host = "[" + host + "]"
+		}
+
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusPermanentRedirect)
+	})
+}
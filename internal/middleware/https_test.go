@@ -0,0 +1,143 @@
+package middleware
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedirectHTTPS(t *testing.T) {
+	tests := []struct {
+		name      string
+		httpsPort int
+		method    string
+		target    string
+		host      string
+		wantCode  int
+		wantLoc   string
+	}{
+		{
+			name:      "default port drops the port",
+			httpsPort: 443,
+			method:    http.MethodGet,
+			target:    "/api/users?page=2&sort=name",
+			host:      "example.com:80",
+			wantCode:  http.StatusPermanentRedirect,
+			wantLoc:   "https://example.com/api/users?page=2&sort=name",
+		},
+		{
+			name:      "custom port replaces the http port",
+			httpsPort: 8443,
+			method:    http.MethodPost,
+			target:    "/orders?id=7",
+			host:      "example.com:8080",
+			wantCode:  http.StatusPermanentRedirect,
+			wantLoc:   "https://example.com:8443/orders?id=7",
+		},
+		{
+			name:      "host without port",
+			httpsPort: 8443,
+			method:    http.MethodGet,
+			target:    "/",
+			host:      "example.com",
+			wantCode:  http.StatusPermanentRedirect,
+			wantLoc:   "https://example.com:8443/",
+		},
+		{
+			name:      "ipv6 host keeps brackets",
+			httpsPort: 443,
+			method:    http.MethodGet,
+			target:    "/health",
+			host:      "[::1]:8080",
+			wantCode:  http.StatusPermanentRedirect,
+			wantLoc:   "https://[::1]/health",
+		},
+		{
+			name:      "escaped path and query are preserved",
+			httpsPort: 443,
+			method:    http.MethodGet,
+			target:    "/files/a%20b?q=x%2By",
+			host:      "example.com",
+			wantCode:  http.StatusPermanentRedirect,
+			wantLoc:   "https://example.com/files/a%20b?q=x%2By",
+		},
+		{
+			name:      "missing host",
+			httpsPort: 443,
+			method:    http.MethodGet,
+			target:    "/",
+			host:      "",
+			wantCode:  http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, nil)
+			req.Host = tt.host
+			rec := httptest.NewRecorder()
+
+			RedirectHTTPS(tt.httpsPort).ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantCode, rec.Code)
+			assert.Equal(t, tt.wantLoc, rec.Header().Get("Location"))
+		})
+	}
+}
+
+func TestHSTS_Header(t *testing.T) {
+	tests := []struct {
+		name              string
+		maxAge            time.Duration
+		includeSubdomains bool
+		preload           bool
+		want              string
+	}{
+		{name: "max-age only", maxAge: 365 * 24 * time.Hour, want: "max-age=31536000"},
+		{name: "include subdomains", maxAge: time.Hour, includeSubdomains: true, want: "max-age=3600; includeSubDomains"},
+		{name: "preload", maxAge: time.Hour, preload: true, want: "max-age=3600; preload"},
+		{name: "all directives", maxAge: time.Hour, includeSubdomains: true, preload: true, want: "max-age=3600; includeSubDomains; preload"},
+		{name: "disabled", maxAge: 0, includeSubdomains: true, preload: true, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHSTS(tt.maxAge, tt.includeSubdomains, tt.preload)
+			assert.Equal(t, tt.want, h.Header())
+			assert.Equal(t, tt.want, serveHSTS(h, true))
+		})
+	}
+}
+
+func TestHSTS_Middleware(t *testing.T) {
+	h := NewHSTS(time.Hour, false, false)
+
+	// HTTP thuong khong gui header
+	assert.Empty(t, serveHSTS(h, false))
+	assert.Equal(t, "max-age=3600", serveHSTS(h, true))
+
+	// doi policy luc chay, request sau dung gia tri moi
+	h.SetPolicy(2*time.Hour, true, true)
+	assert.Equal(t, "max-age=7200; includeSubDomains; preload", serveHSTS(h, true))
+
+	h.SetPolicy(0, false, false)
+	assert.Empty(t, serveHSTS(h, true))
+}
+
+func serveHSTS(h *HSTS, secure bool) string {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if secure {
+		req.TLS = &tls.ConnectionState{}
+	}
+	rec := httptest.NewRecorder()
+
+	h.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})).ServeHTTP(rec, req)
+
+	return rec.Header().Get("Strict-Transport-Security")
+}
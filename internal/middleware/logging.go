@@ -103,3 +103,10 @@ func (w *responseWriterInterceptor) Write(b []byte) (int, error) {
 	w.bytesWritten += int64(n)
 	return n, err
 }
+
+/*
+* de http.ResponseController (ReverseProxy) flush va hijack duoc writer goc (SSE, WebSocket)
+ */
+func (w *responseWriterInterceptor) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
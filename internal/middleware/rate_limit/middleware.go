@@ -15,6 +15,7 @@ func (i *ipRateLimiter) Middleware(next http.Handler) http.Handler {
 		cl := i.GetLimiter(key)
 
 		if !cl.limiter.Allow() {
+			i.rejected.Add(1)
 			i.logger.Warn("Rate limit exceeded",
 				"key", key,
 				"method", r.Method,
@@ -36,6 +37,7 @@ func (i *ipRateLimiter) Middleware(next http.Handler) http.Handler {
 			return
 		}
 
+		i.allowed.Add(1)
 		next.ServeHTTP(w, r)
 	})
 }
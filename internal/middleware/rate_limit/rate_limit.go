@@ -5,6 +5,7 @@ import (
 	"log/slog"
 	"net/http"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/nhutphuongasasa/loadbalancer/internal/cache"
@@ -15,6 +16,16 @@ type IRateLimiter interface {
 	Start()
 	Stop()
 	Middleware(next http.Handler) http.Handler
+	Stats() Stats
+	SetLimit(r rate.Limit, b int)
+}
+
+type Stats struct {
+	RequestsPerSecond float64 `json:"requestsPerSecond"`
+	Burst             int     `json:"burst"`
+	TrackedClients    int     `json:"trackedClients"`
+	Allowed           uint64  `json:"allowed"`
+	Rejected          uint64  `json:"rejected"`
 }
 
 type ipRateLimiter struct {
@@ -26,6 +37,9 @@ type ipRateLimiter struct {
 
 	trustedProxies TrustedProxies
 
+	allowed  atomic.Uint64
+	rejected atomic.Uint64
+
 	ctx      context.Context
 	cancel   context.CancelFunc
 	startOne sync.Once
@@ -128,3 +142,39 @@ func (i *ipRateLimiter) GetLimiter(ip string) *client {
 	value.lastSeen = time.Now()
 	return value
 }
+
+/*
+*Thong ke rate limiter: cau hinh hien tai, so IP dang theo doi va so request cho qua/bi chan
+ */
+func (i *ipRateLimiter) Stats() Stats {
+	i.mux.RLock()
+	tracked := len(i.ips)
+	limit, burst := i.tokenPerSecond, i.limitBucket
+	i.mux.RUnlock()
+
+	return Stats{
+		RequestsPerSecond: float64(limit),
+		Burst:             burst,
+		TrackedClients:    tracked,
+		Allowed:           i.allowed.Load(),
+		Rejected:          i.rejected.Load(),
+	}
+}
+
+/*
+*Doi gioi han luc chay, ap dung cho ca cac IP dang duoc theo doi
+ */
+func (i *ipRateLimiter) SetLimit(r rate.Limit, b int) {
+	i.mux.Lock()
+	defer i.mux.Unlock()
+
+	i.tokenPerSecond = r
+	i.limitBucket = b
+
+	for _, c := range i.ips {
+		c.limiter.SetLimit(r)
+		c.limiter.SetBurst(b)
+	}
+
+	i.logger.Info("Rate limit updated", "requests_per_second", float64(r), "burst", b, "tracked_clients", len(i.ips))
+}
@@ -19,6 +19,7 @@ type IStickier interface {
 	SetStickySession(w http.ResponseWriter, serverName, backendId string) error
 	GetBackendFromContext(r *http.Request) ([]*model.ServerPair, bool)
 	GetCacheKeyFromContext(r *http.Request) string
+	MigrateSession(ctx context.Context, cacheKey string, pairs []*model.ServerPair, serverName, backendId string) error
 }
 
 type stickyManager struct {
@@ -36,6 +37,8 @@ const (
 
 type contextKey string
 
+var errSessionNotFound = errors.New("sticky session not found")
+
 const StickyBackendKey contextKey = "sticky_backend_id"
 const CacheKey contextKey = "cache_key"
 
@@ -172,12 +175,13 @@ func (s *stickyManager) getBackendFromCache(ctx context.Context, sessionID strin
 	var result []*model.ServerPair
 	err := s.cache.GetArray(ctx, key, &result)
 
+	// session het han hoac redis loi thi coi nhu chua co session, handler se tao session moi
 	if err == redis.Nil {
-		return nil, "", nil
+		return nil, "", errSessionNotFound
 	}
 
 	if err != nil {
-		return nil, "nil", nil
+		return nil, "", err
 	}
 
 	return result, key, nil
@@ -201,3 +205,35 @@ func (s *stickyManager) GetCacheKeyFromContext(r *http.Request) string {
 
 	return cacheKey
 }
+
+/*
+*Chuyen session cua 1 service sang backend moi (vd backend cu dang draining), giu cac service khac
+ */
+func (s *stickyManager) MigrateSession(ctx context.Context, cacheKey string, pairs []*model.ServerPair, serverName, backendId string) error {
+	if cacheKey == "" {
+		return errors.New("empty cache key")
+	}
+
+	updated := make([]*model.ServerPair, 0, len(pairs)+1)
+	for _, p := range pairs {
+		if p.ServerName != serverName {
+			updated = append(updated, p)
+		}
+	}
+	updated = append(updated, &model.ServerPair{
+		ServerName: serverName,
+		InstanceId: backendId,
+	})
+
+	if err := s.cache.ReplaceArray(ctx, cacheKey, updated, 0); err != nil {
+		return err
+	}
+
+	s.logger.Debug("Sticky session migrated",
+		"cache_key", cacheKey,
+		"service", serverName,
+		"backend_id", backendId,
+	)
+
+	return nil
+}
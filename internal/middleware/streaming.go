@@ -0,0 +1,165 @@
+package middleware
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/nhutphuongasasa/loadbalancer/internal/grpc"
+	"github.com/nhutphuongasasa/loadbalancer/internal/httputil"
+)
+
+// gia han deadline toi da 1 lan moi khoang nay (hoac idle/10 neu nho hon) thay vi moi lan doc ghi
+const deadlineGranularity = time.Second
+
+/*
+*Request dai han (WebSocket, SSE, gRPC, upload khong biet do dai) khong bi cat boi
+*ReadTimeout/WriteTimeout cua server ma bi dong khi khong co du lieu qua lai trong idle timeout.
+*idle = 0 thi khong gioi han
+ */
+type Streaming struct {
+	idle atomic.Int64
+}
+
+func NewStreaming(idle time.Duration) *Streaming {
+	s := &Streaming{}
+	s.SetIdleTimeout(idle)
+	return s
+}
+
+func (s *Streaming) SetIdleTimeout(idle time.Duration) {
+	s.idle.Store(int64(idle))
+}
+
+func (s *Streaming) IdleTimeout() time.Duration {
+	return time.Duration(s.idle.Load())
+}
+
+func (s *Streaming) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !IsStreaming(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		rc := http.NewResponseController(w)
+		dl := &idleDeadline{idle: s.IdleTimeout(), set: func(t time.Time) {
+			_ = rc.SetReadDeadline(t)
+			_ = rc.SetWriteDeadline(t)
+		}}
+		dl.touch()
+
+		if r.Body != nil && r.Body != http.NoBody {
+			r.Body = &streamBody{ReadCloser: r.Body, dl: dl}
+		}
+		next.ServeHTTP(&streamWriter{ResponseWriter: w, rc: rc, dl: dl}, r)
+	})
+}
+
+/*
+*Upgrade (WebSocket), SSE, gRPC hoac body khong biet do dai
+ */
+func IsStreaming(r *http.Request) bool {
+	return httputil.IsUpgrade(r) ||
+		strings.Contains(r.Header.Get("Accept"), "text/event-stream") ||
+		grpc.IsRequest(r) ||
+		(r.ContentLength < 0 && r.Body != nil && r.Body != http.NoBody)
+}
+
+/*
+*Deadline doc va ghi cung day lui moi khi co du lieu theo bat ky chieu nao
+ */
+type idleDeadline struct {
+	idle time.Duration
+	set  func(time.Time)
+
+	mu   sync.Mutex
+	last time.Time
+}
+
+func (d *idleDeadline) touch() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := time.Now()
+	if !d.last.IsZero() && now.Sub(d.last) < min(deadlineGranularity, d.idle/10) {
+		return
+	}
+	d.last = now
+
+	if d.idle <= 0 {
+		d.set(time.Time{})
+		return
+	}
+	d.set(now.Add(d.idle))
+}
+
+type streamBody struct {
+	io.ReadCloser
+	dl *idleDeadline
+}
+
+func (b *streamBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 {
+		b.dl.touch()
+	}
+	return n, err
+}
+
+/*
+*Flush de SSE va gRPC toi client ngay, Hijack de WebSocket tiep tuc duoc tinh idle sau khi upgrade
+ */
+type streamWriter struct {
+	http.ResponseWriter
+	rc *http.ResponseController
+	dl *idleDeadline
+}
+
+func (w *streamWriter) Write(b []byte) (int, error) {
+	w.dl.touch()
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *streamWriter) Flush() {
+	_ = w.rc.Flush()
+}
+
+func (w *streamWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, brw, err := w.rc.Hijack()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ic := &idleConn{Conn: conn}
+	ic.dl = &idleDeadline{idle: w.dl.idle, set: func(t time.Time) { _ = conn.SetDeadline(t) }}
+	ic.dl.touch()
+	return ic, brw, nil
+}
+
+func (w *streamWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+type idleConn struct {
+	net.Conn
+	dl *idleDeadline
+}
+
+func (c *idleConn) Read(p []byte) (int, error) {
+	n, err := c.Conn.Read(p)
+	if n > 0 {
+		c.dl.touch()
+	}
+	return n, err
+}
+
+func (c *idleConn) Write(p []byte) (int, error) {
+	c.dl.touch()
+	return c.Conn.Write(p)
+}
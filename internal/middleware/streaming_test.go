@@ -0,0 +1,85 @@
+package middleware
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sseBackend(events int, gap time.Duration) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for i := 0; i < events; i++ {
+			fmt.Fprintf(w, "data: %d\n\n", i)
+			w.(http.Flusher).Flush()
+			time.Sleep(gap)
+		}
+	}))
+}
+
+func readEvents(t *testing.T, proxyURL string) ([]string, error) {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodGet, proxyURL, nil)
+	require.NoError(t, err)
+	req.Header.Set("Accept", "text/event-stream")
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	var events []string
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		if line, ok := strings.CutPrefix(scanner.Text(), "data: "); ok {
+			events = append(events, line)
+		}
+	}
+	return events, scanner.Err()
+}
+
+func TestStreaming_SSEOutlivesWriteTimeout(t *testing.T) {
+	backend := sseBackend(8, 100*time.Millisecond)
+	defer backend.Close()
+
+	target, _ := url.Parse(backend.URL)
+	proxy := httputil.NewSingleHostReverseProxy(target)
+
+	front := httptest.NewUnstartedServer(NewStreaming(300 * time.Millisecond).Middleware(proxy))
+	front.Config.WriteTimeout = 200 * time.Millisecond
+	front.Start()
+	defer front.Close()
+
+	events, err := readEvents(t, front.URL)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"0", "1", "2", "3", "4", "5", "6", "7"}, events)
+}
+
+func TestStreaming_ClosesIdleStream(t *testing.T) {
+	backend := sseBackend(2, 800*time.Millisecond)
+	defer backend.Close()
+
+	target, _ := url.Parse(backend.URL)
+	front := httptest.NewServer(NewStreaming(300 * time.Millisecond).Middleware(httputil.NewSingleHostReverseProxy(target)))
+	defer front.Close()
+
+	// backend con giu stream toi 1.6s, chi deadline idle 300ms moi cat som hon event thu 2
+	start := time.Now()
+	events, err := readEvents(t, front.URL)
+	elapsed := time.Since(start)
+
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	assert.Equal(t, []string{"0"}, events)
+	assert.GreaterOrEqual(t, elapsed, 300*time.Millisecond)
+	assert.Less(t, elapsed, 800*time.Millisecond)
+}
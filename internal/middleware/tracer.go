@@ -84,12 +84,14 @@ func (t *Tracer) CombinedTracingMiddleware(next http.Handler) http.Handler {
 
 		t.PropagateTraceHeaders(tc, r)
 
-		t.logger.Info("tracing injected",
+		t.logger.Debug("tracing injected",
 			slog.String("trace_id", tc.TraceID),
 			slog.String("path", r.URL.Path),
 		)
 
-		next.ServeHTTP(w, r)
+		ctx := context.WithValue(r.Context(), traceCtxKey, tc)
+		ctx = context.WithValue(ctx, requestIDKey, tc.TraceID)
+		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
 
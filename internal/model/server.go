@@ -2,13 +2,20 @@
 package model
 
 import (
+	"crypto/tls"
 	"fmt"
+	"net"
 	"net/http"
 	"net/http/httputil"
 	"net/url"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
+
+	"github.com/nhutphuongasasa/loadbalancer/internal/grpc"
 )
 
 type ServerPair struct {
@@ -23,6 +30,18 @@ type Input struct {
 	Port        int               `json:"port,omitempty"`
 	Weight      int               `json:"weight,omitempty"`
 	Metadata    map[string]string `json:"metadata,omitempty"`
+	TLS         *UpstreamTLS      `json:"tls,omitempty"`
+}
+
+/*
+*TLS toi backend khai bao luc dang ky. Chi gom gia tri khong can doc file tren balancer;
+*CA file va client cert cho mTLS nam trong config upstreams theo service
+ */
+type UpstreamTLS struct {
+	Enabled    bool     `json:"enabled"`
+	ServerName string   `json:"serverName,omitempty"`   // SNI va ten dung de verify
+	CAPEM      string   `json:"caPEM,omitempty"`        // CA rieng cua backend
+	Pins       []string `json:"pinnedSHA256,omitempty"` // sha256/<base64> cua public key
 }
 
 type Server struct {
@@ -38,6 +57,48 @@ type Server struct {
 	Weight      int
 	proxy       *httputil.ReverseProxy
 	activeConns int32
+	pending     bool // khoi phuc tu snapshot, cho health check xac nhan
+	draining    bool // khong nhan request moi, cho request dang xu ly ket thuc
+	static      bool // khai bao trong config, khong het han theo TTL
+
+	scheme    string       // http, https hoac tcp
+	upstream  *UpstreamTLS // gia tri luc dang ky, luu vao snapshot
+	tlsConfig *tls.Config  // cau hinh da gop voi config, dung cho proxy va health check
+
+	protocol        string // auto, http1, http2 hoac h2c theo config upstreams, rong = auto
+	maxConnsPerHost int    // gioi han ket noi toi instance, 0 = khong gioi han
+}
+
+type ServerOption func(*Server)
+
+/*
+*Ket noi toi backend qua HTTPS voi cfg (nil = verify bang CA he thong)
+ */
+func WithUpstreamTLS(upstream *UpstreamTLS, cfg *tls.Config) ServerOption {
+	return func(s *Server) {
+		s.scheme = "https"
+		s.upstream = upstream
+		s.tlsConfig = cfg
+	}
+}
+
+/*
+*Backend nhan ket noi TCP tho tu listener tcp, health check bang TCP connect thay vi HTTP
+ */
+func WithTCP() ServerOption {
+	return func(s *Server) {
+		s.scheme = "tcp"
+	}
+}
+
+/*
+*Giao thuc toi backend va gioi han ket noi theo config upstreams cua service
+ */
+func WithUpstreamProtocol(protocol string, maxConnsPerHost int) ServerOption {
+	return func(s *Server) {
+		s.protocol = protocol
+		s.maxConnsPerHost = maxConnsPerHost
+	}
 }
 
 func NewServer(
@@ -45,14 +106,31 @@ func NewServer(
 	port, weight int,
 	metadata map[string]string,
 	transport http.RoundTripper,
+	opts ...ServerOption,
 ) *Server {
 	if weight <= 0 {
 		weight = 10
 	}
 
+	srv := &Server{
+		InstanceID:  id,
+		ServiceName: serviceName,
+		Host:        host,
+		Port:        port,
+		Health:      true,
+		LastSeen:    time.Now(),
+		Metadata:    metadata,
+		TTL:         30 * time.Second,
+		Weight:      weight,
+		scheme:      "http",
+	}
+	for _, opt := range opts {
+		opt(srv)
+	}
+
 	u := &url.URL{
-		Scheme: "http",
-		Host:   fmt.Sprintf("%s:%d", host, port),
+		Scheme: srv.scheme,
+		Host:   net.JoinHostPort(host, strconv.Itoa(port)),
 	}
 
 	proxy := httputil.NewSingleHostReverseProxy(u)
@@ -79,27 +157,16 @@ func NewServer(
 	}
 
 	proxy.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+		// client gRPC doc trang thai tu header grpc-status thay vi ma HTTP
+		if grpc.IsRequest(r) {
+			grpc.WriteError(w, grpc.Unavailable, "backend service unreachable or unavailable")
+			return
+		}
 		http.Error(w, "Backend service unreachable or unavailable", http.StatusServiceUnavailable)
 	}
 
-	proxy.Transport = transport
-
-	proxy.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
-		http.Error(w, "Backend service unreachable or unavailable", http.StatusServiceUnavailable)
-	}
-
-	return &Server{
-		InstanceID:  id,
-		ServiceName: serviceName,
-		Host:        host,
-		Port:        port,
-		Health:      true,
-		LastSeen:    time.Now(),
-		Metadata:    metadata,
-		TTL:         30 * time.Second,
-		Weight:      weight,
-		proxy:       proxy,
-	}
+	srv.proxy = proxy
+	return srv
 }
 
 func (s *Server) GetID() string {
@@ -111,7 +178,74 @@ func (s *Server) GetServiceName() string {
 }
 
 func (s *Server) GetAddr() string {
-	return fmt.Sprintf("http://%s:%d", s.Host, s.Port)
+	return fmt.Sprintf("%s://%s", s.scheme, net.JoinHostPort(s.Host, strconv.Itoa(s.Port)))
+}
+
+func (s *Server) Scheme() string {
+	return s.scheme
+}
+
+func (s *Server) IsTCP() bool {
+	return s.scheme == "tcp"
+}
+
+/*
+*host:port khong kem scheme, dung cho ket noi TCP
+ */
+func (s *Server) HostPort() string {
+	return net.JoinHostPort(s.Host, strconv.Itoa(s.Port))
+}
+
+/*
+*Gia tri TLS luc dang ky (nil khi backend la HTTP hoac TLS chi den tu config)
+ */
+func (s *Server) UpstreamTLS() *UpstreamTLS {
+	return s.upstream
+}
+
+/*
+*Cau hinh TLS da gop cua backend, nil khi dung HTTP hoac CA he thong
+ */
+func (s *Server) TLSConfig() *tls.Config {
+	return s.tlsConfig
+}
+
+func (s *Server) Protocol() string {
+	if s.protocol == "" {
+		return "auto"
+	}
+	return s.protocol
+}
+
+func (s *Server) MaxConnsPerHost() int {
+	return s.maxConnsPerHost
+}
+
+/*
+*Giao thuc transport duoc dung voi backend. auto: HTTPS thuong luong h2 qua ALPN, HTTP giu HTTP/1.1
+ */
+func (s *Server) Protocols() *http.Protocols {
+	p := new(http.Protocols)
+	switch s.Protocol() {
+	case "http1":
+		p.SetHTTP1(true)
+	case "http2":
+		p.SetHTTP2(true)
+	case "h2c":
+		p.SetUnencryptedHTTP2(true)
+	default:
+		p.SetHTTP1(true)
+		p.SetHTTP2(s.scheme == "https")
+	}
+	return p
+}
+
+/*
+*true khi cac request toi backend duoc ghep tren it ket noi HTTP/2
+ */
+func (s *Server) Multiplexed() bool {
+	p := s.Protocols()
+	return p.HTTP2() || p.UnencryptedHTTP2()
 }
 
 func (s *Server) GetMetadata() map[string]string {
@@ -145,16 +279,102 @@ func (s *Server) SetAlive(status bool) {
 	s.mux.Unlock()
 }
 
+/*
+*Instance duoc khoi phuc tu snapshot va chua duoc health check xac nhan
+ */
+func (s *Server) IsPending() bool {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+	return s.pending
+}
+
+func (s *Server) SetPending(pending bool) {
+	s.mux.Lock()
+	s.pending = pending
+	if pending {
+		s.Health = false
+	}
+	s.mux.Unlock()
+}
+
+func (s *Server) IsDraining() bool {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+	return s.draining
+}
+
+func (s *Server) SetDraining(draining bool) {
+	s.mux.Lock()
+	s.draining = draining
+	s.mux.Unlock()
+}
+
+func (s *Server) IsStatic() bool {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+	return s.static
+}
+
+func (s *Server) SetStatic(static bool) {
+	s.mux.Lock()
+	s.static = static
+	s.mux.Unlock()
+}
+
+/*
+*Instance co the nhan request moi: healthy, khong draining va weight > 0
+ */
+func (s *Server) IsAvailable() bool {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+	return s.Health && !s.draining && s.Weight > 0
+}
+
+/*
+*Trang thai tong hop cua instance de hien thi
+ */
+func (s *Server) State() string {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+
+	switch {
+	case s.pending:
+		return "pending"
+	case s.draining:
+		return "draining"
+	case !s.Health:
+		return "unhealthy"
+	default:
+		return "active"
+	}
+}
+
 func (s *Server) GetWeight() int {
 	s.mux.RLock()
 	defer s.mux.RUnlock()
 	if s.Weight > 0 {
 		return s.Weight
 	}
-	return 1
+	return 0
+}
+
+/*
+*Doi weight luc runtime, weight = 0 nghia la khong nhan traffic moi
+ */
+func (s *Server) SetWeight(weight int) {
+	if weight < 0 {
+		weight = 0
+	}
+
+	s.mux.Lock()
+	s.Weight = weight
+	s.mux.Unlock()
 }
 
 func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.IncConn()
+	defer s.DecConn()
+
 	s.proxy.ServeHTTP(w, r)
 }
 
@@ -178,5 +398,50 @@ func (s *Server) IsExpired(now time.Time) bool {
 	s.mux.RLock()
 	defer s.mux.RUnlock()
 
+	if s.static {
+		return false
+	}
+
 	return now.After(s.LastSeen.Add(s.TTL))
 }
+
+/*
+*Kiem tra metadata cua instance co chua tat ca cap key=value cua selector khong
+ */
+func (s *Server) MatchesSelector(selector map[string]string) bool {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+
+	for k, v := range selector {
+		if s.Metadata[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+/*
+*Tao key on dinh cho selector (sap xep theo key) de dung lam khoa map
+ */
+func SelectorKey(selector map[string]string) string {
+	if len(selector) == 0 {
+		return ""
+	}
+
+	keys := make([]string, 0, len(selector))
+	for k := range selector {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for i, k := range keys {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(k)
+		b.WriteByte('=')
+		b.WriteString(selector[k])
+	}
+	return b.String()
+}
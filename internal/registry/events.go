@@ -0,0 +1,148 @@
+package registry
+
+import (
+	"sync"
+	"time"
+
+	"github.com/nhutphuongasasa/loadbalancer/internal/model"
+)
+
+type EventType string
+
+const (
+	EventRegister     EventType = "register"
+	EventDeregister   EventType = "deregister"
+	EventHealthChange EventType = "health_change"
+	EventDrain        EventType = "drain"
+	EventDrained      EventType = "drained" // het request dang xu ly, co the tat instance
+	EventResume       EventType = "resume"
+	EventWeightChange EventType = "weight_change"
+)
+
+const defaultEventHistory = 1024
+
+/*
+*1 thay doi cua registry, Index tang dan de client co the resume
+ */
+type Event struct {
+	Index       uint64    `json:"index"`
+	Type        EventType `json:"type"`
+	ServiceName string    `json:"serviceName"`
+	InstanceID  string    `json:"instanceID"`
+	Host        string    `json:"host"`
+	Port        int       `json:"port"`
+	Weight      int       `json:"weight"`
+	Healthy     bool      `json:"healthy"`
+	Draining    bool      `json:"draining"`
+	Time        time.Time `json:"time"`
+}
+
+/*
+*Luu lich su su kien co gioi han (ring buffer) va danh thuc cac watcher khi co su kien moi
+ */
+type EventHub struct {
+	mu        sync.Mutex
+	events    []Event
+	start     int // vi tri su kien cu nhat trong ring buffer
+	size      int
+	lastIndex uint64
+	notify    chan struct{}
+}
+
+func NewEventHub(capacity int) *EventHub {
+	if capacity <= 0 {
+		capacity = defaultEventHistory
+	}
+
+	return &EventHub{
+		events: make([]Event, capacity),
+		notify: make(chan struct{}),
+	}
+}
+
+/*
+*Ghi nhan su kien tu trang thai hien tai cua server
+ */
+func (h *EventHub) Publish(eventType EventType, srv *model.Server) Event {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.lastIndex++
+	ev := Event{
+		Index:       h.lastIndex,
+		Type:        eventType,
+		ServiceName: srv.ServiceName,
+		InstanceID:  srv.InstanceID,
+		Host:        srv.Host,
+		Port:        srv.Port,
+		Weight:      srv.GetWeight(),
+		Healthy:     srv.IsHealthy(),
+		Draining:    srv.IsDraining(),
+		Time:        time.Now(),
+	}
+
+	pos := (h.start + h.size) % len(h.events)
+	h.events[pos] = ev
+	if h.size < len(h.events) {
+		h.size++
+	} else {
+		h.start = (h.start + 1) % len(h.events)
+	}
+
+	// dong channel de danh thuc tat ca watcher dang cho
+	close(h.notify)
+	h.notify = make(chan struct{})
+
+	return ev
+}
+
+/*
+*Tra ve cac su kien co Index > index, loc theo service neu co.
+*truncated = true khi index qua cu va mot so su kien da bi ghi de, hoac index lon hon
+*index hien tai (balancer da khoi dong lai, index dem lai tu 1): client phai lay lai toan bo
+ */
+func (h *EventHub) Since(index uint64, service string) (events []Event, lastIndex uint64, truncated bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if index > h.lastIndex {
+		return h.sinceLocked(0, service), h.lastIndex, true
+	}
+
+	if h.size > 0 {
+		oldest := h.events[h.start].Index
+		truncated = index+1 < oldest && index != 0
+	}
+
+	return h.sinceLocked(index, service), h.lastIndex, truncated
+}
+
+func (h *EventHub) sinceLocked(index uint64, service string) []Event {
+	var events []Event
+	for i := 0; i < h.size; i++ {
+		ev := h.events[(h.start+i)%len(h.events)]
+		if ev.Index <= index {
+			continue
+		}
+		if service != "" && ev.ServiceName != service {
+			continue
+		}
+		events = append(events, ev)
+	}
+	return events
+}
+
+/*
+*Channel se bi dong khi co su kien moi sau thoi diem goi ham
+ */
+func (h *EventHub) Changed() <-chan struct{} {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.notify
+}
+
+func (h *EventHub) LastIndex() uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.lastIndex
+}
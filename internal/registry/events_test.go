@@ -0,0 +1,79 @@
+package registry
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nhutphuongasasa/loadbalancer/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventHub_SinceAndTruncate(t *testing.T) {
+	hub := NewEventHub(3)
+	srv := model.NewServer("a-1", "svc-a", "10.0.0.1", 80, 1, nil, nil)
+	other := model.NewServer("b-1", "svc-b", "10.0.0.2", 80, 1, nil, nil)
+
+	hub.Publish(EventRegister, srv)
+	hub.Publish(EventRegister, other)
+
+	events, last, truncated := hub.Since(0, "")
+	assert.Len(t, events, 2)
+	assert.Equal(t, uint64(2), last)
+	assert.False(t, truncated)
+
+	events, _, _ = hub.Since(0, "svc-b")
+	assert.Len(t, events, 1)
+	assert.Equal(t, "b-1", events[0].InstanceID)
+
+	// ghi de su kien cu nhat
+	hub.Publish(EventHealthChange, srv)
+	hub.Publish(EventHealthChange, srv)
+	hub.Publish(EventDeregister, srv)
+
+	events, last, truncated = hub.Since(1, "")
+	assert.True(t, truncated)
+	assert.Equal(t, uint64(5), last)
+	assert.Equal(t, uint64(3), events[0].Index)
+
+	events, _, truncated = hub.Since(4, "")
+	assert.False(t, truncated)
+	assert.Len(t, events, 1)
+	assert.Equal(t, EventDeregister, events[0].Type)
+}
+
+func TestEventHub_IndexAheadAfterRestart(t *testing.T) {
+	hub := NewEventHub(0)
+	srv := model.NewServer("a-1", "svc-a", "10.0.0.1", 80, 1, nil, nil)
+
+	// balancer moi khoi dong, watcher con giu index cua process truoc
+	events, last, truncated := hub.Since(42, "")
+	assert.True(t, truncated)
+	assert.Empty(t, events)
+	assert.Equal(t, uint64(0), last)
+
+	hub.Publish(EventRegister, srv)
+	hub.Publish(EventHealthChange, srv)
+
+	events, last, truncated = hub.Since(42, "")
+	assert.True(t, truncated)
+	assert.Equal(t, uint64(2), last)
+	assert.Len(t, events, 2)
+
+	_, _, truncated = hub.Since(2, "")
+	assert.False(t, truncated)
+}
+
+func TestEventHub_ChangedWakesWatchers(t *testing.T) {
+	hub := NewEventHub(0)
+	changed := hub.Changed()
+
+	go hub.Publish(EventRegister, model.NewServer("a-1", "svc-a", "10.0.0.1", 80, 1, nil, nil))
+
+	select {
+	case <-changed:
+	case <-time.After(time.Second):
+		t.Fatal("watcher was not notified")
+	}
+
+	assert.Equal(t, uint64(1), hub.LastIndex())
+}
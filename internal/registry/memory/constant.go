@@ -1,7 +1,6 @@
 package memory
 
 const (
-	maxBatchSize           = 20
-	maxConcurrentCheck     = 5
-	maxInstancesPerService = 50
+	maxBatchSize       = 20
+	maxConcurrentCheck = 5
 )
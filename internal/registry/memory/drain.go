@@ -0,0 +1,145 @@
+package memory
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/nhutphuongasasa/loadbalancer/internal/model"
+	"github.com/nhutphuongasasa/loadbalancer/internal/registry"
+)
+
+const drainPollInterval = 500 * time.Millisecond
+
+var ErrInstanceNotFound = errors.New("server not found")
+
+/*
+*Tim instance dang duoc quan li
+ */
+func (r *InMemoryRegistry) GetInstance(serviceName, instanceID string) (*model.Server, error) {
+	r.mux.RLock()
+	defer r.mux.RUnlock()
+
+	return r.lookupLocked(serviceName, instanceID)
+}
+
+/*
+*Tat ca instance dang quan li, sap xep theo service roi instance id
+ */
+func (r *InMemoryRegistry) ListInstances() []*model.Server {
+	r.mux.RLock()
+	defer r.mux.RUnlock()
+
+	list := make([]*model.Server, 0)
+	for _, instances := range r.services {
+		for _, srv := range instances {
+			list = append(list, srv)
+		}
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].ServiceName != list[j].ServiceName {
+			return list[i].ServiceName < list[j].ServiceName
+		}
+		return list[i].InstanceID < list[j].InstanceID
+	})
+	return list
+}
+
+func (r *InMemoryRegistry) lookupLocked(serviceName, instanceID string) (*model.Server, error) {
+	if instances, ok := r.services[serviceName]; ok {
+		if srv, exists := instances[instanceID]; exists {
+			return srv, nil
+		}
+	}
+	return nil, ErrInstanceNotFound
+}
+
+/*
+*Dua instance vao trang thai draining: server pool ngung chon instance nay,
+*request dang xu ly van chay tiep cho den khi ket thuc
+ */
+func (r *InMemoryRegistry) Drain(serviceName, instanceID string) (*model.Server, error) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	srv, err := r.lookupLocked(serviceName, instanceID)
+	if err != nil {
+		return nil, err
+	}
+
+	if srv.IsDraining() {
+		return srv, nil
+	}
+
+	srv.SetDraining(true)
+	r.notify(registry.EventDrain, srv)
+
+	r.logger.Info("Instance draining",
+		"service", serviceName,
+		"id", instanceID,
+		"active_conns", srv.GetActiveConns(),
+	)
+
+	r.wg.Add(1)
+	go r.watchDrain(srv)
+
+	return srv, nil
+}
+
+/*
+*Dua instance dang draining tro lai nhan traffic
+ */
+func (r *InMemoryRegistry) Resume(serviceName, instanceID string) (*model.Server, error) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	srv, err := r.lookupLocked(serviceName, instanceID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !srv.IsDraining() {
+		return srv, nil
+	}
+
+	srv.SetDraining(false)
+	r.notify(registry.EventResume, srv)
+
+	r.logger.Info("Instance resumed", "service", serviceName, "id", instanceID)
+	return srv, nil
+}
+
+/*
+*Theo doi so ket noi dang mo cua instance draining, bao safe to stop khi ve 0
+ */
+func (r *InMemoryRegistry) watchDrain(srv *model.Server) {
+	defer r.wg.Done()
+
+	ticker := time.NewTicker(drainPollInterval)
+	defer ticker.Stop()
+
+	for {
+		if !srv.IsDraining() {
+			return
+		}
+
+		if srv.GetActiveConns() == 0 {
+			r.mux.Lock()
+			_, err := r.lookupLocked(srv.ServiceName, srv.InstanceID)
+			if err == nil && srv.IsDraining() && r.events != nil {
+				r.events.Publish(registry.EventDrained, srv)
+			}
+			r.mux.Unlock()
+
+			r.logger.Info("Instance drained, safe to stop", "service", srv.ServiceName, "id", srv.InstanceID)
+			return
+		}
+
+		select {
+		case <-ticker.C:
+		case <-r.ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package memory
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/nhutphuongasasa/loadbalancer/internal/model"
+	"github.com/nhutphuongasasa/loadbalancer/internal/registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDrain_WaitsForActiveConns(t *testing.T) {
+	hub := registry.NewEventHub(0)
+	reg := NewInMemoryRegistry(slog.New(slog.NewTextHandler(io.Discard, nil)), time.Hour, nil, WithEventHub(hub))
+
+	srv := model.NewServer("api-1", "api", "10.0.0.1", 8080, 1, nil, nil)
+	require.NoError(t, reg.Register(srv))
+	<-reg.GetUpdateChan()
+
+	srv.IncConn()
+
+	drained, err := reg.Drain("api", "api-1")
+	require.NoError(t, err)
+	assert.Equal(t, "draining", drained.State())
+	assert.False(t, drained.IsAvailable())
+
+	// server pool nhan update de loai instance
+	update := <-reg.GetUpdateChan()
+	assert.True(t, update.IsDraining())
+
+	time.Sleep(2 * drainPollInterval)
+	events, _, _ := hub.Since(0, "")
+	assert.Equal(t, registry.EventDrain, events[len(events)-1].Type)
+
+	srv.DecConn()
+	require.Eventually(t, func() bool {
+		events, _, _ := hub.Since(0, "")
+		return events[len(events)-1].Type == registry.EventDrained
+	}, 2*time.Second, 50*time.Millisecond)
+
+	resumed, err := reg.Resume("api", "api-1")
+	require.NoError(t, err)
+	assert.Equal(t, "active", resumed.State())
+
+	_, err = reg.Drain("api", "missing")
+	assert.ErrorIs(t, err, ErrInstanceNotFound)
+}
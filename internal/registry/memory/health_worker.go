@@ -46,7 +46,8 @@ func (r *InMemoryRegistry) removeWorkerLocked(serviceName string) {
  */
 func (r *InMemoryRegistry) workerLoop(serviceName string, worker *health.HeathChecker) {
 	defer r.wg.Done()
-	ticker := time.NewTicker(r.checkInterval)
+	interval, changed := r.currentCheckInterval()
+	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
 	sem := semaphore.NewWeighted(int64(maxConcurrentCheck))
@@ -55,6 +56,9 @@ func (r *InMemoryRegistry) workerLoop(serviceName string, worker *health.HeathCh
 		select {
 		case <-r.ctx.Done():
 			return
+		case <-changed:
+			interval, changed = r.currentCheckInterval()
+			ticker.Reset(interval)
 		case <-ticker.C:
 			batch := r.extractBatch(serviceName)
 			if len(batch) == 0 {
@@ -125,3 +129,26 @@ func (r *InMemoryRegistry) executeBatchCheck(batch []*model.Server, worker *heal
 		worker.CheckServers(batch, r.UpdateStatus)
 	}()
 }
+
+func (r *InMemoryRegistry) currentCheckInterval() (time.Duration, <-chan struct{}) {
+	r.intervalMux.Lock()
+	defer r.intervalMux.Unlock()
+	return r.checkInterval, r.intervalChanged
+}
+
+/*
+*Doi chu ky health check, cac worker dang chay ap dung ngay o vong lap ke tiep
+ */
+func (r *InMemoryRegistry) SetCheckInterval(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	r.intervalMux.Lock()
+	r.checkInterval = interval
+	close(r.intervalChanged)
+	r.intervalChanged = make(chan struct{})
+	r.intervalMux.Unlock()
+
+	r.logger.Info("Health check interval changed", "interval", interval)
+}
@@ -7,7 +7,6 @@ import (
 
 	"github.com/nhutphuongasasa/loadbalancer/internal/model"
 	"github.com/nhutphuongasasa/loadbalancer/internal/registry"
-	"github.com/nhutphuongasasa/loadbalancer/internal/resilience"
 )
 
 //can dam bao lam sao 1 server thuc te chi co 1 instance thoi
@@ -40,14 +39,7 @@ func (r *InMemoryRegistry) Register(srv *model.Server) error {
 }
 
 func (r *InMemoryRegistry) createResilienceProxy(srv *model.Server) {
-	breaker := resilience.NewSonyGoBreaker(
-		fmt.Sprintf("cb-default-%s", srv.InstanceID),
-		3, 5*time.Second, 10*time.Second, r.logger,
-	)
-
-	retryPol := resilience.NewExponentialRetry(3, 200*time.Millisecond, 3*time.Second, 0.2, r.logger)
-
-	srv.GetProxy().Transport = resilience.NewResilientTransport(registry.GlobalBaseTransport, breaker, retryPol, r.logger)
+	srv.GetProxy().Transport = registry.NewResilientTransport(fmt.Sprintf("cb-default-%s", srv.InstanceID), srv, r.resilience, r.logger)
 }
 
 /*
@@ -59,10 +51,10 @@ func (r *InMemoryRegistry) checkServer(srv *model.Server) bool {
 	}
 
 	// Giới hạn số instance per service
-	if len(r.services[srv.ServiceName]) >= maxInstancesPerService {
+	if len(r.services[srv.ServiceName]) >= r.maxInstances {
 		r.logger.Error("Cannot register new server: max instances reached",
 			"service", srv.ServiceName,
-			"max_allowed", maxInstancesPerService,
+			"max_allowed", r.maxInstances,
 			"current", len(r.services[srv.ServiceName]),
 		)
 		return false
@@ -77,11 +69,13 @@ func (r *InMemoryRegistry) checkServer(srv *model.Server) bool {
 func (r *InMemoryRegistry) setupNewInstance(srv *model.Server) {
 	srv.LastSeen = time.Now()
 	srv.Health = true
-	srv.TTL = 30 * time.Second
+	if srv.TTL <= 0 {
+		srv.TTL = r.instanceTTL
+	}
 
 	r.services[srv.ServiceName][srv.InstanceID] = srv
 
-	r.updateChan <- srv
+	r.notify(registry.EventRegister, srv)
 }
 
 /*
@@ -98,7 +92,9 @@ func (r *InMemoryRegistry) Deregister(serviceName, instanceID string) error {
 				delete(r.services, serviceName)
 			}
 
-			r.updateChan <- srv
+			// danh dau down de server pool loai instance ra khoi danh sach
+			srv.SetAlive(false)
+			r.notify(registry.EventDeregister, srv)
 
 			if len(instances) == 0 {
 				r.removeWorkerLocked(serviceName)
@@ -108,5 +104,5 @@ func (r *InMemoryRegistry) Deregister(serviceName, instanceID string) error {
 			return nil
 		}
 	}
-	return errors.New("server not found")
+	return ErrInstanceNotFound
 }
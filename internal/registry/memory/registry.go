@@ -7,8 +7,10 @@ import (
 	"sync"
 	"time"
 
+	"github.com/nhutphuongasasa/loadbalancer/internal/config"
 	"github.com/nhutphuongasasa/loadbalancer/internal/health"
 	"github.com/nhutphuongasasa/loadbalancer/internal/model"
+	"github.com/nhutphuongasasa/loadbalancer/internal/registry"
 	"github.com/nhutphuongasasa/loadbalancer/internal/registry/provider"
 )
 
@@ -20,7 +22,21 @@ type InMemoryRegistry struct {
 	workers         map[string]*workerState
 	workersMux      sync.Mutex
 	checkInterval   time.Duration
+	intervalMux     sync.Mutex
+	intervalChanged chan struct{} // dong khi checkInterval doi de worker reset ticker
 	providerChannel provider.ProviderChannel
+	events          *registry.EventHub
+
+	instanceTTL  time.Duration
+	maxInstances int
+	resilience   *config.ResilienceConfig
+	upstreams    *registry.Upstreams
+
+	snapshotPath     string
+	snapshotInterval time.Duration
+
+	weightMux     sync.Mutex
+	weightReverts map[string]*weightRevert
 
 	logger *slog.Logger
 
@@ -36,7 +52,55 @@ type workerState struct {
 	lastIndex int // vi tri bat dau cho batch tiep theo
 }
 
-func NewInMemoryRegistry(logger *slog.Logger, checkInterval time.Duration, providerChannel provider.ProviderChannel) *InMemoryRegistry {
+type Option func(*InMemoryRegistry)
+
+/*
+*Gan event hub de ghi lai cac thay doi cho watcher
+ */
+func WithEventHub(hub *registry.EventHub) Option {
+	return func(r *InMemoryRegistry) {
+		r.events = hub
+	}
+}
+
+/*
+*TTL mac dinh cho instance dang ky khong kem ttl
+ */
+func WithInstanceTTL(ttl time.Duration) Option {
+	return func(r *InMemoryRegistry) {
+		if ttl > 0 {
+			r.instanceTTL = ttl
+		}
+	}
+}
+
+func WithMaxInstancesPerService(n int) Option {
+	return func(r *InMemoryRegistry) {
+		if n > 0 {
+			r.maxInstances = n
+		}
+	}
+}
+
+/*
+*Cau hinh circuit breaker va retry cho instance tao boi registry (backend tinh, snapshot)
+ */
+func WithResilience(cfg *config.ResilienceConfig) Option {
+	return func(r *InMemoryRegistry) {
+		r.resilience = cfg
+	}
+}
+
+/*
+*Cau hinh TLS toi backend cho instance tao boi registry (backend tinh, snapshot)
+ */
+func WithUpstreams(u *registry.Upstreams) Option {
+	return func(r *InMemoryRegistry) {
+		r.upstreams = u
+	}
+}
+
+func NewInMemoryRegistry(logger *slog.Logger, checkInterval time.Duration, providerChannel provider.ProviderChannel, opts ...Option) *InMemoryRegistry {
 	if logger == nil {
 		logger = slog.Default()
 	}
@@ -45,6 +109,7 @@ func NewInMemoryRegistry(logger *slog.Logger, checkInterval time.Duration, provi
 		checkInterval = 10 * time.Second
 	}
 
+	def := config.Defaults().Registry
 	reg := &InMemoryRegistry{
 		services:        make(map[string]map[string]*model.Server),
 		updateChan:      make(chan *model.Server, 64),
@@ -52,6 +117,14 @@ func NewInMemoryRegistry(logger *slog.Logger, checkInterval time.Duration, provi
 		workers:         make(map[string]*workerState),
 		checkInterval:   checkInterval,
 		providerChannel: providerChannel,
+		weightReverts:   make(map[string]*weightRevert),
+		intervalChanged: make(chan struct{}),
+		instanceTTL:     def.InstanceTTL,
+		maxInstances:    def.MaxInstancesPerService,
+	}
+
+	for _, opt := range opts {
+		opt(reg)
 	}
 
 	reg.ctx, reg.cancel = context.WithCancel(context.Background())
@@ -70,9 +143,12 @@ func (r *InMemoryRegistry) UpdateStatus(srv *model.Server, alive bool) {
 		if existing, exists := instances[srv.InstanceID]; exists {
 			wasHealthy := existing.IsHealthy()
 			existing.SetAlive(alive)
+			if alive {
+				r.confirmRestored(existing)
+			}
 
 			// day vao channel de update server_pool
-			r.updateChan <- existing
+			r.notify(registry.EventHealthChange, existing)
 			r.logger.Debug("Health state changed",
 				"service", srv.ServiceName,
 				"id", srv.InstanceID,
@@ -83,12 +159,34 @@ func (r *InMemoryRegistry) UpdateStatus(srv *model.Server, alive bool) {
 	}
 }
 
+/*
+*Instance khoi phuc tu snapshot da duoc xac nhan con song
+ */
+func (r *InMemoryRegistry) confirmRestored(srv *model.Server) {
+	if srv.IsPending() {
+		srv.SetPending(false)
+		r.logger.Info("Restored instance verified", "service", srv.ServiceName, "id", srv.InstanceID)
+	}
+}
+
 /*
 *Khoi dong tat ca cac dich vu
  */
 func (r *InMemoryRegistry) Start() {
 	r.startOne.Do(func() {
 		r.ctx, r.cancel = context.WithCancel(context.Background())
+
+		if r.snapshotPath != "" {
+			if err := r.restoreSnapshot(); err != nil {
+				r.logger.Error("Failed to restore registry snapshot", "path", r.snapshotPath, "err", err)
+			}
+
+			if r.snapshotInterval > 0 {
+				r.wg.Add(1)
+				go r.snapshotLoop()
+			}
+		}
+
 		r.wg.Add(1)
 		go r.ServerGate()
 		r.wg.Add(1)
@@ -106,6 +204,7 @@ func (i *InMemoryRegistry) Stop() {
 			i.logger.Info("Starting stop registry")
 			i.cancel()
 		}
+		i.stopWeightReverts()
 		i.wg.Wait()
 		i.logger.Info("Complete stop registry")
 	})
@@ -120,13 +219,66 @@ func (r *InMemoryRegistry) ServerGate() {
 		select {
 		case <-r.ctx.Done():
 			return
-		case srv := <-r.providerChannel:
-			r.Register(srv)
-			r.logger.Debug("List of server registry")
+		case event := <-r.providerChannel:
+			r.handleProviderEvent(event)
 		}
 	}
 }
 
+/*
+*Xu ly su kien tu provider: dang ky, huy dang ky hoac heartbeat
+ */
+func (r *InMemoryRegistry) handleProviderEvent(event *provider.ProviderEvent) {
+	var err error
+
+	switch event.Action {
+	case provider.ActionRegister:
+		err = r.Register(event.Server)
+		r.logger.Debug("List of server registry")
+	case provider.ActionDeregister:
+		err = r.Deregister(event.ServiceName, event.InstanceID)
+	case provider.ActionHeartbeat:
+		err = r.Heartbeat(event.ServiceName, event.InstanceID)
+	}
+
+	if err != nil {
+		r.logger.Warn("Failed to handle provider event",
+			"action", event.Action,
+			"service", event.ServiceName,
+			"id", event.InstanceID,
+			"err", err,
+		)
+	}
+}
+
+/*
+*Cap nhat LastSeen cua instance, danh dau healthy lai neu truoc do bi down
+ */
+func (r *InMemoryRegistry) Heartbeat(serviceName, instanceID string) error {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	instances, ok := r.services[serviceName]
+	if !ok {
+		return ErrInstanceNotFound
+	}
+
+	existing, exists := instances[instanceID]
+	if !exists {
+		return ErrInstanceNotFound
+	}
+
+	wasHealthy := existing.IsHealthy()
+	existing.SetAlive(true)
+	r.confirmRestored(existing)
+
+	if !wasHealthy {
+		r.notify(registry.EventHealthChange, existing)
+	}
+
+	return nil
+}
+
 /*
 *Khoi dong duyet cac server de loai bo server het thoi gian ttl
  */
@@ -162,6 +314,8 @@ func (r *InMemoryRegistry) loopRemoveServers() {
 					"id", instanceID)
 
 				delete(instances, instanceID)
+				srv.SetAlive(false)
+				r.notify(registry.EventDeregister, srv)
 			}
 		}
 		if len(instances) == 0 {
@@ -196,6 +350,18 @@ func (r *InMemoryRegistry) Discover(serviceName string) ([]*model.Server, error)
 	return healthy, nil
 }
 
+/*
+*Day thay doi vao updateChan cho server pool va ghi lai su kien cho watcher.
+*Phai goi khi dang giu r.mux de thu tu su kien dung voi thu tu thay doi
+ */
+func (r *InMemoryRegistry) notify(eventType registry.EventType, srv *model.Server) {
+	r.updateChan <- srv
+
+	if r.events != nil {
+		r.events.Publish(eventType, srv)
+	}
+}
+
 /*
 *Tra ve channel de health checker gui thong tin alive cua instance
  */
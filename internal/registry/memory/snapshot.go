@@ -0,0 +1,213 @@
+package memory
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/nhutphuongasasa/loadbalancer/internal/health"
+	"github.com/nhutphuongasasa/loadbalancer/internal/model"
+)
+
+const snapshotVersion = 1
+
+type snapshotFile struct {
+	Version   int                `json:"version"`
+	CreatedAt time.Time          `json:"createdAt"`
+	Instances []snapshotInstance `json:"instances"`
+}
+
+type snapshotInstance struct {
+	ServiceName string             `json:"serviceName"`
+	InstanceID  string             `json:"instanceID"`
+	Host        string             `json:"host"`
+	Port        int                `json:"port"`
+	Weight      int                `json:"weight"`
+	Metadata    map[string]string  `json:"metadata,omitempty"`
+	TTL         string             `json:"ttl"`
+	TLS         *model.UpstreamTLS `json:"tls,omitempty"`
+}
+
+/*
+*Bat luu snapshot dinh ky ra file va nap lai khi khoi dong
+ */
+func WithSnapshot(path string, interval time.Duration) Option {
+	return func(r *InMemoryRegistry) {
+		r.snapshotPath = path
+		r.snapshotInterval = interval
+	}
+}
+
+/*
+*Ghi snapshot dinh ky, ghi them 1 lan cuoi khi registry dung
+ */
+func (r *InMemoryRegistry) snapshotLoop() {
+	defer r.wg.Done()
+
+	ticker := time.NewTicker(r.snapshotInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := r.SaveSnapshot(); err != nil {
+				r.logger.Error("Failed to write registry snapshot", "path", r.snapshotPath, "err", err)
+			}
+		case <-r.ctx.Done():
+			if err := r.SaveSnapshot(); err != nil {
+				r.logger.Error("Failed to write final registry snapshot", "path", r.snapshotPath, "err", err)
+			}
+			return
+		}
+	}
+}
+
+/*
+*Ghi snapshot theo kieu atomic: ghi file tam cung thu muc, fsync roi rename de
+*khong bao gio de lai file do dang khi process bi kill giua chung
+ */
+func (r *InMemoryRegistry) SaveSnapshot() error {
+	snap := r.buildSnapshot()
+
+	data, err := json.MarshalIndent(snap, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(r.snapshotPath)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(dir, filepath.Base(r.snapshotPath)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmp.Name(), r.snapshotPath); err != nil {
+		return err
+	}
+
+	r.logger.Debug("Registry snapshot written", "path", r.snapshotPath, "instances", len(snap.Instances))
+	return nil
+}
+
+func (r *InMemoryRegistry) buildSnapshot() *snapshotFile {
+	r.mux.RLock()
+	defer r.mux.RUnlock()
+
+	snap := &snapshotFile{
+		Version:   snapshotVersion,
+		CreatedAt: time.Now().UTC(),
+		Instances: make([]snapshotInstance, 0),
+	}
+
+	for _, instances := range r.services {
+		for _, srv := range instances {
+			// backend tinh duoc nap lai tu config, khong can luu
+			if srv.IsStatic() {
+				continue
+			}
+			snap.Instances = append(snap.Instances, snapshotInstance{
+				ServiceName: srv.ServiceName,
+				InstanceID:  srv.InstanceID,
+				Host:        srv.Host,
+				Port:        srv.Port,
+				Weight:      r.configuredWeight(srv),
+				Metadata:    srv.GetMetadata(),
+				TTL:         srv.TTL.String(),
+				TLS:         srv.UpstreamTLS(),
+			})
+		}
+	}
+
+	return snap
+}
+
+/*
+*Nap snapshot vao registry o trang thai pending, health check xac nhan xong moi dua vao server pool
+ */
+func (r *InMemoryRegistry) restoreSnapshot() error {
+	data, err := os.ReadFile(r.snapshotPath)
+	if os.IsNotExist(err) {
+		r.logger.Info("No registry snapshot found, starting empty", "path", r.snapshotPath)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var snap snapshotFile
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return fmt.Errorf("decode snapshot: %w", err)
+	}
+
+	if snap.Version != snapshotVersion {
+		return fmt.Errorf("unsupported snapshot version %d (expected %d)", snap.Version, snapshotVersion)
+	}
+
+	restored := make([]*model.Server, 0, len(snap.Instances))
+
+	r.mux.Lock()
+	for _, inst := range snap.Instances {
+		opts, err := r.upstreams.ServerOptions(inst.ServiceName, inst.TLS)
+		if err != nil {
+			r.logger.Warn("Skipping snapshot instance with unusable upstream TLS", "service", inst.ServiceName, "id", inst.InstanceID, "err", err)
+			continue
+		}
+
+		srv := model.NewServer(inst.InstanceID, inst.ServiceName, inst.Host, inst.Port, inst.Weight, inst.Metadata, nil, opts...)
+		if ttl, err := time.ParseDuration(inst.TTL); err == nil && ttl > 0 {
+			srv.TTL = ttl
+		}
+
+		if !r.checkServer(srv) {
+			continue
+		}
+
+		// instance da dang ky lai truoc khi nap snapshot thi giu ban moi
+		if _, exists := r.services[srv.ServiceName][srv.InstanceID]; exists {
+			continue
+		}
+
+		r.createResilienceProxy(srv)
+		srv.SetPending(true)
+		r.services[srv.ServiceName][srv.InstanceID] = srv
+		r.ensureWorkerForService(srv.ServiceName)
+
+		restored = append(restored, srv)
+	}
+	r.mux.Unlock()
+
+	r.logger.Info("Registry snapshot restored, waiting for health verification",
+		"path", r.snapshotPath,
+		"instances", len(restored),
+		"snapshot_age", time.Since(snap.CreatedAt).Round(time.Second),
+	)
+
+	if len(restored) > 0 {
+		// kiem tra ngay, khong doi tick dau tien cua worker
+		r.wg.Add(1)
+		go func() {
+			defer r.wg.Done()
+			health.NewHeathChecker(r.logger).CheckServers(restored, r.UpdateStatus)
+		}()
+	}
+
+	return nil
+}
@@ -0,0 +1,66 @@
+package memory
+
+import (
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/nhutphuongasasa/loadbalancer/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshot_RestorePendingUntilVerified(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	path := filepath.Join(t.TempDir(), "registry.json")
+
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer backend.Close()
+
+	host, portStr, err := net.SplitHostPort(backend.Listener.Addr().String())
+	require.NoError(t, err)
+	port, _ := strconv.Atoi(portStr)
+
+	first := NewInMemoryRegistry(logger, time.Hour, nil, WithSnapshot(path, 0))
+	require.NoError(t, first.Register(model.NewServer("api-1", "api", host, port, 5, map[string]string{"v": "2"}, nil)))
+	<-first.GetUpdateChan()
+	require.NoError(t, first.SaveSnapshot())
+
+	// khong de lai file tam sau khi rename
+	entries, err := os.ReadDir(filepath.Dir(path))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+
+	second := NewInMemoryRegistry(logger, time.Hour, nil, WithSnapshot(path, 0))
+	second.Start()
+	defer second.Stop()
+
+	select {
+	case srv := <-second.GetUpdateChan():
+		assert.Equal(t, "api-1", srv.InstanceID)
+		assert.True(t, srv.IsHealthy())
+		assert.False(t, srv.IsPending())
+		assert.Equal(t, 5, srv.GetWeight())
+		assert.Equal(t, "2", srv.GetMetadata()["v"])
+	case <-time.After(5 * time.Second):
+		t.Fatal("restored instance was never verified")
+	}
+}
+
+func TestSnapshot_RejectsUnknownVersion(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	path := filepath.Join(t.TempDir(), "registry.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"version": 99, "instances": []}`), 0644))
+
+	reg := NewInMemoryRegistry(logger, time.Hour, nil, WithSnapshot(path, 0))
+	err := reg.restoreSnapshot()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unsupported snapshot version")
+}
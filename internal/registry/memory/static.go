@@ -0,0 +1,22 @@
+package memory
+
+import (
+	"github.com/nhutphuongasasa/loadbalancer/internal/model"
+)
+
+/*
+*Dang ky backend khai bao trong config: khong het han theo TTL nhung van duoc health check.
+*upstream nil thi TLS chi theo config upstreams cua service
+ */
+func (r *InMemoryRegistry) RegisterStatic(serviceName, instanceID, host string, port, weight int, upstream *model.UpstreamTLS) error {
+	opts, err := r.upstreams.ServerOptions(serviceName, upstream)
+	if err != nil {
+		return err
+	}
+
+	srv := model.NewServer(instanceID, serviceName, host, port, weight, map[string]string{"source": "static"}, nil, opts...)
+	srv.SetStatic(true)
+	r.createResilienceProxy(srv)
+
+	return r.Register(srv)
+}
@@ -0,0 +1,116 @@
+package memory
+
+import (
+	"time"
+
+	"github.com/nhutphuongasasa/loadbalancer/internal/model"
+	"github.com/nhutphuongasasa/loadbalancer/internal/registry"
+)
+
+type weightRevert struct {
+	original int
+	timer    *time.Timer
+}
+
+func weightKey(serviceName, instanceID string) string {
+	return serviceName + "/" + instanceID
+}
+
+/*
+*Doi weight cua instance luc runtime va day vao server pool.
+*revertAfter > 0 thi tu dong tra ve weight cu sau khoang thoi gian do
+ */
+func (r *InMemoryRegistry) SetWeight(serviceName, instanceID string, weight int, revertAfter time.Duration) (*model.Server, error) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	srv, err := r.lookupLocked(serviceName, instanceID)
+	if err != nil {
+		return nil, err
+	}
+
+	key := weightKey(serviceName, instanceID)
+	previous := srv.GetWeight()
+
+	r.weightMux.Lock()
+	pending, hasPending := r.weightReverts[key]
+	if hasPending {
+		pending.timer.Stop()
+		delete(r.weightReverts, key)
+	}
+
+	if revertAfter > 0 {
+		// doi tam nhieu lan lien tiep thi van tra ve weight goc ban dau
+		original := previous
+		if hasPending {
+			original = pending.original
+		}
+
+		r.weightReverts[key] = &weightRevert{
+			original: original,
+			timer: time.AfterFunc(revertAfter, func() {
+				r.revertWeight(serviceName, instanceID, original)
+			}),
+		}
+	}
+	r.weightMux.Unlock()
+
+	srv.SetWeight(weight)
+	r.notify(registry.EventWeightChange, srv)
+
+	r.logger.Info("Instance weight changed",
+		"service", serviceName,
+		"id", instanceID,
+		"from", previous,
+		"to", weight,
+		"revert_after", revertAfter,
+	)
+
+	return srv, nil
+}
+
+/*
+*Weight khong tinh thay doi tam thoi: dang cho revert thi tra ve weight goc,
+*de snapshot khong bien weight tam thanh vinh vien khi restart
+ */
+func (r *InMemoryRegistry) configuredWeight(srv *model.Server) int {
+	r.weightMux.Lock()
+	defer r.weightMux.Unlock()
+
+	if pending, ok := r.weightReverts[weightKey(srv.ServiceName, srv.InstanceID)]; ok {
+		return pending.original
+	}
+	return srv.GetWeight()
+}
+
+func (r *InMemoryRegistry) revertWeight(serviceName, instanceID string, original int) {
+	r.weightMux.Lock()
+	delete(r.weightReverts, weightKey(serviceName, instanceID))
+	r.weightMux.Unlock()
+
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	srv, err := r.lookupLocked(serviceName, instanceID)
+	if err != nil {
+		return
+	}
+
+	srv.SetWeight(original)
+	r.notify(registry.EventWeightChange, srv)
+
+	r.logger.Info("Instance weight reverted", "service", serviceName, "id", instanceID, "weight", original)
+}
+
+/*
+*Huy cac timer revert con treo khi registry dung
+ */
+func (r *InMemoryRegistry) stopWeightReverts() {
+	r.weightMux.Lock()
+	defer r.weightMux.Unlock()
+
+	for key, rv := range r.weightReverts {
+		rv.timer.Stop()
+		delete(r.weightReverts, key)
+	}
+}
@@ -0,0 +1,61 @@
+package memory
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/nhutphuongasasa/loadbalancer/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetWeight_AutoRevert(t *testing.T) {
+	reg := NewInMemoryRegistry(slog.New(slog.NewTextHandler(io.Discard, nil)), time.Hour, nil)
+
+	srv := model.NewServer("api-1", "api", "10.0.0.1", 8080, 10, nil, nil)
+	require.NoError(t, reg.Register(srv))
+	<-reg.GetUpdateChan()
+
+	_, err := reg.SetWeight("api", "api-1", 2, 50*time.Millisecond)
+	require.NoError(t, err)
+	assert.Equal(t, 2, (<-reg.GetUpdateChan()).GetWeight())
+
+	// doi tam lan 2 van revert ve weight goc
+	_, err = reg.SetWeight("api", "api-1", 0, 50*time.Millisecond)
+	require.NoError(t, err)
+	update := <-reg.GetUpdateChan()
+	assert.False(t, update.IsAvailable())
+
+	select {
+	case update = <-reg.GetUpdateChan():
+		assert.Equal(t, 10, update.GetWeight())
+		assert.True(t, update.IsAvailable())
+	case <-time.After(time.Second):
+		t.Fatal("weight was not reverted")
+	}
+}
+
+func TestSnapshot_KeepsWeightBeforeTemporaryChange(t *testing.T) {
+	reg := NewInMemoryRegistry(slog.New(slog.NewTextHandler(io.Discard, nil)), time.Hour, nil)
+	defer reg.stopWeightReverts()
+
+	require.NoError(t, reg.Register(model.NewServer("api-1", "api", "10.0.0.1", 8080, 10, nil, nil)))
+	<-reg.GetUpdateChan()
+
+	_, err := reg.SetWeight("api", "api-1", 2, time.Hour)
+	require.NoError(t, err)
+	<-reg.GetUpdateChan()
+
+	// restart luc dang cho revert thi weight tam bi bo
+	snap := reg.buildSnapshot()
+	require.Len(t, snap.Instances, 1)
+	assert.Equal(t, 10, snap.Instances[0].Weight)
+
+	// doi vinh vien thi luu weight moi
+	_, err = reg.SetWeight("api", "api-1", 7, 0)
+	require.NoError(t, err)
+	<-reg.GetUpdateChan()
+	assert.Equal(t, 7, reg.buildSnapshot().Instances[0].Weight)
+}
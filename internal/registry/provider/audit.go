@@ -0,0 +1,62 @@
+package provider
+
+import (
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/nhutphuongasasa/loadbalancer/internal/model"
+)
+
+/*
+*Mo file audit log dang JSON, loi thi dung logger chinh voi module AUDIT
+ */
+func newAuditLogger(path string, fallback *slog.Logger) *slog.Logger {
+	if path == "" {
+		return fallback.With("module", "AUDIT")
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		fallback.Warn("Could not create audit log dir, using main logger", "path", path, "err", err)
+		return fallback.With("module", "AUDIT")
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0640)
+	if err != nil {
+		fallback.Warn("Could not open audit log, using main logger", "path", path, "err", err)
+		return fallback.With("module", "AUDIT")
+	}
+
+	return slog.New(slog.NewJSONHandler(f, nil)).With("module", "AUDIT")
+}
+
+/*
+*Ghi lai 1 thay doi dang ky (chap nhan hoac tu choi)
+ */
+func (p *ProviderServer) audit(req *http.Request, action string, input *model.Input, principal string, rejected *apiError) {
+	attrs := []any{
+		"action", action,
+		"remote_addr", req.RemoteAddr,
+		"path", req.URL.Path,
+		"principal", principal,
+	}
+
+	if input != nil {
+		attrs = append(attrs,
+			"service", input.ServiceName,
+			"instance", input.InstanceID,
+			"host", input.Host,
+			"port", input.Port,
+		)
+	}
+
+	if rejected != nil {
+		attrs = append(attrs, "result", "rejected", "code", rejected.Code, "reason", rejected.Message)
+		p.auditLogger.Warn("Registration rejected", attrs...)
+		return
+	}
+
+	attrs = append(attrs, "result", "accepted")
+	p.auditLogger.Info("Registration changed", attrs...)
+}
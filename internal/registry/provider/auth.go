@@ -0,0 +1,260 @@
+package provider
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nhutphuongasasa/loadbalancer/internal/config"
+)
+
+const (
+	HeaderTimestamp = "X-LB-Timestamp"
+	HeaderSignature = "X-LB-Signature"
+	HeaderAPIKey    = "X-LB-API-Key"
+	HeaderConsulKey = "X-Consul-Token"
+
+	authModeNone   = "none"
+	authModeHMAC   = "hmac"
+	authModeAPIKey = "api_key"
+
+	defaultMaxClockSkew = 5 * time.Minute
+)
+
+type registrationAuth struct {
+	mode         string
+	secret       []byte
+	apiKeys      map[string]string
+	maxClockSkew time.Duration
+}
+
+type servicePolicy struct {
+	nets    []*net.IPNet
+	ports   map[int]bool
+	denyAll bool // co CIDR khong hop le, chan moi dang ky cua service
+}
+
+/*
+*Khoi tao thong tin xac thuc tu config, mode rong hoac none thi cho phep tat ca
+ */
+func newRegistrationAuth(cfg *config.RegistryAuthConfig) *registrationAuth {
+	if cfg == nil || cfg.Mode == "" || cfg.Mode == authModeNone {
+		return nil
+	}
+
+	a := &registrationAuth{
+		mode:         cfg.Mode,
+		secret:       []byte(cfg.SharedSecret),
+		apiKeys:      make(map[string]string, len(cfg.APIKeys)),
+		maxClockSkew: cfg.MaxClockSkew,
+	}
+
+	// viper dua key cua map ve chu thuong
+	for svc, key := range cfg.APIKeys {
+		a.apiKeys[strings.ToLower(svc)] = key
+	}
+
+	if a.maxClockSkew <= 0 {
+		a.maxClockSkew = defaultMaxClockSkew
+	}
+
+	return a
+}
+
+/*
+*Xac thuc request dang ky cho 1 service, tra ve principal dung de ghi audit
+ */
+func (a *registrationAuth) authenticate(req *http.Request, body []byte, serviceName string) (string, *apiError) {
+	switch a.mode {
+	case authModeHMAC:
+		return a.verifyHMAC(req, body)
+	case authModeAPIKey:
+		return a.verifyAPIKey(req, serviceName)
+	}
+
+	return "", newAPIError(http.StatusUnauthorized, "auth_mode_unsupported", "registry auth mode is not supported")
+}
+
+/*
+*Chu ky = hex(HMAC-SHA256(secret, timestamp + "\n" + method + "\n" + path + "\n" + body))
+ */
+func (a *registrationAuth) verifyHMAC(req *http.Request, body []byte) (string, *apiError) {
+	tsHeader := req.Header.Get(HeaderTimestamp)
+	sigHeader := req.Header.Get(HeaderSignature)
+	if tsHeader == "" || sigHeader == "" {
+		return "", newAPIError(http.StatusUnauthorized, "missing_signature",
+			fmt.Sprintf("%s and %s headers are required", HeaderTimestamp, HeaderSignature))
+	}
+
+	ts, err := strconv.ParseInt(tsHeader, 10, 64)
+	if err != nil {
+		return "", newAPIError(http.StatusUnauthorized, "invalid_timestamp", "timestamp must be unix seconds")
+	}
+
+	skew := time.Since(time.Unix(ts, 0))
+	if skew < 0 {
+		skew = -skew
+	}
+	if skew > a.maxClockSkew {
+		return "", newAPIError(http.StatusUnauthorized, "expired_signature", "timestamp is outside the allowed clock skew")
+	}
+
+	expected := SignRequest(a.secret, tsHeader, req.Method, req.URL.Path, body)
+	if !hmac.Equal([]byte(expected), []byte(strings.ToLower(sigHeader))) {
+		return "", newAPIError(http.StatusUnauthorized, "invalid_signature", "request signature does not match")
+	}
+
+	return "hmac", nil
+}
+
+func (a *registrationAuth) verifyAPIKey(req *http.Request, serviceName string) (string, *apiError) {
+	key := extractAPIKey(req)
+	if key == "" {
+		return "", newAPIError(http.StatusUnauthorized, "missing_api_key", "an API key is required to register services")
+	}
+
+	expected, ok := a.apiKeys[strings.ToLower(serviceName)]
+	if !ok || subtle.ConstantTimeCompare([]byte(expected), []byte(key)) != 1 {
+		return "", newAPIError(http.StatusForbidden, "invalid_api_key",
+			fmt.Sprintf("API key is not valid for service %q", serviceName))
+	}
+
+	return "api_key:" + serviceName, nil
+}
+
+func extractAPIKey(req *http.Request) string {
+	if key := req.Header.Get(HeaderAPIKey); key != "" {
+		return key
+	}
+
+	if key := req.Header.Get(HeaderConsulKey); key != "" {
+		return key
+	}
+
+	if auth := req.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		return strings.TrimPrefix(auth, "Bearer ")
+	}
+
+	return ""
+}
+
+/*
+*Endpoint quan tri yeu cau Authorization: Bearer <admin_token>, chua cau hinh token thi tu choi
+ */
+func (p *ProviderServer) requireAdmin(req *http.Request) *apiError {
+	if p.adminToken == "" {
+		return newAPIError(http.StatusForbidden, "admin_disabled", "admin operations require registry.auth.admin_token to be configured")
+	}
+
+	auth := req.Header.Get("Authorization")
+	token := strings.TrimPrefix(auth, "Bearer ")
+	if auth == "" || token == auth {
+		return newAPIError(http.StatusUnauthorized, "missing_admin_token", "an admin bearer token is required")
+	}
+
+	if subtle.ConstantTimeCompare([]byte(token), []byte(p.adminToken)) != 1 {
+		return newAPIError(http.StatusForbidden, "invalid_admin_token", "admin token is not valid")
+	}
+
+	return nil
+}
+
+/*
+*Tao chu ky HMAC cho request dang ky, dung chung cho server va client
+ */
+func SignRequest(secret []byte, timestamp, method, path string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp + "\n" + method + "\n" + path + "\n"))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+/*
+*Chuyen danh sach policy trong config thanh allow-list theo service
+ */
+func newServicePolicies(cfgs []*config.RegistryPolicyConfig) map[string]*servicePolicy {
+	policies := make(map[string]*servicePolicy, len(cfgs))
+
+	for _, c := range cfgs {
+		p := &servicePolicy{ports: make(map[int]bool, len(c.Ports))}
+
+		for _, cidr := range c.CIDRs {
+			_, ipNet, err := net.ParseCIDR(cidr)
+			if err != nil {
+				// bo qua CIDR loi co the bien allow-list thanh rong (cho phep tat ca), chan ca service
+				p.denyAll = true
+				continue
+			}
+			p.nets = append(p.nets, ipNet)
+		}
+
+		for _, port := range c.Ports {
+			p.ports[port] = true
+		}
+
+		policies[c.Service] = p
+	}
+
+	return policies
+}
+
+/*
+*Kiem tra host va port co nam trong allow-list cua service khong
+ */
+func (sp *servicePolicy) allow(host string, port int) *apiError {
+	if sp.denyAll {
+		return newAPIError(http.StatusForbidden, "policy_invalid",
+			"registration policy for this service has an invalid cidr, all registrations are denied")
+	}
+
+	if len(sp.ports) > 0 && !sp.ports[port] {
+		return newAPIError(http.StatusForbidden, "port_not_allowed",
+			fmt.Sprintf("port %d is not allowed for this service", port))
+	}
+
+	if len(sp.nets) == 0 {
+		return nil
+	}
+
+	ips := resolveHost(host)
+	if len(ips) == 0 {
+		return newAPIError(http.StatusForbidden, "host_not_allowed",
+			fmt.Sprintf("host %q could not be resolved", host))
+	}
+
+	// moi IP ma host tro toi deu phai nam trong allow-list
+	for _, ip := range ips {
+		allowed := false
+		for _, n := range sp.nets {
+			if n.Contains(ip) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return newAPIError(http.StatusForbidden, "host_not_allowed",
+				fmt.Sprintf("host %q is not in the allowed networks for this service", host))
+		}
+	}
+
+	return nil
+}
+
+func resolveHost(host string) []net.IP {
+	if ip := net.ParseIP(host); ip != nil {
+		return []net.IP{ip}
+	}
+
+	addrs, err := net.LookupIP(host)
+	if err != nil {
+		return nil
+	}
+	return addrs
+}
@@ -0,0 +1,130 @@
+package provider
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nhutphuongasasa/loadbalancer/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func quietLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+func decodeAPIError(t *testing.T, rec *httptest.ResponseRecorder) map[string]string {
+	t.Helper()
+
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	var body map[string]string
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+	return body
+}
+
+func TestRegister_HMAC(t *testing.T) {
+	secret := "s3cret"
+	p := NewProviderServer(quietLogger(), WithRegistryConfig(&config.RegistryConfig{
+		Auth: &config.RegistryAuthConfig{Mode: "hmac", SharedSecret: secret},
+	}))
+	h := p.RegisterHTTPHandler()
+
+	body := `{"serviceName": "user-service", "host": "10.0.0.1", "port": 8080}`
+
+	// khong co chu ky
+	rec := doRequest(t, h, http.MethodPost, "/", body)
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t, "missing_signature", decodeAPIError(t, rec)["error"])
+
+	// chu ky sai
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	req.Header.Set(HeaderTimestamp, ts)
+	req.Header.Set(HeaderSignature, SignRequest([]byte("other"), ts, http.MethodPost, "/", []byte(body)))
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t, "invalid_signature", decodeAPIError(t, rec)["error"])
+
+	// chu ky dung
+	req = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	req.Header.Set(HeaderTimestamp, ts)
+	req.Header.Set(HeaderSignature, SignRequest([]byte(secret), ts, http.MethodPost, "/", []byte(body)))
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusCreated, rec.Code)
+
+	event := <-p.GetProviderChannel()
+	assert.Equal(t, "user-service", event.Server.ServiceName)
+}
+
+func TestRegister_APIKeyPerService(t *testing.T) {
+	p := NewProviderServer(quietLogger(), WithRegistryConfig(&config.RegistryConfig{
+		Auth: &config.RegistryAuthConfig{
+			Mode:    "api_key",
+			APIKeys: map[string]string{"payment-service": "pay-key"},
+		},
+	}))
+	h := p.RegisterHTTPHandler()
+
+	send := func(service, key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/",
+			strings.NewReader(`{"serviceName": "`+service+`", "host": "10.0.0.1", "port": 8080}`))
+		req.Header.Set(HeaderAPIKey, key)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := send("user-service", "pay-key")
+	require.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Equal(t, "invalid_api_key", decodeAPIError(t, rec)["error"])
+
+	rec = send("payment-service", "pay-key")
+	require.Equal(t, http.StatusCreated, rec.Code)
+	<-p.GetProviderChannel()
+}
+
+func TestRegister_PolicyAllowList(t *testing.T) {
+	p := NewProviderServer(quietLogger(), WithRegistryConfig(&config.RegistryConfig{
+		Policies: []*config.RegistryPolicyConfig{
+			{Service: "db-proxy", CIDRs: []string{"10.1.0.0/16"}, Ports: []int{5432}},
+			{Service: "cache", CIDRs: []string{"10.3.0.0/33"}},
+		},
+	}))
+	h := p.RegisterHTTPHandler()
+
+	tests := []struct {
+		name     string
+		body     string
+		wantCode int
+		wantErr  string
+	}{
+		{"host outside cidr", `{"serviceName": "db-proxy", "host": "10.2.0.1", "port": 5432}`, http.StatusForbidden, "host_not_allowed"},
+		{"port not allowed", `{"serviceName": "db-proxy", "host": "10.1.0.1", "port": 5433}`, http.StatusForbidden, "port_not_allowed"},
+		{"allowed", `{"serviceName": "db-proxy", "host": "10.1.2.3", "port": 5432}`, http.StatusCreated, ""},
+		{"service without policy", `{"serviceName": "web", "host": "192.168.1.1", "port": 80}`, http.StatusCreated, ""},
+		{"invalid cidr denies all", `{"serviceName": "cache", "host": "10.3.0.1", "port": 6379}`, http.StatusForbidden, "policy_invalid"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := doRequest(t, h, http.MethodPost, "/", tt.body)
+			require.Equal(t, tt.wantCode, rec.Code)
+
+			if tt.wantErr != "" {
+				assert.Equal(t, tt.wantErr, decodeAPIError(t, rec)["error"])
+				return
+			}
+			<-p.GetProviderChannel()
+		})
+	}
+}
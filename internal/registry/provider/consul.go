@@ -0,0 +1,273 @@
+package provider
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nhutphuongasasa/loadbalancer/internal/model"
+)
+
+// consulServiceDefinition la body cua PUT /v1/agent/service/register theo Consul agent API
+type consulServiceDefinition struct {
+	ID      string            `json:"ID"`
+	Name    string            `json:"Name"`
+	Tags    []string          `json:"Tags,omitempty"`
+	Address string            `json:"Address,omitempty"`
+	Port    int               `json:"Port,omitempty"`
+	Meta    map[string]string `json:"Meta,omitempty"`
+	Weights *consulWeights    `json:"Weights,omitempty"`
+	Check   *consulCheck      `json:"Check,omitempty"`
+	Checks  []*consulCheck    `json:"Checks,omitempty"`
+}
+
+type consulWeights struct {
+	Passing int `json:"Passing"`
+	Warning int `json:"Warning"`
+}
+
+type consulCheck struct {
+	CheckID string `json:"CheckID,omitempty"`
+	Name    string `json:"Name,omitempty"`
+	TTL     string `json:"TTL,omitempty"`
+	HTTP    string `json:"HTTP,omitempty"`
+	TCP     string `json:"TCP,omitempty"`
+}
+
+type consulEntry struct {
+	serviceName string
+	instanceID  string
+	checkIDs    []string
+}
+
+/*
+*Dich service definition cua Consul sang model.Input va dang ky nhu endpoint goc
+ */
+func (p *ProviderServer) consulRegister(w http.ResponseWriter, req *http.Request) {
+	body, err := readBody(req)
+	if err != nil {
+		writeAPIError(w, newAPIError(http.StatusBadRequest, "invalid_body", err.Error()))
+		return
+	}
+
+	var def consulServiceDefinition
+	if err := json.Unmarshal(body, &def); err != nil {
+		p.logger.Error("Invalid consul service definition", "err", err)
+		http.Error(w, "Request decode failed: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	input := def.toInput()
+	if err := completeInput(input, req.RemoteAddr); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ttl, err := def.ttl()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if !p.admit(w, req, body, "register", input) {
+		return
+	}
+
+	srv, err := p.buildServer(input, ttl)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	p.trackConsulEntry(&consulEntry{
+		serviceName: input.ServiceName,
+		instanceID:  input.InstanceID,
+		checkIDs:    def.checkIDs(input.InstanceID),
+	})
+
+	p.addNewServerChannel <- &ProviderEvent{Action: ActionRegister, Server: srv}
+
+	p.logger.Info("Consul service registered",
+		"service", input.ServiceName,
+		"id", input.InstanceID,
+		"ttl", srv.TTL,
+	)
+
+	// Consul agent tra ve 200 voi body rong
+	w.WriteHeader(http.StatusOK)
+}
+
+/*
+*Loai bo instance theo service ID cua Consul
+ */
+func (p *ProviderServer) consulDeregister(w http.ResponseWriter, req *http.Request) {
+	id := req.PathValue("id")
+
+	p.consulMux.RLock()
+	entry, ok := p.consulIDs[id]
+	p.consulMux.RUnlock()
+
+	if !ok {
+		http.Error(w, fmt.Sprintf("Unknown service ID %q. Ensure that the service ID is passed, not the service name.", id), http.StatusNotFound)
+		return
+	}
+
+	if !p.admit(w, req, nil, "deregister", entry.input()) {
+		return
+	}
+
+	p.consulMux.Lock()
+	if current, exists := p.consulIDs[id]; exists && current == entry {
+		delete(p.consulIDs, id)
+		for _, checkID := range entry.checkIDs {
+			delete(p.consulChecks, checkID)
+		}
+	}
+	p.consulMux.Unlock()
+
+	p.addNewServerChannel <- &ProviderEvent{
+		Action:      ActionDeregister,
+		ServiceName: entry.serviceName,
+		InstanceID:  entry.instanceID,
+	}
+
+	p.logger.Info("Consul service deregistered", "service", entry.serviceName, "id", entry.instanceID)
+	w.WriteHeader(http.StatusOK)
+}
+
+/*
+*TTL check pass cua Consul tuong duong 1 lan heartbeat cua instance
+ */
+func (p *ProviderServer) consulCheckPass(w http.ResponseWriter, req *http.Request) {
+	checkID := req.PathValue("id")
+
+	p.consulMux.RLock()
+	entry, ok := p.consulChecks[checkID]
+	p.consulMux.RUnlock()
+
+	if !ok {
+		http.Error(w, fmt.Sprintf("Unknown check ID %q", checkID), http.StatusNotFound)
+		return
+	}
+
+	if !p.admit(w, req, nil, "heartbeat", entry.input()) {
+		return
+	}
+
+	p.addNewServerChannel <- &ProviderEvent{
+		Action:      ActionHeartbeat,
+		ServiceName: entry.serviceName,
+		InstanceID:  entry.instanceID,
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (p *ProviderServer) trackConsulEntry(entry *consulEntry) {
+	p.consulMux.Lock()
+	defer p.consulMux.Unlock()
+
+	// dang ky lai cung ID thi xoa check cu truoc
+	if old, ok := p.consulIDs[entry.instanceID]; ok {
+		for _, checkID := range old.checkIDs {
+			delete(p.consulChecks, checkID)
+		}
+	}
+
+	p.consulIDs[entry.instanceID] = entry
+	for _, checkID := range entry.checkIDs {
+		p.consulChecks[checkID] = entry
+	}
+}
+
+func (e *consulEntry) input() *model.Input {
+	return &model.Input{ServiceName: e.serviceName, InstanceID: e.instanceID}
+}
+
+func (d *consulServiceDefinition) toInput() *model.Input {
+	metadata := make(map[string]string, len(d.Meta)+1)
+	for k, v := range d.Meta {
+		metadata[k] = v
+	}
+
+	if len(d.Tags) > 0 {
+		tags := append([]string(nil), d.Tags...)
+		sort.Strings(tags)
+		metadata["consul_tags"] = strings.Join(tags, ",")
+	}
+
+	input := &model.Input{
+		ServiceName: d.Name,
+		InstanceID:  d.ID,
+		Host:        d.Address,
+		Port:        d.Port,
+		Metadata:    metadata,
+	}
+
+	if d.Weights != nil {
+		input.Weight = d.Weights.Passing
+	}
+
+	// Consul mac dinh ID = Name khi khong truyen ID
+	if input.InstanceID == "" {
+		input.InstanceID = d.Name
+	}
+
+	return input
+}
+
+func (d *consulServiceDefinition) allChecks() []*consulCheck {
+	checks := make([]*consulCheck, 0, len(d.Checks)+1)
+	if d.Check != nil {
+		checks = append(checks, d.Check)
+	}
+	return append(checks, d.Checks...)
+}
+
+/*
+*Tinh check ID theo quy tac cua Consul: "service:<id>" hoac "service:<id>:<n>" khi co nhieu check
+ */
+func (d *consulServiceDefinition) checkIDs(serviceID string) []string {
+	checks := d.allChecks()
+	ids := make([]string, 0, len(checks))
+
+	for i, c := range checks {
+		switch {
+		case c.CheckID != "":
+			ids = append(ids, c.CheckID)
+		case len(checks) == 1:
+			ids = append(ids, "service:"+serviceID)
+		default:
+			ids = append(ids, fmt.Sprintf("service:%s:%d", serviceID, i+1))
+		}
+	}
+
+	return ids
+}
+
+/*
+*Lay TTL nho nhat trong cac TTL check, 0 neu khong co TTL check
+ */
+func (d *consulServiceDefinition) ttl() (time.Duration, error) {
+	var ttl time.Duration
+
+	for _, c := range d.allChecks() {
+		if c.TTL == "" {
+			continue
+		}
+
+		parsed, err := time.ParseDuration(c.TTL)
+		if err != nil {
+			return 0, fmt.Errorf("invalid check TTL %q: %w", c.TTL, err)
+		}
+
+		if ttl == 0 || parsed < ttl {
+			ttl = parsed
+		}
+	}
+
+	return ttl, nil
+}
@@ -0,0 +1,89 @@
+package provider
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func doRequest(t *testing.T, h http.Handler, method, path, body string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestConsulRegister_TranslatesToRegisterEvent(t *testing.T) {
+	p := NewProviderServer(slog.Default())
+	h := p.RegisterHTTPHandler()
+
+	rec := doRequest(t, h, http.MethodPut, "/v1/agent/service/register", `{
+		"ID": "web-1",
+		"Name": "web",
+		"Tags": ["v2", "primary"],
+		"Address": "10.0.0.5",
+		"Port": 9000,
+		"Meta": {"region": "eu"},
+		"Weights": {"Passing": 3, "Warning": 1},
+		"Check": {"TTL": "15s"}
+	}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	event := <-p.GetProviderChannel()
+	require.Equal(t, ActionRegister, event.Action)
+
+	srv := event.Server
+	assert.Equal(t, "web-1", srv.InstanceID)
+	assert.Equal(t, "web", srv.ServiceName)
+	assert.Equal(t, "10.0.0.5", srv.Host)
+	assert.Equal(t, 9000, srv.Port)
+	assert.Equal(t, 3, srv.Weight)
+	assert.Equal(t, 15*time.Second, srv.TTL)
+	assert.Equal(t, "eu", srv.Metadata["region"])
+	assert.Equal(t, "primary,v2", srv.Metadata["consul_tags"])
+}
+
+func TestConsulCheckPassAndDeregister(t *testing.T) {
+	p := NewProviderServer(slog.Default())
+	h := p.RegisterHTTPHandler()
+
+	rec := doRequest(t, h, http.MethodPut, "/v1/agent/service/register",
+		`{"ID": "api-1", "Name": "api", "Port": 8081, "Check": {"TTL": "10s"}}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	<-p.GetProviderChannel()
+
+	rec = doRequest(t, h, http.MethodPut, "/v1/agent/check/pass/service:api-1", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	event := <-p.GetProviderChannel()
+	assert.Equal(t, ActionHeartbeat, event.Action)
+	assert.Equal(t, "api", event.ServiceName)
+	assert.Equal(t, "api-1", event.InstanceID)
+
+	rec = doRequest(t, h, http.MethodPut, "/v1/agent/service/deregister/api-1", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	event = <-p.GetProviderChannel()
+	assert.Equal(t, ActionDeregister, event.Action)
+	assert.Equal(t, "api", event.ServiceName)
+
+	// check da bi xoa cung instance
+	rec = doRequest(t, h, http.MethodPut, "/v1/agent/check/pass/service:api-1", "")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestConsulRegister_InvalidTTL(t *testing.T) {
+	p := NewProviderServer(slog.Default())
+
+	rec := doRequest(t, p.RegisterHTTPHandler(), http.MethodPut, "/v1/agent/service/register",
+		`{"Name": "api", "Port": 8081, "Check": {"TTL": "soon"}}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
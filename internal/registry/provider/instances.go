@@ -0,0 +1,217 @@
+package provider
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/nhutphuongasasa/loadbalancer/internal/model"
+	"github.com/nhutphuongasasa/loadbalancer/internal/registry"
+)
+
+type InstanceStatus struct {
+	ServiceName string            `json:"serviceName"`
+	InstanceID  string            `json:"instanceID"`
+	Host        string            `json:"host"`
+	Port        int               `json:"port"`
+	Weight      int               `json:"weight"`
+	State       string            `json:"state"`
+	Healthy     bool              `json:"healthy"`
+	Draining    bool              `json:"draining"`
+	ActiveConns int32             `json:"activeConns"`
+	SafeToStop  bool              `json:"safeToStop"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+}
+
+func NewInstanceStatus(srv *model.Server) *InstanceStatus {
+	conns := srv.GetActiveConns()
+	draining := srv.IsDraining()
+
+	return &InstanceStatus{
+		ServiceName: srv.ServiceName,
+		InstanceID:  srv.InstanceID,
+		Host:        srv.Host,
+		Port:        srv.Port,
+		Weight:      srv.GetWeight(),
+		State:       srv.State(),
+		Healthy:     srv.IsHealthy(),
+		Draining:    draining,
+		ActiveConns: conns,
+		SafeToStop:  draining && conns == 0,
+		Metadata:    srv.GetMetadata(),
+	}
+}
+
+/*
+*Gan registry de cac endpoint quan tri instance (drain, resume, status) hoat dong
+ */
+func (p *ProviderServer) BindRegistry(m registry.InstanceManager) {
+	p.instances = m
+}
+
+func (p *ProviderServer) instanceStatusHandler(w http.ResponseWriter, req *http.Request) {
+	p.instanceAction(w, req, "", func(service, id string) (*model.Server, error) {
+		return p.instances.GetInstance(service, id)
+	})
+}
+
+/*
+*POST drain: instance tu yeu cau drain khi rolling deploy hoac admin dua vao maintenance
+ */
+func (p *ProviderServer) drainHandler(w http.ResponseWriter, req *http.Request) {
+	p.instanceAction(w, req, "drain", func(service, id string) (*model.Server, error) {
+		return p.instances.Drain(service, id)
+	})
+}
+
+func (p *ProviderServer) resumeHandler(w http.ResponseWriter, req *http.Request) {
+	p.instanceAction(w, req, "resume", func(service, id string) (*model.Server, error) {
+		return p.instances.Resume(service, id)
+	})
+}
+
+/*
+*DELETE instance: huy dang ky theo service va instance id, khong can di qua lop Consul
+ */
+func (p *ProviderServer) deregisterHandler(w http.ResponseWriter, req *http.Request) {
+	input := &model.Input{
+		ServiceName: req.PathValue("service"),
+		InstanceID:  req.PathValue("id"),
+	}
+
+	body, err := readBody(req)
+	if err != nil {
+		writeAPIError(w, newAPIError(http.StatusBadRequest, "invalid_body", err.Error()))
+		return
+	}
+
+	if !p.admit(w, req, body, "deregister", input) {
+		return
+	}
+
+	if p.instances != nil {
+		if _, err := p.instances.GetInstance(input.ServiceName, input.InstanceID); err != nil {
+			writeAPIError(w, newAPIError(http.StatusNotFound, "instance_not_found", err.Error()))
+			return
+		}
+	}
+
+	p.addNewServerChannel <- &ProviderEvent{
+		Action:      ActionDeregister,
+		ServiceName: input.ServiceName,
+		InstanceID:  input.InstanceID,
+	}
+
+	p.logger.Info("Service deregistered", "service", input.ServiceName, "id", input.InstanceID)
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]string{
+		"status":      "success",
+		"message":     "Backend deregistered successfully",
+		"serviceName": input.ServiceName,
+		"instanceID":  input.InstanceID,
+	})
+}
+
+func (p *ProviderServer) instanceAction(
+	w http.ResponseWriter,
+	req *http.Request,
+	action string,
+	fn func(service, id string) (*model.Server, error),
+) {
+	if p.instances == nil {
+		writeAPIError(w, newAPIError(http.StatusNotImplemented, "registry_unavailable", "registry is not bound to the provider server"))
+		return
+	}
+
+	input := &model.Input{
+		ServiceName: req.PathValue("service"),
+		InstanceID:  req.PathValue("id"),
+	}
+
+	// thao tac doc trang thai khong can xac thuc
+	if action != "" {
+		body, err := readBody(req)
+		if err != nil {
+			writeAPIError(w, newAPIError(http.StatusBadRequest, "invalid_body", err.Error()))
+			return
+		}
+
+		if !p.admit(w, req, body, action, input) {
+			return
+		}
+	}
+
+	srv, err := fn(input.ServiceName, input.InstanceID)
+	if err != nil {
+		writeAPIError(w, newAPIError(http.StatusNotFound, "instance_not_found", err.Error()))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(NewInstanceStatus(srv))
+}
+
+type weightRequest struct {
+	Weight      *int   `json:"weight"`
+	RevertAfter string `json:"revertAfter,omitempty"`
+}
+
+/*
+*PUT weight: chi admin duoc doi weight, weight = 0 de ngung gui traffic
+ */
+func (p *ProviderServer) weightHandler(w http.ResponseWriter, req *http.Request) {
+	if p.instances == nil {
+		writeAPIError(w, newAPIError(http.StatusNotImplemented, "registry_unavailable", "registry is not bound to the provider server"))
+		return
+	}
+
+	input := &model.Input{
+		ServiceName: req.PathValue("service"),
+		InstanceID:  req.PathValue("id"),
+	}
+
+	if apiErr := p.requireAdmin(req); apiErr != nil {
+		p.audit(req, "weight", input, "anonymous", apiErr)
+		writeAPIError(w, apiErr)
+		return
+	}
+
+	body, err := readBody(req)
+	if err != nil {
+		writeAPIError(w, newAPIError(http.StatusBadRequest, "invalid_body", err.Error()))
+		return
+	}
+
+	var wr weightRequest
+	if err := json.Unmarshal(body, &wr); err != nil || wr.Weight == nil {
+		writeAPIError(w, newAPIError(http.StatusBadRequest, "invalid_json", "body must contain a numeric weight"))
+		return
+	}
+
+	if *wr.Weight < 0 {
+		writeAPIError(w, newAPIError(http.StatusBadRequest, "invalid_weight", "weight must be >= 0"))
+		return
+	}
+
+	var revertAfter time.Duration
+	if wr.RevertAfter != "" {
+		revertAfter, err = time.ParseDuration(wr.RevertAfter)
+		if err != nil || revertAfter <= 0 {
+			writeAPIError(w, newAPIError(http.StatusBadRequest, "invalid_revert_after", "revertAfter must be a positive duration"))
+			return
+		}
+	}
+
+	srv, err := p.instances.SetWeight(input.ServiceName, input.InstanceID, *wr.Weight, revertAfter)
+	if err != nil {
+		writeAPIError(w, newAPIError(http.StatusNotFound, "instance_not_found", err.Error()))
+		return
+	}
+
+	input.Weight = *wr.Weight
+	p.audit(req, "weight", input, "admin", nil)
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(NewInstanceStatus(srv))
+}
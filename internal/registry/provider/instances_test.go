@@ -0,0 +1,22 @@
+package provider
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeregister_Native(t *testing.T) {
+	p := NewProviderServer(quietLogger())
+	h := p.RegisterHTTPHandler()
+
+	rec := doRequest(t, h, http.MethodDelete, "/v1/instances/user-service/user-1", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	event := <-p.GetProviderChannel()
+	assert.Equal(t, ActionDeregister, event.Action)
+	assert.Equal(t, "user-service", event.ServiceName)
+	assert.Equal(t, "user-1", event.InstanceID)
+}
@@ -0,0 +1,61 @@
+package provider
+
+import (
+	"log/slog"
+
+	"github.com/nhutphuongasasa/loadbalancer/internal/config"
+	"github.com/nhutphuongasasa/loadbalancer/internal/registry"
+)
+
+type Option func(*ProviderServer)
+
+/*
+*Ap dung cau hinh xac thuc, allow-list va audit log cho registry
+ */
+func WithRegistryConfig(cfg *config.RegistryConfig) Option {
+	return func(p *ProviderServer) {
+		if cfg == nil {
+			return
+		}
+
+		p.auth = newRegistrationAuth(cfg.Auth)
+		if cfg.Auth != nil {
+			p.adminToken = cfg.Auth.AdminToken
+		}
+		p.policies = newServicePolicies(cfg.Policies)
+		p.auditLogger = newAuditLogger(cfg.AuditLog, p.logger)
+	}
+}
+
+/*
+*Cau hinh circuit breaker va retry cho instance dang ky qua API
+ */
+func WithResilience(cfg *config.ResilienceConfig) Option {
+	return func(p *ProviderServer) {
+		p.resilience = cfg
+	}
+}
+
+/*
+*Cau hinh TLS toi backend theo service, gop voi gia tri tls gui kem luc dang ky
+ */
+func WithUpstreams(u *registry.Upstreams) Option {
+	return func(p *ProviderServer) {
+		p.upstreams = u
+	}
+}
+
+func WithAuditLogger(logger *slog.Logger) Option {
+	return func(p *ProviderServer) {
+		p.auditLogger = logger
+	}
+}
+
+/*
+*Bat endpoint /v1/watch doc su kien tu event hub cua registry
+ */
+func WithEventHub(hub *registry.EventHub) Option {
+	return func(p *ProviderServer) {
+		p.events = hub
+	}
+}
@@ -2,16 +2,39 @@ package provider
 
 import (
 	"encoding/json"
+	"errors"
+	"io"
 	"log/slog"
 	"net"
 	"net/http"
+	"sync"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/nhutphuongasasa/loadbalancer/internal/config"
 	"github.com/nhutphuongasasa/loadbalancer/internal/model"
 	"github.com/nhutphuongasasa/loadbalancer/internal/registry"
 )
 
-type ProviderChannel chan *model.Server
+type EventAction int
+
+const (
+	ActionRegister EventAction = iota
+	ActionDeregister
+	ActionHeartbeat
+)
+
+/*
+*Su kien ma provider gui sang registry qua ProviderChannel
+ */
+type ProviderEvent struct {
+	Action      EventAction
+	Server      *model.Server
+	ServiceName string
+	InstanceID  string
+}
+
+type ProviderChannel chan *ProviderEvent
 
 // type IProviderServer interface {
 // 	RegisterHTTPHandler() http.Handler
@@ -20,20 +43,73 @@ type ProviderChannel chan *model.Server
 
 type ProviderServer struct {
 	logger              *slog.Logger
+	auditLogger         *slog.Logger
 	addNewServerChannel ProviderChannel
+
+	auth       *registrationAuth
+	adminToken string
+	policies   map[string]*servicePolicy
+	events     *registry.EventHub
+	instances  registry.InstanceManager
+	resilience *config.ResilienceConfig
+	upstreams  *registry.Upstreams
+
+	consulMux    sync.RWMutex
+	consulChecks map[string]*consulEntry // checkID -> instance
+	consulIDs    map[string]*consulEntry // serviceID -> instance
 }
 
-func NewProviderServer(logger *slog.Logger) *ProviderServer {
-	return &ProviderServer{
+func NewProviderServer(logger *slog.Logger, opts ...Option) *ProviderServer {
+	p := &ProviderServer{
 		logger:              logger,
 		addNewServerChannel: make(ProviderChannel, 10),
+		policies:            make(map[string]*servicePolicy),
+		consulChecks:        make(map[string]*consulEntry),
+		consulIDs:           make(map[string]*consulEntry),
 	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	if p.auditLogger == nil {
+		p.auditLogger = newAuditLogger("", logger)
+	}
+
+	if p.auth == nil {
+		logger.Warn("Registry authentication is disabled, any client can register services")
+	}
+
+	return p
 }
 
 /*
-*Handler giup thuc hien dnag ky server vao danh sach registry
+*Tra ve mux gom endpoint dang ky goc va lop tuong thich Consul
  */
 func (p *ProviderServer) RegisterHTTPHandler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("PUT /v1/agent/service/register", p.consulRegister)
+	mux.HandleFunc("PUT /v1/agent/service/deregister/{id}", p.consulDeregister)
+	mux.HandleFunc("PUT /v1/agent/check/pass/{id}", p.consulCheckPass)
+
+	mux.HandleFunc("GET /v1/watch", p.watchHandler)
+
+	mux.HandleFunc("GET /v1/instances/{service}/{id}", p.instanceStatusHandler)
+	mux.HandleFunc("DELETE /v1/instances/{service}/{id}", p.deregisterHandler)
+	mux.HandleFunc("POST /v1/instances/{service}/{id}/drain", p.drainHandler)
+	mux.HandleFunc("DELETE /v1/instances/{service}/{id}/drain", p.resumeHandler)
+	mux.HandleFunc("PUT /v1/instances/{service}/{id}/weight", p.weightHandler)
+
+	mux.Handle("/", p.registerHandler())
+
+	return mux
+}
+
+/*
+*Handler giup thuc hien dnag ky server vao danh sach registry
+ */
+func (p *ProviderServer) registerHandler() http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
 		p.logger.Info("new server register")
 		ok, input := p.checkInfo(w, req)
@@ -41,24 +117,13 @@ func (p *ProviderServer) RegisterHTTPHandler() http.Handler {
 			return
 		}
 
-		resilientTP := p.createResilientTransport(
-			input.ServiceName,
-			input.InstanceID,
-			registry.GlobalBaseTransport,
-			p.logger,
-		)
-
-		srv := model.NewServer(
-			input.InstanceID,
-			input.ServiceName,
-			input.Host,
-			input.Port,
-			input.Weight,
-			input.Metadata,
-			resilientTP,
-		)
-
-		p.addNewServerChannel <- srv
+		srv, err := p.buildServer(input, 0)
+		if err != nil {
+			writeAPIError(w, newAPIError(http.StatusBadRequest, "invalid_tls", err.Error()))
+			return
+		}
+
+		p.addNewServerChannel <- &ProviderEvent{Action: ActionRegister, Server: srv}
 
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusCreated)
@@ -70,6 +135,7 @@ func (p *ProviderServer) RegisterHTTPHandler() http.Handler {
 			"host":        srv.Host,
 			"port":        srv.Port,
 			"weight":      srv.Weight,
+			"scheme":      srv.Scheme(),
 			"autoGenerated": map[string]bool{
 				"instanceID": input.InstanceID == "",
 				"host":       input.Host == "",
@@ -80,31 +146,125 @@ func (p *ProviderServer) RegisterHTTPHandler() http.Handler {
 	})
 }
 
+/*
+*Tao doi tuong server tu input da duoc kiem tra, ttl <= 0 thi dung mac dinh.
+*Loi khi cau hinh TLS toi backend khong dung duoc (CA, pin, client cert)
+ */
+func (p *ProviderServer) buildServer(input *model.Input, ttl time.Duration) (*model.Server, error) {
+	opts, err := p.upstreams.ServerOptions(input.ServiceName, input.TLS)
+	if err != nil {
+		return nil, err
+	}
+
+	srv := model.NewServer(
+		input.InstanceID,
+		input.ServiceName,
+		input.Host,
+		input.Port,
+		input.Weight,
+		input.Metadata,
+		nil,
+		opts...,
+	)
+
+	srv.GetProxy().Transport = p.createResilientTransport(srv, p.logger)
+
+	if ttl > 0 {
+		srv.TTL = ttl
+	}
+
+	return srv, nil
+}
+
 func (p *ProviderServer) checkInfo(w http.ResponseWriter, req *http.Request) (bool, *model.Input) {
 	input := &model.Input{}
 
 	if req.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeAPIError(w, newAPIError(http.StatusMethodNotAllowed, "method_not_allowed", "Method not allowed"))
 		return false, nil
 	}
 
-	if err := json.NewDecoder(req.Body).Decode(input); err != nil {
+	body, err := readBody(req)
+	if err != nil {
+		writeAPIError(w, newAPIError(http.StatusBadRequest, "invalid_body", err.Error()))
+		return false, nil
+	}
+
+	if err := json.Unmarshal(body, input); err != nil {
 		p.logger.Error("Invalid JSON body", "err", err)
-		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		writeAPIError(w, newAPIError(http.StatusBadRequest, "invalid_json", "Invalid JSON"))
 		return false, nil
 	}
 
-	if input.ServiceName == "" {
-		http.Error(w, "serviceName is required", http.StatusBadRequest)
+	if err := completeInput(input, req.RemoteAddr); err != nil {
+		writeAPIError(w, newAPIError(http.StatusBadRequest, "invalid_input", err.Error()))
+		return false, nil
+	}
+
+	if !p.admit(w, req, body, "register", input) {
 		return false, nil
 	}
 
+	return true, input
+}
+
+/*
+*Xac thuc va kiem tra allow-list, ghi audit cho ca truong hop chap nhan va tu choi
+ */
+func (p *ProviderServer) admit(w http.ResponseWriter, req *http.Request, body []byte, action string, input *model.Input) bool {
+	principal := "anonymous"
+
+	if p.auth != nil {
+		var apiErr *apiError
+		principal, apiErr = p.auth.authenticate(req, body, input.ServiceName)
+		if apiErr != nil {
+			p.audit(req, action, input, principal, apiErr)
+			writeAPIError(w, apiErr)
+			return false
+		}
+	}
+
+	// chi kiem tra host/port khi dang ky, deregister/heartbeat khong doi dia chi
+	if action == "register" {
+		if policy, ok := p.policies[input.ServiceName]; ok {
+			if apiErr := policy.allow(input.Host, input.Port); apiErr != nil {
+				p.audit(req, action, input, principal, apiErr)
+				writeAPIError(w, apiErr)
+				return false
+			}
+		}
+	}
+
+	// heartbeat khong phai la thay doi dang ky nen khong ghi audit khi thanh cong
+	if action != "heartbeat" {
+		p.audit(req, action, input, principal, nil)
+	}
+	return true
+}
+
+func readBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil {
+		return nil, nil
+	}
+	defer req.Body.Close()
+
+	return io.ReadAll(io.LimitReader(req.Body, maxRegisterBodyBytes))
+}
+
+/*
+*Kiem tra cac truong bat buoc va dien gia tri mac dinh cho input
+ */
+func completeInput(input *model.Input, remoteAddr string) error {
+	if input.ServiceName == "" {
+		return errors.New("serviceName is required")
+	}
+
 	if input.InstanceID == "" {
 		input.InstanceID = uuid.New().String()
 	}
 
 	if input.Host == "" {
-		host, _, _ := net.SplitHostPort(req.RemoteAddr)
+		host, _, _ := net.SplitHostPort(remoteAddr)
 		if host == "" || host == "::1" || host == "127.0.0.1" {
 			host = "localhost"
 		}
@@ -112,15 +272,14 @@ func (p *ProviderServer) checkInfo(w http.ResponseWriter, req *http.Request) (bo
 	}
 
 	if input.Port <= 0 {
-		http.Error(w, "port is required", http.StatusBadRequest)
-		input.Port = 8080
+		return errors.New("port is required")
 	}
 
 	if input.Weight <= 0 {
 		input.Weight = 10
 	}
 
-	return true, input
+	return nil
 }
 
 func (p *ProviderServer) GetProviderChannel() ProviderChannel {
@@ -6,39 +6,18 @@ import (
 
 	"log/slog"
 
-	"github.com/nhutphuongasasa/loadbalancer/internal/resilience"
+	"github.com/nhutphuongasasa/loadbalancer/internal/model"
+	"github.com/nhutphuongasasa/loadbalancer/internal/registry"
 )
 
 /*
 *Khoi tao http.RoundTripper voi retry circuit breaker
  */
 func (p *ProviderServer) createResilientTransport(
-	serviceName, instanceID string,
-	baseTransport http.RoundTripper,
+	srv *model.Server,
 	logger *slog.Logger,
 ) http.RoundTripper {
-	breakerName := fmt.Sprintf("cb-%s-%s", serviceName, instanceID)
+	breakerName := fmt.Sprintf("cb-%s-%s", srv.ServiceName, srv.InstanceID)
 
-	breaker := resilience.NewSonyGoBreaker(
-		breakerName,
-		defaultMaxFailures,
-		defaultTimeout,
-		defaultInterval,
-		logger,
-	)
-
-	retryPol := resilience.NewExponentialRetry(
-		defaultMaxRetries,
-		defaultBaseDelay,
-		defaultMaxDelay,
-		defaultJitterFactor,
-		logger,
-	)
-
-	return resilience.NewResilientTransport(
-		baseTransport,
-		breaker,
-		retryPol,
-		logger,
-	)
+	return registry.NewResilientTransport(breakerName, srv, p.resilience, logger)
 }
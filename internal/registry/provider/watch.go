@@ -0,0 +1,167 @@
+package provider
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nhutphuongasasa/loadbalancer/internal/registry"
+)
+
+const (
+	defaultWatchWait  = 30 * time.Second
+	maxWatchWait      = 5 * time.Minute
+	sseKeepAlive      = 15 * time.Second
+	headerWatchIndex  = "X-LB-Index"
+	headerLastEventID = "Last-Event-ID"
+)
+
+type watchResponse struct {
+	Index     uint64           `json:"index"`
+	Truncated bool             `json:"truncated"`
+	Events    []registry.Event `json:"events"`
+}
+
+/*
+*GET /v1/watch: SSE khi client gui Accept: text/event-stream, nguoc lai la long-poll
+ */
+func (p *ProviderServer) watchHandler(w http.ResponseWriter, req *http.Request) {
+	if p.events == nil {
+		writeAPIError(w, newAPIError(http.StatusNotImplemented, "watch_disabled", "registry event stream is not enabled"))
+		return
+	}
+
+	index, err := watchIndex(req)
+	if err != nil {
+		writeAPIError(w, newAPIError(http.StatusBadRequest, "invalid_index", err.Error()))
+		return
+	}
+
+	service := req.URL.Query().Get("service")
+
+	if strings.Contains(req.Header.Get("Accept"), "text/event-stream") {
+		p.streamEvents(w, req, index, service)
+		return
+	}
+
+	wait := defaultWatchWait
+	if raw := req.URL.Query().Get("wait"); raw != "" {
+		wait, err = time.ParseDuration(raw)
+		if err != nil || wait < 0 {
+			writeAPIError(w, newAPIError(http.StatusBadRequest, "invalid_wait", "wait must be a positive duration"))
+			return
+		}
+	}
+	if wait > maxWatchWait {
+		wait = maxWatchWait
+	}
+
+	p.longPollEvents(w, req, index, service, wait)
+}
+
+/*
+*Giu request den khi co su kien moi hon index hoac het thoi gian cho
+ */
+func (p *ProviderServer) longPollEvents(w http.ResponseWriter, req *http.Request, index uint64, service string, wait time.Duration) {
+	// WriteTimeout cua registry server ngan hon thoi gian cho
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Now().Add(wait + 10*time.Second))
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	for {
+		changed := p.events.Changed()
+		events, last, truncated := p.events.Since(index, service)
+
+		if len(events) > 0 || truncated {
+			writeWatchResponse(w, &watchResponse{Index: last, Truncated: truncated, Events: events})
+			return
+		}
+
+		select {
+		case <-changed:
+		case <-timer.C:
+			writeWatchResponse(w, &watchResponse{Index: last, Events: []registry.Event{}})
+			return
+		case <-req.Context().Done():
+			return
+		}
+	}
+}
+
+func writeWatchResponse(w http.ResponseWriter, resp *watchResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set(headerWatchIndex, strconv.FormatUint(resp.Index, 10))
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+/*
+*Gui su kien lien tuc theo dinh dang Server-Sent Events, id = index de resume bang Last-Event-ID
+ */
+func (p *ProviderServer) streamEvents(w http.ResponseWriter, req *http.Request, index uint64, service string) {
+	rc := http.NewResponseController(w)
+	_ = rc.SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	keepAlive := time.NewTicker(sseKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		changed := p.events.Changed()
+		events, last, truncated := p.events.Since(index, service)
+
+		if truncated {
+			// client da bo lo su kien, bao client tai lai toan bo trang thai
+			fmt.Fprintf(w, "event: reset\ndata: {\"index\":%d}\n\n", last)
+		}
+
+		for _, ev := range events {
+			data, _ := json.Marshal(ev)
+			fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", ev.Index, ev.Type, data)
+		}
+
+		if len(events) > 0 || truncated {
+			if err := rc.Flush(); err != nil {
+				return
+			}
+		}
+		index = last
+
+		select {
+		case <-changed:
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+			if err := rc.Flush(); err != nil {
+				return
+			}
+		case <-req.Context().Done():
+			return
+		}
+	}
+}
+
+/*
+*Lay index bat dau tu query ?index= hoac header Last-Event-ID cua SSE
+ */
+func watchIndex(req *http.Request) (uint64, error) {
+	raw := req.URL.Query().Get("index")
+	if raw == "" {
+		raw = req.Header.Get(headerLastEventID)
+	}
+	if raw == "" {
+		return 0, nil
+	}
+
+	index, err := strconv.ParseUint(raw, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("index must be a non-negative integer")
+	}
+	return index, nil
+}
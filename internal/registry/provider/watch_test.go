@@ -0,0 +1,56 @@
+package provider
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/nhutphuongasasa/loadbalancer/internal/model"
+	"github.com/nhutphuongasasa/loadbalancer/internal/registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatch_LongPollBlocksUntilEvent(t *testing.T) {
+	hub := registry.NewEventHub(0)
+	p := NewProviderServer(quietLogger(), WithEventHub(hub))
+	h := p.RegisterHTTPHandler()
+
+	hub.Publish(registry.EventRegister, model.NewServer("a-1", "svc", "10.0.0.1", 80, 1, nil, nil))
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		hub.Publish(registry.EventHealthChange, model.NewServer("a-1", "svc", "10.0.0.1", 80, 1, nil, nil))
+	}()
+
+	rec := doRequest(t, h, http.MethodGet, "/v1/watch?index=1&wait=2s", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "2", rec.Header().Get("X-LB-Index"))
+
+	var resp watchResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+	require.Len(t, resp.Events, 1)
+	assert.Equal(t, registry.EventHealthChange, resp.Events[0].Type)
+}
+
+func TestWatch_LongPollTimeout(t *testing.T) {
+	p := NewProviderServer(quietLogger(), WithEventHub(registry.NewEventHub(0)))
+
+	rec := doRequest(t, p.RegisterHTTPHandler(), http.MethodGet, "/v1/watch?wait=10ms", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var resp watchResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+	assert.Empty(t, resp.Events)
+	assert.Equal(t, uint64(0), resp.Index)
+}
+
+func TestWatch_Disabled(t *testing.T) {
+	p := NewProviderServer(quietLogger())
+
+	rec := httptest.NewRecorder()
+	p.RegisterHTTPHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/watch", nil))
+	assert.Equal(t, http.StatusNotImplemented, rec.Code)
+}
@@ -19,6 +19,16 @@ type Registry interface {
 	GetUpdateChan() <-chan *model.Server
 }
 
+/*
+*Cac thao tac quan tri tren 1 instance dang chay, dung boi registry API
+ */
+type InstanceManager interface {
+	GetInstance(serviceName, instanceID string) (*model.Server, error)
+	Drain(serviceName, instanceID string) (*model.Server, error)
+	Resume(serviceName, instanceID string) (*model.Server, error)
+	SetWeight(serviceName, instanceID string, weight int, revertAfter time.Duration) (*model.Server, error)
+}
+
 var GlobalBaseTransport = &http.Transport{
 	MaxIdleConns:          100,
 	MaxIdleConnsPerHost:   10,
@@ -0,0 +1,44 @@
+package registry
+
+import (
+	"log/slog"
+	"sync"
+
+	"github.com/nhutphuongasasa/loadbalancer/internal/config"
+	"github.com/nhutphuongasasa/loadbalancer/internal/model"
+	"github.com/nhutphuongasasa/loadbalancer/internal/resilience"
+)
+
+/*
+*Tao transport co circuit breaker va retry cho 1 instance theo section resilience cua config.
+*cfg nil hoac thieu muc nao thi dung gia tri mac dinh cua schema, transport goc theo TLS va giao thuc cua srv
+ */
+func NewResilientTransport(name string, srv *model.Server, cfg *config.ResilienceConfig, logger *slog.Logger) *resilience.ResilientTransport {
+	cb, rt := resilienceSettings(cfg)
+
+	breaker := resilience.NewSonyGoBreaker(name, cb.MaxFailures, cb.Timeout, cb.Interval, logger)
+	retryPol := resilience.NewExponentialRetry(rt.MaxRetries, rt.BaseDelay, rt.MaxDelay, rt.Jitter, logger)
+
+	return resilience.NewResilientTransport(BaseTransport(srv), breaker, retryPol, logger, resilience.WithMaxRetryBody(rt.MaxBody))
+}
+
+// tinh 1 lan, moi instance moi deu build transport
+var defaultResilience = sync.OnceValue(func() *config.ResilienceConfig {
+	return config.Defaults().Resilience
+})
+
+func resilienceSettings(cfg *config.ResilienceConfig) (*config.CircuitBreakerConfig, *config.RetryConfig) {
+	def := defaultResilience()
+	if cfg == nil {
+		return def.CircuitBreaker, def.Retry
+	}
+
+	cb, rt := cfg.CircuitBreaker, cfg.Retry
+	if cb == nil {
+		cb = def.CircuitBreaker
+	}
+	if rt == nil {
+		rt = def.Retry
+	}
+	return cb, rt
+}
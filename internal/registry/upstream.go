@@ -0,0 +1,170 @@
+package registry
+
+import (
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/nhutphuongasasa/loadbalancer/internal/config"
+	"github.com/nhutphuongasasa/loadbalancer/internal/model"
+	lbtls "github.com/nhutphuongasasa/loadbalancer/internal/tls"
+)
+
+const (
+	upstreamPingInterval = 30 * time.Second
+	upstreamPingTimeout  = 15 * time.Second
+)
+
+/*
+*Cau hinh ket noi toi backend theo service. Config upstreams uu tien,
+*gia tri gui kem luc dang ky chi bo sung phan config de trong
+ */
+type Upstreams struct {
+	mu       sync.RWMutex
+	baseDir  string // thu muc goc cho duong dan file tuong doi (tls.cert_dir)
+	services map[string]*config.UpstreamConfig
+	tcp      map[string]bool // service sau listener tcp
+}
+
+func NewUpstreams(baseDir string, cfgs []*config.UpstreamConfig) *Upstreams {
+	u := &Upstreams{baseDir: baseDir}
+	u.Set(cfgs)
+	return u
+}
+
+/*
+*Thay cau hinh khi config doi, chi ap dung cho instance dang ky sau do
+ */
+func (u *Upstreams) Set(cfgs []*config.UpstreamConfig) {
+	services := make(map[string]*config.UpstreamConfig, len(cfgs))
+	for _, c := range cfgs {
+		if tlsOf(c) != nil || !isDefaultProtocol(c.Protocol) || c.MaxConnsPerHost > 0 {
+			services[c.Service] = c
+		}
+	}
+
+	u.mu.Lock()
+	u.services = services
+	u.mu.Unlock()
+}
+
+func tlsOf(c *config.UpstreamConfig) *config.UpstreamTLSConfig {
+	if c == nil || c.TLS == nil || !c.TLS.Enabled {
+		return nil
+	}
+	return c.TLS
+}
+
+func isDefaultProtocol(protocol string) bool {
+	return protocol == "" || protocol == config.UpstreamProtocolAuto
+}
+
+/*
+*Service nhan ket noi tu listener tcp, instance dang ky sau do duoc health check bang TCP connect
+ */
+func (u *Upstreams) SetTCPServices(listeners []*config.TCPListenerConfig) {
+	tcp := make(map[string]bool, len(listeners))
+	for _, l := range listeners {
+		tcp[l.Service] = true
+	}
+
+	u.mu.Lock()
+	u.tcp = tcp
+	u.mu.Unlock()
+}
+
+func (u *Upstreams) IsTCPService(service string) bool {
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+	return u.tcp[service]
+}
+
+/*
+*Option cho model.NewServer theo service va gia tri luc dang ky (spec co the nil).
+*Tra ve nil khi backend dung HTTP/1.1 khong gioi han ket noi. u nil thi chi dung spec
+ */
+func (u *Upstreams) ServerOptions(service string, spec *model.UpstreamTLS) ([]model.ServerOption, error) {
+	var svc *config.UpstreamConfig
+	var tcp bool
+	if u != nil {
+		u.mu.RLock()
+		svc, tcp = u.services[service], u.tcp[service]
+		u.mu.RUnlock()
+	}
+
+	// byte duoc chuyen nguyen, TLS va giao thuc HTTP do client va backend tu lo
+	if tcp {
+		return []model.ServerOption{model.WithTCP()}, nil
+	}
+
+	var serverOpts []model.ServerOption
+	if svc != nil && (!isDefaultProtocol(svc.Protocol) || svc.MaxConnsPerHost > 0) {
+		serverOpts = append(serverOpts, model.WithUpstreamProtocol(svc.Protocol, svc.MaxConnsPerHost))
+	}
+
+	cfg := tlsOf(svc)
+	if cfg == nil && (spec == nil || !spec.Enabled) {
+		return serverOpts, nil
+	}
+
+	var opts lbtls.UpstreamOptions
+	if spec != nil && spec.Enabled {
+		opts.ServerName = spec.ServerName
+		opts.CAPEM = []byte(spec.CAPEM)
+		opts.Pins = spec.Pins
+	}
+	if cfg != nil {
+		if cfg.ServerName != "" {
+			opts.ServerName = cfg.ServerName
+		}
+		if len(cfg.PinnedSHA256) > 0 {
+			opts.Pins = cfg.PinnedSHA256
+		}
+		opts.CAFile = u.path(cfg.CAFile)
+		opts.CertFile = u.path(cfg.CertFile)
+		opts.KeyFile = u.path(cfg.KeyFile)
+		opts.InsecureSkipVerify = cfg.InsecureSkipVerify
+	}
+
+	tlsCfg, err := lbtls.UpstreamClientConfig(opts)
+	if err != nil {
+		return nil, fmt.Errorf("upstream TLS for service %s: %w", service, err)
+	}
+	return append(serverOpts, model.WithUpstreamTLS(spec, tlsCfg)), nil
+}
+
+func (u *Upstreams) path(file string) string {
+	if file == "" || filepath.IsAbs(file) {
+		return file
+	}
+	return filepath.Join(u.baseDir, file)
+}
+
+/*
+*Transport goc cho 1 backend: HTTP/1.1 khong gioi han dung chung GlobalBaseTransport.
+*Backend HTTPS, HTTP/2 hoac co gioi han ket noi can transport (va pool ket noi) rieng
+ */
+func BaseTransport(srv *model.Server) http.RoundTripper {
+	tlsCfg := srv.TLSConfig()
+	if tlsCfg == nil && !srv.Multiplexed() && srv.MaxConnsPerHost() == 0 {
+		return GlobalBaseTransport
+	}
+
+	t := GlobalBaseTransport.Clone()
+	t.TLSClientConfig = tlsCfg
+	t.Protocols = srv.Protocols()
+	t.MaxConnsPerHost = srv.MaxConnsPerHost()
+
+	if srv.Multiplexed() {
+		// 1 ket noi HTTP/2 chiu nhieu stream, ping dinh ky de phat hien ket noi chet
+		// thay vi de request treo tren ket noi da mat
+		t.MaxIdleConnsPerHost = 2
+		t.HTTP2 = &http.HTTP2Config{
+			SendPingTimeout: upstreamPingInterval,
+			PingTimeout:     upstreamPingTimeout,
+		}
+	}
+	return t
+}
@@ -0,0 +1,54 @@
+package registry
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/nhutphuongasasa/loadbalancer/internal/config"
+	"github.com/nhutphuongasasa/loadbalancer/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBaseTransport_H2CUpstream(t *testing.T) {
+	backend := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, r.Proto)
+	}))
+	// backend chi noi HTTP/2 cleartext, request HTTP/1.1 bi tu choi
+	backend.Config.Protocols = new(http.Protocols)
+	backend.Config.Protocols.SetUnencryptedHTTP2(true)
+	backend.Start()
+	defer backend.Close()
+
+	host, portStr, err := net.SplitHostPort(backend.Listener.Addr().String())
+	require.NoError(t, err)
+	port, _ := strconv.Atoi(portStr)
+
+	u := NewUpstreams("", []*config.UpstreamConfig{{Service: "grpc", Protocol: config.UpstreamProtocolH2C, MaxConnsPerHost: 1}})
+	opts, err := u.ServerOptions("grpc", nil)
+	require.NoError(t, err)
+
+	srv := model.NewServer("grpc-1", "grpc", host, port, 1, nil, nil, opts...)
+	assert.Equal(t, "h2c", srv.Protocol())
+	assert.True(t, srv.Multiplexed())
+
+	transport := BaseTransport(srv)
+	require.NotSame(t, GlobalBaseTransport, transport)
+
+	req, err := http.NewRequest(http.MethodGet, srv.GetAddr(), nil)
+	require.NoError(t, err)
+	resp, err := transport.RoundTrip(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, "HTTP/2.0", string(body))
+
+	// service khong cau hinh gi dung chung transport HTTP/1.1
+	plain := model.NewServer("web-1", "web", host, port, 1, nil, nil)
+	assert.Same(t, GlobalBaseTransport, BaseTransport(plain))
+}
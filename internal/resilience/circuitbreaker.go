@@ -11,6 +11,8 @@ import (
 type CircuitBreaker interface {
 	Execute(fn func() (interface{}, error)) (interface{}, error)
 	State() gobreaker.State
+	Name() string
+	Counts() gobreaker.Counts
 }
 
 type sonyGoBreaker struct {
@@ -58,3 +60,11 @@ func (b *sonyGoBreaker) Execute(fn func() (interface{}, error)) (interface{}, er
 func (b *sonyGoBreaker) State() gobreaker.State {
 	return b.cb.State()
 }
+
+func (b *sonyGoBreaker) Name() string {
+	return b.cb.Name()
+}
+
+func (b *sonyGoBreaker) Counts() gobreaker.Counts {
+	return b.cb.Counts()
+}
@@ -0,0 +1,110 @@
+package resilience
+
+import (
+	"errors"
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+var errBodyNotReplayable = errors.New("request body is no longer replayable")
+
+/*
+*Body khong biet do dai (gRPC) van chay thang toi backend, nhung toi da max byte dau
+*duoc ghi lai. Lan gui lai doc phan da ghi roi doc tiep tu body goc, nen khong phai
+*doc truoc ca body (stream 2 chieu se treo) ma van retry duoc call unary
+ */
+type replayBody struct {
+	src io.Reader
+	max int64
+
+	mu       sync.Mutex
+	buf      []byte
+	total    int64 // so byte da doc tu src
+	overflow bool  // vuot max hoac da xong, khong gui lai duoc nua
+	err      error // loi cuoi cua src, ke ca io.EOF
+}
+
+func newReplayBody(src io.Reader, max int64) *replayBody {
+	return &replayBody{src: src, max: max}
+}
+
+/*
+*Body cho 1 lan gui, doc tu dau
+ */
+func (b *replayBody) reader() *replayReader {
+	return &replayReader{body: b}
+}
+
+func (b *replayBody) replayable() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return !b.overflow
+}
+
+/*
+*Lan gui da thanh cong, bo phan da ghi de khong giu bo nho suot stream
+ */
+func (b *replayBody) release() {
+	b.mu.Lock()
+	b.overflow, b.buf = true, nil
+	b.mu.Unlock()
+}
+
+type replayReader struct {
+	body     *replayBody
+	off      int64
+	detached atomic.Bool // transport da dong body cua lan gui nay
+}
+
+func (r *replayReader) Read(p []byte) (int, error) {
+	if r.detached.Load() {
+		return 0, io.ErrClosedPipe
+	}
+
+	b := r.body
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if r.off < b.total {
+		if b.overflow {
+			return 0, errBodyNotReplayable
+		}
+		n := copy(p, b.buf[r.off:])
+		r.off += int64(n)
+		return n, nil
+	}
+	if b.err != nil {
+		return 0, b.err
+	}
+
+	n, err := b.src.Read(p)
+	if n > 0 {
+		b.total += int64(n)
+		if !b.overflow {
+			if b.total > b.max {
+				b.overflow, b.buf = true, nil
+			} else {
+				b.buf = append(b.buf, p[:n]...)
+			}
+		}
+		r.off += int64(n)
+	}
+	if err != nil {
+		b.err = err
+	}
+
+	// lan gui da bi bo trong luc cho src, du lieu vua doc nam trong buf cho lan sau
+	if r.detached.Load() {
+		return 0, io.ErrClosedPipe
+	}
+	return n, err
+}
+
+/*
+*Body goc do http.Server dong khi handler ket thuc, o day chi ngat lan gui nay
+ */
+func (r *replayReader) Close() error {
+	r.detached.Store(true)
+	return nil
+}
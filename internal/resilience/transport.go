@@ -7,13 +7,34 @@ import (
 	"net/http"
 
 	"log/slog"
+
+	"github.com/nhutphuongasasa/loadbalancer/internal/grpc"
+	"github.com/nhutphuongasasa/loadbalancer/internal/httputil"
 )
 
+// body lon hon muc nay khong duoc doc vao bo nho de retry
+const defaultMaxRetryBody = 1 << 20
+
 type ResilientTransport struct {
-	base        http.RoundTripper
-	breaker     CircuitBreaker
-	retryPolicy RetryPolicy
-	logger      *slog.Logger
+	base         http.RoundTripper
+	breaker      CircuitBreaker
+	retryPolicy  RetryPolicy
+	logger       *slog.Logger
+	maxRetryBody int64
+}
+
+type TransportOption func(*ResilientTransport)
+
+/*
+*Request co body lon hon n byte (hoac khong biet do dai) chi gui 1 lan, khong buffer.
+*Rieng gRPC khong biet do dai: n byte dau duoc ghi lai trong luc gui de retry
+ */
+func WithMaxRetryBody(n int64) TransportOption {
+	return func(t *ResilientTransport) {
+		if n > 0 {
+			t.maxRetryBody = n
+		}
+	}
 }
 
 func NewResilientTransport(
@@ -21,6 +42,7 @@ func NewResilientTransport(
 	breaker CircuitBreaker,
 	retryPolicy RetryPolicy,
 	logger *slog.Logger,
+	opts ...TransportOption,
 ) *ResilientTransport {
 	if logger == nil {
 		logger = slog.Default()
@@ -29,33 +51,52 @@ func NewResilientTransport(
 		base = http.DefaultTransport
 	}
 
-	return &ResilientTransport{
-		base:        base,
-		breaker:     breaker,
-		retryPolicy: retryPolicy,
-		logger:      logger,
+	t := &ResilientTransport{
+		base:         base,
+		breaker:      breaker,
+		retryPolicy:  retryPolicy,
+		logger:       logger,
+		maxRetryBody: defaultMaxRetryBody,
+	}
+	for _, opt := range opts {
+		opt(t)
 	}
+	return t
 }
 
 func (t *ResilientTransport) RoundTrip(req *http.Request) (*http.Response, error) {
-	var bodyBytes []byte
-	if req.Body != nil {
-		var err error
-		bodyBytes, err = io.ReadAll(req.Body)
+	var body requestBody
+	switch {
+	case httputil.IsUpgrade(req):
+		return t.roundTripOnce(req)
+	case req.Body == nil || req.Body == http.NoBody:
+		body = bufferedBody(nil)
+	case req.ContentLength >= 0 && req.ContentLength <= t.maxRetryBody:
+		bodyBytes, err := io.ReadAll(req.Body)
 		if err != nil {
 			return nil, err
 		}
-		req.Body = io.NopCloser(bytes.NewBuffer(bodyBytes))
+		body = bufferedBody(bodyBytes)
+	case req.ContentLength < 0 && grpc.IsGRPC(req.Header.Get("Content-Type")):
+		// client gRPC qua HTTP/2 khong gui content-length, call unary van phai retry duoc UNAVAILABLE
+		body = &replayedBody{replayBody: newReplayBody(req.Body, t.maxRetryBody)}
+	default:
+		return t.roundTripOnce(req)
 	}
 
 	var resp *http.Response
+	var stopped error
+	attempt := 0
 
 	err := t.retryPolicy.Do(func() error {
+		if attempt++; attempt > 1 && !body.replayable() {
+			stopped = errBodyNotReplayable
+			return nil
+		}
+
 		_, cbErr := t.breaker.Execute(func() (interface{}, error) {
 			cloneReq := req.Clone(req.Context())
-			if len(bodyBytes) > 0 {
-				cloneReq.Body = io.NopCloser(bytes.NewBuffer(bodyBytes))
-			}
+			cloneReq.Body = body.next()
 
 			var rErr error
 			resp, rErr = t.base.RoundTrip(cloneReq)
@@ -63,10 +104,10 @@ func (t *ResilientTransport) RoundTrip(req *http.Request) (*http.Response, error
 				return nil, rErr
 			}
 
-			if resp.StatusCode >= 500 {
+			if failure := failureOf(resp); failure != nil {
 				_ = resp.Body.Close()
 				resp = nil
-				return nil, fmt.Errorf("backend error status: %d", resp.StatusCode)
+				return nil, failure
 			}
 
 			return resp, nil
@@ -82,9 +123,92 @@ func (t *ResilientTransport) RoundTrip(req *http.Request) (*http.Response, error
 		return nil
 	})
 
+	if stopped != nil {
+		return nil, stopped
+	}
 	if err != nil {
 		return nil, err
 	}
 
+	body.release()
 	return resp, nil
 }
+
+/*
+*Body cho tung lan gui cua 1 request duoc retry
+ */
+type requestBody interface {
+	next() io.ReadCloser
+	replayable() bool
+	release()
+}
+
+type bufferedBody []byte
+
+func (b bufferedBody) next() io.ReadCloser {
+	if len(b) == 0 {
+		return http.NoBody
+	}
+	return io.NopCloser(bytes.NewReader(b))
+}
+
+func (b bufferedBody) replayable() bool { return true }
+
+func (b bufferedBody) release() {}
+
+type replayedBody struct {
+	*replayBody
+}
+
+func (b *replayedBody) next() io.ReadCloser {
+	return b.reader()
+}
+
+/*
+*Gui 1 lan qua circuit breaker, body chay thang toi backend. Phan hoi loi van tra ve
+*cho client nhu backend gui nhung duoc dem la that bai
+ */
+func (t *ResilientTransport) roundTripOnce(req *http.Request) (*http.Response, error) {
+	result, err := t.breaker.Execute(func() (interface{}, error) {
+		resp, err := t.base.RoundTrip(req)
+		if err != nil {
+			return nil, err
+		}
+		return resp, failureOf(resp)
+	})
+
+	if resp, ok := result.(*http.Response); ok && resp != nil {
+		return resp, nil
+	}
+	if err != nil {
+		t.logger.Warn("Streaming request failed",
+			slog.String("error", err.Error()),
+			slog.String("state", t.breaker.State().String()),
+		)
+	}
+	return nil, err
+}
+
+/*
+*Phan hoi tinh la that bai (retry va dem vao circuit breaker): HTTP 5xx hoac
+*gRPC trailers-only co ma retry duoc (UNAVAILABLE). Loi gRPC nam trong trailer
+*chi biet sau khi body da gui cho client nen khong retry. Chi request replayable moi duoc retry
+ */
+func failureOf(resp *http.Response) error {
+	if resp.StatusCode >= 500 {
+		return fmt.Errorf("backend error status: %d", resp.StatusCode)
+	}
+	if grpc.IsResponse(resp) {
+		if code, ok := grpc.StatusOf(resp.Header); ok && grpc.Retryable(code) {
+			return fmt.Errorf("backend grpc status: %s", code)
+		}
+	}
+	return nil
+}
+
+/*
+*Circuit breaker cua transport, dung de xem trang thai tu admin API
+ */
+func (t *ResilientTransport) Breaker() CircuitBreaker {
+	return t.breaker
+}
@@ -0,0 +1,153 @@
+package resilience
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nhutphuongasasa/loadbalancer/internal/grpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResilientTransport_RetriesGRPCUnavailable(t *testing.T) {
+	// 1 message gRPC: co nen (1 byte), do dai (4 byte big-endian), noi dung
+	msg := []byte{0, 0, 0, 0, 5, 'h', 'e', 'l', 'l', 'o'}
+
+	var calls atomic.Int32
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// moi lan gui lai deu nhan du message
+		body, _ := io.ReadAll(r.Body)
+		assert.Equal(t, msg, body)
+
+		switch calls.Add(1) {
+		case 1:
+			grpc.WriteError(w, grpc.Unavailable, "starting")
+		default:
+			// loi khong retry duoc duoc tra ve nguyen cho client
+			grpc.WriteError(w, grpc.PermissionDenied, "denied")
+		}
+	}))
+	defer backend.Close()
+
+	transport := NewResilientTransport(
+		nil,
+		NewSonyGoBreaker("grpc-test", 5, time.Second, time.Second, nil),
+		NewExponentialRetry(2, time.Millisecond, 5*time.Millisecond, 0, nil),
+		nil,
+	)
+
+	// client gRPC qua HTTP/2 khong gui content-length
+	req, err := http.NewRequest(http.MethodPost, backend.URL+"/pkg.Svc/Call", io.NopCloser(bytes.NewReader(msg)))
+	require.NoError(t, err)
+	req.ContentLength = -1
+	req.Header.Set("Content-Type", "application/grpc")
+
+	resp, err := transport.RoundTrip(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	code, ok := grpc.StatusOf(resp.Header)
+	assert.True(t, ok)
+	assert.Equal(t, grpc.PermissionDenied, code)
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestResilientTransport_GRPCBodyOverLimitNotRetried(t *testing.T) {
+	var calls atomic.Int32
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		io.Copy(io.Discard, r.Body)
+		grpc.WriteError(w, grpc.Unavailable, "starting")
+	}))
+	defer backend.Close()
+
+	req, err := http.NewRequest(http.MethodPost, backend.URL+"/pkg.Svc/Upload", io.NopCloser(strings.NewReader(strings.Repeat("a", 32))))
+	require.NoError(t, err)
+	req.ContentLength = -1
+	req.Header.Set("Content-Type", "application/grpc")
+
+	// 32 byte vuot gioi han 8 byte nen khong ghi lai duoc de gui lai
+	_, err = newTestTransport().RoundTrip(req)
+	assert.ErrorIs(t, err, errBodyNotReplayable)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func newTestTransport() *ResilientTransport {
+	return NewResilientTransport(
+		nil,
+		NewSonyGoBreaker("stream-test", 5, time.Second, time.Second, nil),
+		NewExponentialRetry(2, time.Millisecond, 5*time.Millisecond, 0, nil),
+		nil,
+		WithMaxRetryBody(8),
+	)
+}
+
+func TestResilientTransport_StreamsLargeBodyOnce(t *testing.T) {
+	var calls atomic.Int32
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		body, _ := io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusServiceUnavailable)
+		io.WriteString(w, strings.ToUpper(string(body)))
+	}))
+	defer backend.Close()
+
+	transport := newTestTransport()
+	for _, length := range []int64{32, -1} {
+		calls.Store(0)
+		req, err := http.NewRequest(http.MethodPost, backend.URL, io.NopCloser(strings.NewReader(strings.Repeat("a", 32))))
+		require.NoError(t, err)
+		req.ContentLength = length
+
+		// khong retry, phan hoi loi cua backend duoc tra nguyen
+		resp, err := transport.RoundTrip(req)
+		require.NoError(t, err)
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+		assert.Equal(t, strings.Repeat("A", 32), string(body))
+		assert.Equal(t, int32(1), calls.Load())
+	}
+}
+
+func TestResilientTransport_UpgradePassesThrough(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, brw, err := http.NewResponseController(w).Hijack()
+		require.NoError(t, err)
+		defer conn.Close()
+
+		brw.WriteString("HTTP/1.1 101 Switching Protocols\r\nConnection: Upgrade\r\nUpgrade: echo\r\n\r\n")
+		brw.Flush()
+		line, _ := brw.ReadString('\n')
+		brw.WriteString(line)
+		brw.Flush()
+	}))
+	defer backend.Close()
+
+	req, err := http.NewRequest(http.MethodGet, backend.URL, nil)
+	require.NoError(t, err)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "echo")
+
+	resp, err := newTestTransport().RoundTrip(req)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusSwitchingProtocols, resp.StatusCode)
+
+	conn, ok := resp.Body.(io.ReadWriteCloser)
+	require.True(t, ok)
+	defer conn.Close()
+
+	_, err = io.WriteString(conn, "ping\n")
+	require.NoError(t, err)
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	require.NoError(t, err)
+	assert.Equal(t, "ping\n", line)
+}
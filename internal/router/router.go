@@ -5,50 +5,79 @@ import (
 	"path/filepath"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"log/slog"
 
 	"github.com/fsnotify/fsnotify"
+	"github.com/nhutphuongasasa/loadbalancer/internal/config"
 	"github.com/spf13/viper"
 )
 
-type RouteRule struct {
-	Prefix      string `mapstructure:"prefix"`
-	Service     string `mapstructure:"service_name"`
-	StripPrefix bool   `mapstructure:"strip_prefix,omitempty"`
-}
+const routingSection = "routing"
 
-type RoutingConfig struct {
-	Rules          []RouteRule `mapstructure:"rules"`
-	DefaultService string      `mapstructure:"default_service,omitempty"`
-}
+// Kieu routing nam trong schema config chung
+type (
+	RouteRule     = config.RouteRule
+	RoutingConfig = config.RoutingConfig
+)
 
 type PathRouter struct {
 	mu           sync.RWMutex
 	rules        []RouteRule
 	defaultSvc   string
 	logger       *slog.Logger
-	viper        *viper.Viper
+	viper        *viper.Viper // chi dung de watch file
 	configPath   string
 	lastReload   time.Time
-	reloadErrors uint64 // de export metric
+	reloadErrors atomic.Uint64 // de export metric, doc trong Status khong qua reloadMu
 	initialized  bool
+	tcp          *config.TCPConfig // service cua listener tcp khong duoc route HTTP
+
+	reloadMu    sync.Mutex // watcher va Reload co the chay dong thoi
+	history     *config.History
+	subscribers []func([]RouteRule) // goi sau moi lan ap dung rule moi, giu reloadMu nen theo dung thu tu
+
+	hits        map[string]*atomic.Uint64 // so request match theo prefix, giu qua cac lan reload
+	defaultHits atomic.Uint64
 }
 
 // Nhan vao config path doc config, validate, apply config
 func NewPathRouter(baseConfigDir string, logger *slog.Logger) (*PathRouter, error) {
+	return NewPathRouterFromFile(filepath.Join(baseConfigDir, "config", "routing.yml"), logger)
+}
+
+// Giong NewPathRouter nhung nhan duong dan file routing truc tiep
+func NewPathRouterFromFile(configPath string, logger *slog.Logger) (*PathRouter, error) {
+	pr, err := LoadPathRouter(configPath, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	// Watch config với debounce
+	go pr.watchConfigWithDebounce()
+
+	pr.logger.Info("PathRouter initialized",
+		slog.String("config_path", configPath),
+		slog.String("default_service", pr.defaultSvc),
+		slog.Int("initial_rules", len(pr.rules)),
+	)
+
+	return pr, nil
+}
+
+// Doc routing config 1 lan, khong theo doi thay doi file (dung cho CLI)
+func LoadPathRouter(configPath string, logger *slog.Logger) (*PathRouter, error) {
 	if logger == nil {
 		logger = slog.Default()
 	}
 
-	configDir := filepath.Join(baseConfigDir, "config")
-	configPath := filepath.Join(configDir, "routing.yml")
-
 	pr := &PathRouter{
 		logger:      logger,
 		configPath:  configPath,
 		initialized: false,
+		hits:        make(map[string]*atomic.Uint64),
 	}
 
 	pr.viper = pr.newViper()
@@ -58,16 +87,49 @@ func NewPathRouter(baseConfigDir string, logger *slog.Logger) (*PathRouter, erro
 		return nil, fmt.Errorf("initial config load failed: %w", err)
 	}
 
-	// Watch config với debounce
-	go pr.watchConfigWithDebounce()
+	return pr, nil
+}
 
-	pr.logger.Info("PathRouter initialized",
-		slog.String("config_path", configPath),
-		slog.String("default_service", pr.defaultSvc),
-		slog.Int("initial_rules", len(pr.rules)),
-	)
+// Doc va giai ma routing config tu file, chua validate
+func LoadRoutingConfig(configPath string) (*RoutingConfig, error) {
+	pr := &PathRouter{configPath: configPath}
+	v := pr.newViper()
 
-	return pr, nil
+	if err := readRouting(v); err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var cfg RoutingConfig
+	if err := unmarshalRouting(v, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
+	}
+
+	return &cfg, nil
+}
+
+// File gop co the dua section routing vao file include
+func readRouting(v *viper.Viper) error {
+	if err := v.ReadInConfig(); err != nil {
+		return err
+	}
+	return config.MergeIncludes(v)
+}
+
+// File gop (config + routing) de rule trong section routing, file routing.yml rieng thi o goc
+func unmarshalRouting(v *viper.Viper, cfg *RoutingConfig) error {
+	if v.IsSet(routingSection) {
+		return v.UnmarshalKey(routingSection, cfg)
+	}
+	return v.Unmarshal(cfg)
+}
+
+// Kiem tra routing config ma khong can khoi tao router
+func ValidateRoutingConfig(cfg *RoutingConfig, logger *slog.Logger) error {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	pr := &PathRouter{logger: logger}
+	return pr.validateRoutingConfig(cfg)
 }
 
 // func (pr *PathRouter) newViper() *viper.Viper {
@@ -95,18 +157,59 @@ func (pr *PathRouter) newViper() *viper.Viper {
 	return v
 }
 
+// Ghi nhan cac lan reload thanh cong vao history, rollback se goi lai Reload
+func (pr *PathRouter) SetHistory(h *config.History) error {
+	pr.reloadMu.Lock()
+	pr.history = h
+	pr.reloadMu.Unlock()
+
+	return h.Track(pr.configPath, "routing", pr.Reload)
+}
+
+// Dang ky ham nhan rule moi sau moi lan reload thanh cong
+func (pr *PathRouter) Subscribe(fn func([]RouteRule)) {
+	pr.reloadMu.Lock()
+	defer pr.reloadMu.Unlock()
+	pr.subscribers = append(pr.subscribers, fn)
+}
+
+// Doc lai routing ngay, khong doi watcher. Config sai thi giu rule cu va tra ve loi
+func (pr *PathRouter) Reload() error {
+	pr.mu.RLock()
+	initialized := pr.initialized
+	pr.mu.RUnlock()
+
+	if !initialized {
+		return pr.reloadConfig()
+	}
+
+	// reloadConfig nuot loi validate sau lan dau de watcher giu rule cu
+	cfg, err := LoadRoutingConfig(pr.configPath)
+	if err != nil {
+		return err
+	}
+	if err := pr.validateRoutingConfig(cfg); err != nil {
+		return err
+	}
+	return pr.reloadConfig()
+}
+
 // Validate va ap dung rule theo config yml
 func (pr *PathRouter) reloadConfig() error {
-	//Doc config
-	if err := pr.viper.ReadInConfig(); err != nil {
-		pr.reloadErrors++
+	pr.reloadMu.Lock()
+	defer pr.reloadMu.Unlock()
+
+	//Doc config bang viper moi: pr.viper chi de watch, goroutine WatchConfig ghi vao no khi file doi
+	v := pr.newViper()
+	if err := readRouting(v); err != nil {
+		pr.reloadErrors.Add(1)
 		return fmt.Errorf("failed to read config file: %w", err)
 	}
 
 	//giai ma config vao struct
 	var cfg RoutingConfig
-	if err := pr.viper.Unmarshal(&cfg); err != nil {
-		pr.reloadErrors++
+	if err := unmarshalRouting(v, &cfg); err != nil {
+		pr.reloadErrors.Add(1)
 		return fmt.Errorf("failed to unmarshal config: %w", err)
 	}
 
@@ -117,21 +220,36 @@ func (pr *PathRouter) reloadConfig() error {
 			return nil
 		}
 
-		pr.reloadErrors++
+		pr.reloadErrors.Add(1)
 		pr.logger.Error("Config validation failed - keeping previous config",
 			slog.Any("error", err),
-			slog.String("file", pr.viper.ConfigFileUsed()),
+			slog.String("file", v.ConfigFileUsed()),
 		)
 		return fmt.Errorf("validation failed (old config kept): %w", err)
 	}
 
 	pr.mu.Lock()
 	pr.rules = cfg.Rules
+	for _, rule := range cfg.Rules {
+		if _, ok := pr.hits[rule.Prefix]; !ok {
+			pr.hits[rule.Prefix] = &atomic.Uint64{}
+		}
+	}
 	pr.defaultSvc = cfg.DefaultService
 	pr.lastReload = time.Now()
 	pr.initialized = true
 	pr.mu.Unlock()
 
+	for _, fn := range pr.subscribers {
+		fn(cfg.Rules)
+	}
+
+	if pr.history != nil {
+		if _, err := pr.history.RecordFile(pr.configPath, "routing", config.SourceFile); err != nil {
+			pr.logger.Warn("Failed to record routing revision", "err", err)
+		}
+	}
+
 	pr.logger.Info("Routing config reloaded successfully",
 		slog.Int("rules_count", len(pr.rules)),
 		slog.String("default_service", pr.defaultSvc),
@@ -189,17 +307,40 @@ func (pr *PathRouter) watchConfigWithDebounce() {
 
 // Match ten server name theo prefix cua path
 func (pr *PathRouter) MatchService(path string) string {
+	if rule, ok := pr.MatchRule(path); ok {
+		return rule.Service
+	}
+
+	pr.mu.RLock()
+	defer pr.mu.RUnlock()
+	if pr.defaultSvc != "" {
+		pr.defaultHits.Add(1)
+	}
+	return pr.defaultSvc
+}
+
+// Tra ve rule match path, false khi khong co rule nao match (dung default service)
+func (pr *PathRouter) MatchRule(path string) (RouteRule, bool) {
 	pr.mu.RLock()
 	defer pr.mu.RUnlock()
 
 	for _, rule := range pr.rules {
-		if strings.HasPrefix(path, rule.Prefix) {
-			return rule.Service
-		} else if rule.Prefix == "/" && len(pr.rules) == 1 {
-			return rule.Service
+		if strings.HasPrefix(path, rule.Prefix) || (rule.Prefix == "/" && len(pr.rules) == 1) {
+			if counter, ok := pr.hits[rule.Prefix]; ok {
+				counter.Add(1)
+			}
+			return rule, true
 		}
 	}
-	return pr.defaultSvc
+	return RouteRule{}, false
+}
+
+// Path gui toi backend: bo "/<service>" o dau khi rule bat strip_prefix
+func ForwardPath(path, serviceName string, stripPrefix bool) string {
+	if !stripPrefix {
+		return path
+	}
+	return strings.TrimPrefix(path, "/"+serviceName)
 }
 
 // Kiem tra xem rule nao co strip_prefix=true va path match rule do hay khong
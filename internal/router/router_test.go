@@ -8,6 +8,7 @@ import (
 
 	"log/slog"
 
+	"github.com/nhutphuongasasa/loadbalancer/internal/config"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -105,6 +106,16 @@ func TestPathRouter_MatchService(t *testing.T) {
 			path:       "",
 			wantSvc:    "root",
 		},
+		{
+			name: "gRPC method path",
+			rules: []RouteRule{
+				{Prefix: "/helloworld.Greeter/", Service: "greeter"},
+				{Prefix: "/api", Service: "api"},
+			},
+			defaultSvc: "fallback",
+			path:       "/helloworld.Greeter/SayHello",
+			wantSvc:    "greeter",
+		},
 		{
 			name:       "empty rules → default",
 			rules:      nil,
@@ -201,6 +212,26 @@ func TestReloadConfig_Invalid_KeepOld(t *testing.T) {
 	assert.Equal(t, oldDefault, pr.defaultSvc)
 }
 
+func TestReload_NotifiesSubscribers(t *testing.T) {
+	_, configFile := setupTempConfigDir(t, validConfigContent())
+
+	pr, err := LoadPathRouter(configFile, slog.Default())
+	require.NoError(t, err)
+
+	var got [][]RouteRule
+	pr.Subscribe(func(rules []RouteRule) { got = append(got, rules) })
+
+	require.NoError(t, os.WriteFile(configFile, []byte("rules:\n  - prefix: /api\n    service_name: api\n    selector:\n      version: v2\ndefault_service: api\n"), 0644))
+	require.NoError(t, pr.Reload())
+	require.Len(t, got, 1)
+	assert.Equal(t, map[string]string{"version": "v2"}, got[0][0].Selector)
+
+	// config sai thi giu rule cu, khong bao cho subscriber
+	require.NoError(t, os.WriteFile(configFile, []byte(invalidConfigContent()), 0644))
+	require.Error(t, pr.Reload())
+	assert.Len(t, got, 1)
+}
+
 func TestReloadConfig_ENV_Override(t *testing.T) {
 	tmpDir, _ := setupTempConfigDir(t, `
 default_service: file-default
@@ -256,3 +287,78 @@ default_service: new-default
 	assert.Equal(t, "new-svc", pr.rules[0].Service)
 	assert.NotEqual(t, initialRulesCount, len(pr.rules))
 }
+
+func TestLoadPathRouter_StatusCountsHits(t *testing.T) {
+	_, configPath := setupTempConfigDir(t, validConfigContent())
+
+	pr, err := LoadPathRouter(configPath, slog.Default())
+	require.NoError(t, err)
+
+	pr.MatchService("/api/v1/users")
+	pr.MatchService("/api/v1/orders")
+	pr.MatchService("/public/logo.png")
+	pr.MatchService("/unknown")
+
+	st := pr.Status()
+	require.Len(t, st.Rules, 3)
+	assert.Equal(t, uint64(2), st.Rules[0].Hits)
+	assert.Equal(t, uint64(0), st.Rules[1].Hits)
+	assert.Equal(t, uint64(1), st.Rules[2].Hits)
+	assert.Equal(t, uint64(1), st.DefaultHits)
+}
+
+func TestLoadRoutingConfig_CombinedFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lb.yml")
+	content := "server:\n  port: 8080\nrouting:\n  rules:\n    - prefix: /api\n      service_name: api\n  default_service: web\n"
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+
+	cfg, err := LoadRoutingConfig(path)
+	require.NoError(t, err)
+	require.Len(t, cfg.Rules, 1)
+	assert.Equal(t, "api", cfg.Rules[0].Service)
+	assert.Equal(t, "web", cfg.DefaultService)
+}
+
+func TestCheckRoutingConfig_GRPCPrefix(t *testing.T) {
+	problems := CheckRoutingConfig(&RoutingConfig{
+		Rules: []RouteRule{
+			{Prefix: "/helloworld.Greeter/", Service: "greeter"},
+			{Prefix: "/echo.Echo", Service: "echo", StripPrefix: true},
+		},
+		DefaultService: "fallback",
+	})
+
+	assert.False(t, problems.HasErrors())
+	paths := make([]string, 0, 2)
+	for _, p := range problems.Warnings() {
+		paths = append(paths, p.Path)
+	}
+	assert.ElementsMatch(t, []string{"rules[1].strip_prefix", "rules[1].prefix"}, paths)
+}
+
+func TestPathRouter_RejectsTCPServices(t *testing.T) {
+	tcp := &config.TCPConfig{Listeners: []*config.TCPListenerConfig{{Port: 5432, Service: "postgres"}}}
+
+	problems := CheckTCPServices(&RoutingConfig{
+		Rules:          []RouteRule{{Prefix: "/api", Service: "api"}, {Prefix: "/db", Service: "postgres"}},
+		DefaultService: "postgres",
+	}, tcp)
+	paths := make([]string, 0, 2)
+	for _, p := range problems.Errors() {
+		paths = append(paths, p.Path)
+	}
+	assert.ElementsMatch(t, []string{"rules[1].service_name", "default_service"}, paths)
+
+	// rule dang ap dung da vi pham, reload sau cung bi tu choi
+	_, configPath := setupTempConfigDir(t, validConfigContent())
+	pr, err := LoadPathRouter(configPath, slog.Default())
+	require.NoError(t, err)
+	require.NoError(t, pr.SetTCPServices(tcp))
+
+	pr.mu.RLock()
+	svc := pr.rules[0].Service
+	pr.mu.RUnlock()
+	tcp.Listeners = append(tcp.Listeners, &config.TCPListenerConfig{Port: 1883, Service: svc})
+	assert.Error(t, pr.SetTCPServices(tcp))
+	assert.Error(t, pr.Reload())
+}
@@ -0,0 +1,52 @@
+package router
+
+import "time"
+
+type RuleStatus struct {
+	Prefix      string            `json:"prefix"`
+	Service     string            `json:"service"`
+	StripPrefix bool              `json:"stripPrefix"`
+	Selector    map[string]string `json:"selector,omitempty"`
+	Hits        uint64            `json:"hits"`
+}
+
+type Status struct {
+	ConfigPath     string       `json:"configPath"`
+	DefaultService string       `json:"defaultService"`
+	DefaultHits    uint64       `json:"defaultHits"`
+	LastReload     time.Time    `json:"lastReload"`
+	ReloadErrors   uint64       `json:"reloadErrors"`
+	Rules          []RuleStatus `json:"rules"`
+}
+
+/*
+*Trang thai hien tai cua router: cac rule dang ap dung kem so request da match
+ */
+func (pr *PathRouter) Status() Status {
+	pr.mu.RLock()
+	defer pr.mu.RUnlock()
+
+	st := Status{
+		ConfigPath:     pr.configPath,
+		DefaultService: pr.defaultSvc,
+		DefaultHits:    pr.defaultHits.Load(),
+		LastReload:     pr.lastReload,
+		ReloadErrors:   pr.reloadErrors.Load(),
+		Rules:          make([]RuleStatus, 0, len(pr.rules)),
+	}
+
+	for _, rule := range pr.rules {
+		rs := RuleStatus{
+			Prefix:      rule.Prefix,
+			Service:     rule.Service,
+			StripPrefix: rule.StripPrefix,
+			Selector:    rule.Selector,
+		}
+		if counter, ok := pr.hits[rule.Prefix]; ok {
+			rs.Hits = counter.Load()
+		}
+		st.Rules = append(st.Rules, rs)
+	}
+
+	return st
+}
@@ -4,59 +4,212 @@ import (
 	"fmt"
 	"log/slog"
 	"strings"
+
+	"github.com/nhutphuongasasa/loadbalancer/internal/config"
+	"github.com/nhutphuongasasa/loadbalancer/internal/grpc"
+	"github.com/nhutphuongasasa/loadbalancer/internal/tls"
 )
 
-// Kiem tra tinh hop lecua routing config
+// Kiem tra tinh hop le cua routing config, loi tra ve, canh bao ghi log
 func (pr *PathRouter) validateRoutingConfig(cfg *RoutingConfig) error {
-	var errs []string
+	pr.mu.RLock()
+	tcp := pr.tcp
+	pr.mu.RUnlock()
+
+	problems := CheckRoutingConfig(cfg)
+	problems = append(problems, CheckTCPServices(cfg, tcp)...)
+	return pr.report(problems)
+}
+
+/*
+*Service sau listener tcp khong duoc route HTTP, ap dung cho cac lan reload sau.
+*Tra ve loi neu rule dang ap dung da vi pham
+ */
+func (pr *PathRouter) SetTCPServices(tcp *config.TCPConfig) error {
+	pr.mu.Lock()
+	pr.tcp = tcp
+	current := &RoutingConfig{Rules: pr.rules, DefaultService: pr.defaultSvc}
+	pr.mu.Unlock()
+
+	return pr.report(CheckTCPServices(current, tcp))
+}
+
+// Canh bao ghi log, loi gop lai tra ve
+func (pr *PathRouter) report(problems config.Problems) error {
+	for _, p := range problems.Warnings() {
+		pr.logger.Warn(p.Message, slog.String("path", p.Path))
+	}
+
+	if problems.HasErrors() {
+		errs := problems.Errors()
+		msgs := make([]string, len(errs))
+		for i, p := range errs {
+			msgs[i] = p.Path + ": " + p.Message
+		}
+		return fmt.Errorf("routing config validation failed: %s", strings.Join(msgs, "; "))
+	}
+
+	return nil
+}
+
+// Tra ve tat ca van de cua routing config, path tinh tu goc routing (vd rules[0].prefix)
+func CheckRoutingConfig(cfg *RoutingConfig) config.Problems {
+	var ps config.Problems
 
-	prefixSet := make(map[string]struct{})
+	prefixSet := make(map[string]int)
 
 	for i, rule := range cfg.Rules {
-		idx := i + 1
+		path := fmt.Sprintf("rules[%d]", i)
 
 		if rule.Prefix == "" {
-			errs = append(errs, fmt.Sprintf("rule #%d: prefix is empty", idx))
+			ps = append(ps, problem(path+".prefix", config.SeverityError, "prefix is empty"))
 			continue
 		}
 
 		//Canh bao nen co prefix bat dau la "/"
 		if !strings.HasPrefix(rule.Prefix, "/") {
-			pr.logger.Warn("prefix should start with '/', normalizing it",
-				slog.Int("rule", idx), slog.String("prefix", rule.Prefix))
-			rule.Prefix = "/" + rule.Prefix
+			ps = append(ps, problem(path+".prefix", config.SeverityWarning,
+				fmt.Sprintf("prefix %q should start with '/'", rule.Prefix)))
 		}
 
 		//loi server_name rong, khong hop le
 		if rule.Service == "" {
-			errs = append(errs, fmt.Sprintf("rule #%d: service_name is empty (prefix: %s)", idx, rule.Prefix))
+			ps = append(ps, problem(path+".service_name", config.SeverityError,
+				fmt.Sprintf("service_name is empty (prefix: %s)", rule.Prefix)))
+		}
+
+		//selector phai co key va value
+		for k, v := range rule.Selector {
+			if k == "" || v == "" {
+				ps = append(ps, problem(path+".selector", config.SeverityError,
+					"selector must not contain empty key or value"))
+				break
+			}
+		}
+
+		//fingerprint phai la SHA-256 dang hex
+		if rule.ClientCert != nil {
+			for j, f := range rule.ClientCert.Fingerprints {
+				if tls.NormalizeFingerprint(f) == "" {
+					ps = append(ps, problem(fmt.Sprintf("%s.client_cert.fingerprints[%d]", path, j), config.SeverityError,
+						fmt.Sprintf("invalid fingerprint %q, expected SHA-256 in hex", f)))
+				}
+			}
+		}
+
+		//method gRPC nam trong path nen khong duoc cat, prefix thieu "/" match ca service khac
+		if grpc.IsServicePath(rule.Prefix) {
+			if rule.StripPrefix {
+				ps = append(ps, problem(path+".strip_prefix", config.SeverityWarning,
+					fmt.Sprintf("strip_prefix on gRPC prefix %q changes the method path, backends will answer UNIMPLEMENTED", rule.Prefix)))
+			}
+			if strings.Count(rule.Prefix, "/") == 1 {
+				ps = append(ps, problem(path+".prefix", config.SeverityWarning,
+					fmt.Sprintf("gRPC prefix %q also matches services sharing its name as prefix, end it with '/'", rule.Prefix)))
+			}
 		}
 
 		//kiem tra trung lap prefix
-		if _, dup := prefixSet[rule.Prefix]; dup {
-			errs = append(errs, fmt.Sprintf("duplicate prefix: '%s' at rule #%d", rule.Prefix, idx))
+		if first, dup := prefixSet[rule.Prefix]; dup {
+			ps = append(ps, problem(path+".prefix", config.SeverityError,
+				fmt.Sprintf("duplicate prefix %q (first defined at rules[%d])", rule.Prefix, first)))
+		} else {
+			prefixSet[rule.Prefix] = i
 		}
-		prefixSet[rule.Prefix] = struct{}{}
 
 		//kiem tra prefix la "/" match moi thu
 		if rule.Prefix == "/" && len(cfg.Rules) > 1 {
-			errs = append(errs, fmt.Sprintf("prefix '/' will match everything → other rules may never be used (rule #%d)", idx))
+			ps = append(ps, problem(path+".prefix", config.SeverityError,
+				"prefix '/' will match everything, other rules may never be used"))
 		}
 	}
 
 	if len(cfg.Rules) == 0 {
 		if cfg.DefaultService == "" {
-			errs = append(errs, "no rules and no default_service → all requests will fail")
+			ps = append(ps, problem("rules", config.SeverityError,
+				"no rules and no default_service, all requests will fail"))
 		} else {
-			pr.logger.Warn("no routing rules defined → all traffic goes to default_service")
+			ps = append(ps, problem("rules", config.SeverityWarning,
+				"no routing rules defined, all traffic goes to default_service"))
 		}
 	} else if cfg.DefaultService == "" {
-		pr.logger.Warn("no default_service configured → unmatched requests will fail")
+		ps = append(ps, problem("default_service", config.SeverityWarning,
+			"no default_service configured, unmatched requests will fail"))
 	}
 
-	if len(errs) > 0 {
-		return fmt.Errorf("routing config validation failed: %s", strings.Join(errs, "; "))
+	return ps
+}
+
+/*
+*Doi chieu service trong routing voi backend tinh trong config.
+*Service khong co backend tinh van co the dang ky qua registry nen chi la canh bao.
+*Van de dau nam trong routing, van de thu hai nam trong config
+ */
+func CheckBackendReferences(cfg *RoutingConfig, backends []*config.BackEndConfig) (routing, cfgProblems config.Problems) {
+	static := make(map[string]bool, len(backends))
+	for _, be := range backends {
+		static[be.Service] = true
 	}
 
-	return nil
+	routed := make(map[string]bool, len(cfg.Rules)+1)
+	for i, rule := range cfg.Rules {
+		if rule.Service == "" {
+			continue
+		}
+		routed[rule.Service] = true
+
+		if len(backends) > 0 && !static[rule.Service] {
+			routing = append(routing, problem(fmt.Sprintf("rules[%d].service_name", i), config.SeverityWarning,
+				fmt.Sprintf("service %q has no static backend, it must register through the registry", rule.Service)))
+		}
+	}
+
+	if cfg.DefaultService != "" {
+		routed[cfg.DefaultService] = true
+		if len(backends) > 0 && !static[cfg.DefaultService] {
+			routing = append(routing, problem("default_service", config.SeverityWarning,
+				fmt.Sprintf("service %q has no static backend, it must register through the registry", cfg.DefaultService)))
+		}
+	}
+
+	for i, be := range backends {
+		// backend tcp:// nhan ket noi tu listener tcp, khong qua routing
+		if be.Service != "" && !routed[be.Service] && be.Scheme() != "tcp" {
+			cfgProblems = append(cfgProblems, problem(fmt.Sprintf("backends[%d].service", i), config.SeverityWarning,
+				fmt.Sprintf("service %q is not referenced by any routing rule", be.Service)))
+		}
+	}
+
+	return routing, cfgProblems
+}
+
+/*
+*Service sau listener tcp chi nhan byte tho, request HTTP route toi do se that bai
+ */
+func CheckTCPServices(cfg *RoutingConfig, tcp *config.TCPConfig) config.Problems {
+	if tcp == nil || len(tcp.Listeners) == 0 {
+		return nil
+	}
+
+	listened := make(map[string]bool, len(tcp.Listeners))
+	for _, l := range tcp.Listeners {
+		listened[l.Service] = true
+	}
+
+	var ps config.Problems
+	for i, rule := range cfg.Rules {
+		if listened[rule.Service] {
+			ps = append(ps, problem(fmt.Sprintf("rules[%d].service_name", i), config.SeverityError,
+				fmt.Sprintf("service %q is behind a tcp listener and cannot receive HTTP requests", rule.Service)))
+		}
+	}
+	if listened[cfg.DefaultService] {
+		ps = append(ps, problem("default_service", config.SeverityError,
+			fmt.Sprintf("service %q is behind a tcp listener and cannot receive HTTP requests", cfg.DefaultService)))
+	}
+	return ps
+}
+
+func problem(path string, sev config.Severity, msg string) config.Problem {
+	return config.Problem{Path: path, Severity: sev, Message: msg}
 }
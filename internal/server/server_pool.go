@@ -12,6 +12,16 @@ import (
 type subPool struct {
 	backends []*model.Server
 	strategy strategies.Strategy
+	subsets  map[string]*subset // selector key -> subset
+}
+
+/*
+*Tap con instance cua 1 service co metadata khop selector, tinh san khi pool thay doi
+ */
+type subset struct {
+	selector map[string]string
+	backends []*model.Server
+	strategy strategies.Strategy
 }
 
 type ServerPool struct {
@@ -19,6 +29,7 @@ type ServerPool struct {
 	logger          *slog.Logger
 	updateChan      <-chan *model.Server
 	strategyFactory func(serviceName string) strategies.Strategy
+	selectors       map[string]map[string]map[string]string // service -> selector key -> selector
 	done            chan struct{}
 	mu              sync.RWMutex
 }
@@ -32,6 +43,7 @@ func NewServerPool(
 		logger:          logger,
 		updateChan:      updateChan,
 		strategyFactory: strategyFactory,
+		selectors:       make(map[string]map[string]map[string]string),
 		done:            make(chan struct{}),
 	}
 
@@ -79,10 +91,12 @@ func (p *ServerPool) applyStateChange(srv *model.Server) {
 
 	var strategy strategies.Strategy
 	var oldBackends []*model.Server
+	var oldSubsets map[string]*subset
 
 	if exists {
 		strategy = oldSub.strategy
 		oldBackends = oldSub.backends
+		oldSubsets = oldSub.subsets
 	} else {
 		strategy = p.strategyFactory(svcName)
 		oldBackends = []*model.Server{}
@@ -94,7 +108,7 @@ func (p *ServerPool) applyStateChange(srv *model.Server) {
 	for _, e := range oldBackends {
 		if e.GetID() == srv.GetID() {
 			found = true
-			if srv.IsHealthy() {
+			if srv.IsAvailable() {
 				newList = append(newList, srv)
 			}
 		} else {
@@ -102,13 +116,14 @@ func (p *ServerPool) applyStateChange(srv *model.Server) {
 		}
 	}
 
-	if !found && srv.IsHealthy() {
+	if !found && srv.IsAvailable() {
 		newList = append(newList, srv)
 	}
 
 	newMap[svcName] = &subPool{
 		backends: newList,
 		strategy: strategy,
+		subsets:  p.buildSubsets(svcName, newList, oldSubsets),
 	}
 
 	p.healthyAtomic.Store(&newMap)
@@ -118,6 +133,7 @@ func (p *ServerPool) applyStateChange(srv *model.Server) {
 		"total_healthy", len(newList),
 		"server_id", srv.GetID(),
 		"is_healthy", srv.IsHealthy(),
+		"is_draining", srv.IsDraining(),
 	)
 }
 
@@ -134,6 +150,152 @@ func (p *ServerPool) PickBackend(serviceName string, clientIP string) *model.Ser
 	return sub.strategy.Pick(sub.backends, clientIP)
 }
 
+/*
+*Chon backend trong tap con co metadata khop selector, selector rong thi giong PickBackend
+ */
+func (p *ServerPool) PickBackendWithSelector(serviceName string, selector map[string]string, clientIP string) *model.Server {
+	if len(selector) == 0 {
+		return p.PickBackend(serviceName, clientIP)
+	}
+
+	key := model.SelectorKey(selector)
+
+	currentPtr := p.healthyAtomic.Load().(*map[string]*subPool)
+	sub, ok := (*currentPtr)[serviceName]
+	if !ok {
+		p.logger.Warn("No healthy servers for service", "service", serviceName)
+		return nil
+	}
+
+	set, ok := sub.subsets[key]
+	if !ok {
+		// selector moi gap lan dau, tinh tap con 1 lan roi luu lai
+		set = p.addSelector(serviceName, selector)
+	}
+
+	if set == nil || len(set.backends) == 0 {
+		p.logger.Warn("No healthy servers for selector", "service", serviceName, "selector", key)
+		return nil
+	}
+
+	return set.strategy.Pick(set.backends, clientIP)
+}
+
+/*
+*Ghi nho selector cua service va tinh tap con ngay tu danh sach hien tai
+ */
+func (p *ServerPool) addSelector(serviceName string, selector map[string]string) *subset {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	key := model.SelectorKey(selector)
+
+	if _, ok := p.selectors[serviceName]; !ok {
+		p.selectors[serviceName] = make(map[string]map[string]string)
+	}
+	p.selectors[serviceName][key] = selector
+
+	currentPtr := p.healthyAtomic.Load().(*map[string]*subPool)
+	current := *currentPtr
+
+	sub, ok := current[serviceName]
+	if !ok {
+		return nil
+	}
+
+	// goroutine khac co the da them selector nay
+	if set, ok := sub.subsets[key]; ok {
+		return set
+	}
+
+	newMap := make(map[string]*subPool, len(current))
+	for k, v := range current {
+		newMap[k] = v
+	}
+
+	newSub := &subPool{
+		backends: sub.backends,
+		strategy: sub.strategy,
+		subsets:  p.buildSubsets(serviceName, sub.backends, sub.subsets),
+	}
+	newMap[serviceName] = newSub
+
+	p.healthyAtomic.Store(&newMap)
+
+	p.logger.Debug("Selector subset created", "service", serviceName, "selector", key, "backends", len(newSub.subsets[key].backends))
+
+	return newSub.subsets[key]
+}
+
+/*
+*Thay tap selector bang selector cua cac rule hien tai (service -> selector).
+*Goi sau moi lan reload routing de tap con cua selector da bi bo hoac doi khong con giu lai
+ */
+func (p *ServerPool) SetSelectors(active map[string][]map[string]string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	selectors := make(map[string]map[string]map[string]string, len(active))
+	for serviceName, list := range active {
+		for _, selector := range list {
+			if len(selector) == 0 {
+				continue
+			}
+			if _, ok := selectors[serviceName]; !ok {
+				selectors[serviceName] = make(map[string]map[string]string)
+			}
+			selectors[serviceName][model.SelectorKey(selector)] = selector
+		}
+	}
+	p.selectors = selectors
+
+	currentPtr := p.healthyAtomic.Load().(*map[string]*subPool)
+	current := *currentPtr
+
+	newMap := make(map[string]*subPool, len(current))
+	for name, sub := range current {
+		newMap[name] = &subPool{
+			backends: sub.backends,
+			strategy: sub.strategy,
+			subsets:  p.buildSubsets(name, sub.backends, sub.subsets),
+		}
+	}
+
+	p.healthyAtomic.Store(&newMap)
+}
+
+/*
+*Loc danh sach backend theo tung selector da biet cua service, giu lai strategy cu neu co.
+*Phai goi khi dang giu p.mu
+ */
+func (p *ServerPool) buildSubsets(serviceName string, backends []*model.Server, old map[string]*subset) map[string]*subset {
+	selectors := p.selectors[serviceName]
+	if len(selectors) == 0 {
+		return nil
+	}
+
+	subsets := make(map[string]*subset, len(selectors))
+	for key, selector := range selectors {
+		set := &subset{selector: selector}
+
+		if prev, ok := old[key]; ok {
+			set.strategy = prev.strategy
+		} else {
+			set.strategy = p.strategyFactory(serviceName)
+		}
+
+		for _, srv := range backends {
+			if srv.MatchesSelector(selector) {
+				set.backends = append(set.backends, srv)
+			}
+		}
+
+		subsets[key] = set
+	}
+
+	return subsets
+}
+
 func (p *ServerPool) GetInstanceServer(serviceName, instanceId string) *model.Server {
 	currentPtr := p.healthyAtomic.Load().(*map[string]*subPool)
 	current := *currentPtr
@@ -145,7 +307,7 @@ func (p *ServerPool) GetInstanceServer(serviceName, instanceId string) *model.Se
 
 	for _, srv := range sub.backends {
 		if srv.GetID() == instanceId {
-			if srv.IsHealthy() {
+			if srv.IsAvailable() {
 				return srv
 			}
 			return nil
@@ -158,3 +320,29 @@ func (p *ServerPool) Close() {
 	p.logger.Info("Closing server pool")
 	close(p.done)
 }
+
+/*
+*Doi strategy luc chay: tao strategy moi cho moi service va tap con, danh sach backend giu nguyen
+ */
+func (p *ServerPool) SetStrategyFactory(factory func(string) strategies.Strategy) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.strategyFactory = factory
+
+	currentPtr := p.healthyAtomic.Load().(*map[string]*subPool)
+	current := *currentPtr
+
+	newMap := make(map[string]*subPool, len(current))
+	for svc, sub := range current {
+		newMap[svc] = &subPool{
+			backends: sub.backends,
+			strategy: factory(svc),
+			// old = nil de moi tap con nhan strategy moi
+			subsets: p.buildSubsets(svc, sub.backends, nil),
+		}
+	}
+
+	p.healthyAtomic.Store(&newMap)
+	p.logger.Info("Load balancing strategy replaced", "services", len(newMap))
+}
@@ -0,0 +1,103 @@
+package server
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/nhutphuongasasa/loadbalancer/internal/balancer/strategies"
+	"github.com/nhutphuongasasa/loadbalancer/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestPool(t *testing.T) (*ServerPool, chan *model.Server) {
+	t.Helper()
+
+	updates := make(chan *model.Server, 16)
+	pool := NewServerPool(
+		slog.New(slog.NewTextHandler(io.Discard, nil)),
+		updates,
+		func(string) strategies.Strategy { return strategies.NewWeightedRoundRobin() },
+	)
+	t.Cleanup(pool.Close)
+
+	return pool, updates
+}
+
+// cho goroutine listenUpdates xu ly xong cac update da gui
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	require.Eventually(t, cond, time.Second, 5*time.Millisecond)
+}
+
+func TestPickBackendWithSelector(t *testing.T) {
+	pool, updates := newTestPool(t)
+
+	v1 := model.NewServer("v1", "api", "10.0.0.1", 80, 1, map[string]string{"version": "v1", "region": "eu"}, nil)
+	v2 := model.NewServer("v2", "api", "10.0.0.2", 80, 1, map[string]string{"version": "v2", "region": "eu"}, nil)
+	updates <- v1
+	updates <- v2
+	waitFor(t, func() bool { return pool.GetInstanceServer("api", "v2") != nil })
+
+	for i := 0; i < 5; i++ {
+		got := pool.PickBackendWithSelector("api", map[string]string{"version": "v2"}, "1.2.3.4")
+		require.NotNil(t, got)
+		assert.Equal(t, "v2", got.InstanceID)
+	}
+
+	assert.Nil(t, pool.PickBackendWithSelector("api", map[string]string{"region": "us"}, "1.2.3.4"))
+
+	// tap con duoc cap nhat khi instance moi khop selector xuat hien
+	v2b := model.NewServer("v2b", "api", "10.0.0.3", 80, 1, map[string]string{"version": "v2"}, nil)
+	updates <- v2b
+	waitFor(t, func() bool {
+		sub := (*pool.healthyAtomic.Load().(*map[string]*subPool))["api"]
+		return len(sub.subsets["version=v2"].backends) == 2
+	})
+
+	// instance down bi loai khoi tap con
+	v2.SetAlive(false)
+	updates <- v2
+	waitFor(t, func() bool {
+		sub := (*pool.healthyAtomic.Load().(*map[string]*subPool))["api"]
+		return len(sub.subsets["version=v2"].backends) == 1
+	})
+	assert.Equal(t, "v2b", pool.PickBackendWithSelector("api", map[string]string{"version": "v2"}, "").InstanceID)
+}
+
+func TestSetSelectors_PrunesRemovedSelectors(t *testing.T) {
+	pool, updates := newTestPool(t)
+
+	updates <- model.NewServer("v1", "api", "10.0.0.1", 80, 1, map[string]string{"version": "v1"}, nil)
+	updates <- model.NewServer("v2", "api", "10.0.0.2", 80, 1, map[string]string{"version": "v2"}, nil)
+	waitFor(t, func() bool { return pool.GetInstanceServer("api", "v2") != nil })
+
+	require.NotNil(t, pool.PickBackendWithSelector("api", map[string]string{"version": "v1"}, ""))
+	subsets := func() map[string]*subset {
+		return (*pool.healthyAtomic.Load().(*map[string]*subPool))["api"].subsets
+	}
+	require.Contains(t, subsets(), "version=v1")
+
+	// routing reload doi selector v1 thanh v2
+	pool.SetSelectors(map[string][]map[string]string{"api": {{"version": "v2"}}})
+	assert.NotContains(t, subsets(), "version=v1")
+	assert.Len(t, subsets()["version=v2"].backends, 1)
+
+	// instance moi khong tao lai tap con da bi bo
+	updates <- model.NewServer("v1b", "api", "10.0.0.3", 80, 1, map[string]string{"version": "v1"}, nil)
+	waitFor(t, func() bool { return pool.GetInstanceServer("api", "v1b") != nil })
+	assert.NotContains(t, subsets(), "version=v1")
+
+	pool.SetSelectors(nil)
+	assert.Empty(t, subsets())
+}
+
+func TestSelectorKey_Stable(t *testing.T) {
+	a := model.SelectorKey(map[string]string{"b": "2", "a": "1"})
+	b := model.SelectorKey(map[string]string{"a": "1", "b": "2"})
+	assert.Equal(t, "a=1,b=2", a)
+	assert.Equal(t, a, b)
+	assert.Equal(t, "", model.SelectorKey(nil))
+}
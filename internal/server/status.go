@@ -0,0 +1,43 @@
+package server
+
+import "sort"
+
+type SubsetStatus struct {
+	Selector map[string]string `json:"selector"`
+	Backends []string          `json:"backends"`
+}
+
+type PoolStatus struct {
+	Service  string         `json:"service"`
+	Backends []string       `json:"backends"` // instance dang nhan traffic
+	Subsets  []SubsetStatus `json:"subsets,omitempty"`
+}
+
+/*
+*Danh sach backend dang nhan traffic cua tung service, ke ca cac tap con theo selector
+ */
+func (p *ServerPool) Status() []PoolStatus {
+	currentPtr := p.healthyAtomic.Load().(*map[string]*subPool)
+	current := *currentPtr
+
+	status := make([]PoolStatus, 0, len(current))
+	for svc, sub := range current {
+		ps := PoolStatus{Service: svc, Backends: make([]string, 0, len(sub.backends))}
+		for _, srv := range sub.backends {
+			ps.Backends = append(ps.Backends, srv.GetID())
+		}
+
+		for _, set := range sub.subsets {
+			ss := SubsetStatus{Selector: set.selector, Backends: make([]string, 0, len(set.backends))}
+			for _, srv := range set.backends {
+				ss.Backends = append(ss.Backends, srv.GetID())
+			}
+			ps.Subsets = append(ps.Subsets, ss)
+		}
+
+		status = append(status, ps)
+	}
+
+	sort.Slice(status, func(i, j int) bool { return status[i].Service < status[j].Service })
+	return status
+}
@@ -0,0 +1,129 @@
+package tcpproxy
+
+import (
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const copyBufferSize = 32 * 1024
+
+/*
+*So lieu cong don cua 1 listener, doc boi Metrics
+ */
+type stats struct {
+	accepted atomic.Int64
+	active   atomic.Int64
+	failed   atomic.Int64
+	received atomic.Int64 // byte tu client
+	sent     atomic.Int64 // byte toi client
+}
+
+/*
+*1 cap ket noi client <-> backend. Idle tinh chung cho 2 chieu: chieu nay im nhung chieu kia
+*con du lieu thi khong dong
+ */
+type conn struct {
+	client   net.Conn
+	upstream net.Conn
+	idle     time.Duration
+
+	last     atomic.Int64 // unix nano lan cuoi co du lieu
+	bytesIn  atomic.Int64
+	bytesOut atomic.Int64
+
+	closeOnce sync.Once
+}
+
+func newConn(client, upstream net.Conn, idle time.Duration) *conn {
+	c := &conn{client: client, upstream: upstream, idle: idle}
+	c.touch()
+	return c
+}
+
+func (c *conn) touch() {
+	c.last.Store(time.Now().UnixNano())
+}
+
+func (c *conn) lastActivity() time.Time {
+	return time.Unix(0, c.last.Load())
+}
+
+/*
+*Chuyen du lieu 2 chieu, tra ve khi ca 2 chieu ket thuc hoac ket noi bi dong
+ */
+func (c *conn) pipe(s *stats) {
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		c.copy(c.upstream, c.client, &c.bytesIn, &s.received)
+	}()
+	go func() {
+		defer wg.Done()
+		c.copy(c.client, c.upstream, &c.bytesOut, &s.sent)
+	}()
+	wg.Wait()
+	c.close()
+}
+
+/*
+*EOF tu src thi dong chieu ghi cua dst (half-close) de ben kia van tra loi duoc,
+*loi khac hoac het idle thi dong ca cap
+ */
+func (c *conn) copy(dst, src net.Conn, n, total *atomic.Int64) {
+	buf := make([]byte, copyBufferSize)
+	for {
+		if c.idle > 0 {
+			_ = src.SetReadDeadline(c.lastActivity().Add(c.idle))
+		}
+
+		nr, err := src.Read(buf)
+		if nr > 0 {
+			c.touch()
+			if c.idle > 0 {
+				_ = dst.SetWriteDeadline(time.Now().Add(c.idle))
+			}
+			nw, werr := dst.Write(buf[:nr])
+			n.Add(int64(nw))
+			total.Add(int64(nw))
+			if werr != nil {
+				c.close()
+				return
+			}
+		}
+		if err == nil {
+			continue
+		}
+
+		var ne net.Error
+		if errors.As(err, &ne) && ne.Timeout() && time.Since(c.lastActivity()) < c.idle {
+			continue
+		}
+		if errors.Is(err, io.EOF) {
+			closeWrite(dst, c)
+			return
+		}
+		c.close()
+		return
+	}
+}
+
+func closeWrite(dst net.Conn, c *conn) {
+	if cw, ok := dst.(interface{ CloseWrite() error }); ok {
+		if cw.CloseWrite() == nil {
+			return
+		}
+	}
+	c.close()
+}
+
+func (c *conn) close() {
+	c.closeOnce.Do(func() {
+		c.client.Close()
+		c.upstream.Close()
+	})
+}
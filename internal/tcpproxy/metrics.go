@@ -0,0 +1,29 @@
+package tcpproxy
+
+import (
+	"strconv"
+
+	"github.com/nhutphuongasasa/loadbalancer/internal/metric"
+)
+
+/*
+*So lieu theo listener, dung cho /metrics cua admin
+ */
+func (p *Proxy) Metrics() []*metric.Family {
+	accepted := metric.NewCounter("lb_tcp_connections_total", "TCP connections accepted by a listener.")
+	active := metric.NewGauge("lb_tcp_active_connections", "TCP connections currently proxied by a listener.")
+	failed := metric.NewCounter("lb_tcp_connect_failures_total", "TCP connections closed because no backend could be reached.")
+	received := metric.NewCounter("lb_tcp_received_bytes_total", "Bytes received from TCP clients and forwarded to backends.")
+	sent := metric.NewCounter("lb_tcp_sent_bytes_total", "Bytes received from backends and sent to TCP clients.")
+
+	for _, l := range p.listeners {
+		labels := []string{"port", strconv.Itoa(l.Port), "service", l.Service}
+		accepted.Add(float64(l.stats.accepted.Load()), labels...)
+		active.Add(float64(l.stats.active.Load()), labels...)
+		failed.Add(float64(l.stats.failed.Load()), labels...)
+		received.Add(float64(l.stats.received.Load()), labels...)
+		sent.Add(float64(l.stats.sent.Load()), labels...)
+	}
+
+	return []*metric.Family{accepted, active, failed, received, sent}
+}
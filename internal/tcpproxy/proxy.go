@@ -0,0 +1,272 @@
+package tcpproxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/nhutphuongasasa/loadbalancer/internal/model"
+)
+
+var ErrProxyClosed = errors.New("tcpproxy: proxy closed")
+
+/*
+*Chon instance cho 1 ket noi, ServerPool thoa man interface nay
+ */
+type Picker interface {
+	PickBackend(serviceName string, clientIP string) *model.Server
+}
+
+type Listener struct {
+	Port    int
+	Service string
+}
+
+/*
+*Proxy L4: moi listener nhan ket noi, chon instance cua service theo strategy cua pool
+*va chuyen byte 2 chieu cho den khi 1 ben dong hoac het idle timeout
+ */
+type Proxy struct {
+	picker    Picker
+	listeners []*listener
+	logger    *slog.Logger
+
+	connectTimeout atomic.Int64
+	idleTimeout    atomic.Int64
+
+	mu     sync.Mutex
+	conns  map[*conn]struct{}
+	closed bool
+	wg     sync.WaitGroup // accept loop va ket noi dang chay
+}
+
+type listener struct {
+	Listener
+	ln    net.Listener
+	stats stats
+}
+
+type Option func(*Proxy)
+
+func WithLogger(logger *slog.Logger) Option {
+	return func(p *Proxy) {
+		p.logger = logger
+	}
+}
+
+/*
+*connect = 0 thi khong gioi han thoi gian ket noi toi backend, idle = 0 thi khong dong ket noi ranh
+ */
+func WithTimeouts(connect, idle time.Duration) Option {
+	return func(p *Proxy) {
+		p.SetTimeouts(connect, idle)
+	}
+}
+
+func NewProxy(picker Picker, listeners []Listener, opts ...Option) *Proxy {
+	p := &Proxy{
+		picker: picker,
+		logger: slog.Default(),
+		conns:  make(map[*conn]struct{}),
+	}
+	for _, l := range listeners {
+		p.listeners = append(p.listeners, &listener{Listener: l})
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+/*
+*Ap dung cho ket noi moi, ket noi dang mo giu gia tri cu
+ */
+func (p *Proxy) SetTimeouts(connect, idle time.Duration) {
+	p.connectTimeout.Store(int64(connect))
+	p.idleTimeout.Store(int64(idle))
+}
+
+func (p *Proxy) Empty() bool {
+	return len(p.listeners) == 0
+}
+
+/*
+*Mo tat ca listener roi phuc vu den khi Shutdown, giong http.Server tra ve ErrProxyClosed.
+*Khong mo duoc 1 port thi dong cac port da mo
+ */
+func (p *Proxy) ListenAndServe() error {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return ErrProxyClosed
+	}
+	for _, l := range p.listeners {
+		ln, err := net.Listen("tcp", ":"+strconv.Itoa(l.Port))
+		if err != nil {
+			p.closeListenersLocked()
+			p.mu.Unlock()
+			return fmt.Errorf("listen tcp :%d for service %s: %w", l.Port, l.Service, err)
+		}
+		l.ln = ln
+	}
+	// Add cung luc kiem tra closed de Shutdown khong Wait khi bo dem con 0
+	p.wg.Add(len(p.listeners))
+	p.mu.Unlock()
+
+	errs := make(chan error, len(p.listeners))
+	for _, l := range p.listeners {
+		go func(l *listener) {
+			defer p.wg.Done()
+			errs <- p.serve(l)
+		}(l)
+	}
+
+	// loi accept cua 1 listener dung ca proxy
+	err := <-errs
+	if !errors.Is(err, ErrProxyClosed) {
+		p.mu.Lock()
+		p.closeListenersLocked()
+		p.mu.Unlock()
+	}
+	return err
+}
+
+func (p *Proxy) serve(l *listener) error {
+	p.logger.Info("TCP listener is starting", "port", l.Port, "service", l.Service)
+
+	for {
+		client, err := l.ln.Accept()
+		if err != nil {
+			p.mu.Lock()
+			closed := p.closed
+			p.mu.Unlock()
+			if closed || errors.Is(err, net.ErrClosed) {
+				return ErrProxyClosed
+			}
+			return fmt.Errorf("accept tcp :%d: %w", l.Port, err)
+		}
+
+		p.wg.Add(1)
+		go func() {
+			defer p.wg.Done()
+			p.handle(l, client)
+		}()
+	}
+}
+
+/*
+*Ngung nhan ket noi moi, cho ket noi dang mo ket thuc. Het ctx thi dong cac ket noi con lai
+ */
+func (p *Proxy) Shutdown(ctx context.Context) error {
+	p.mu.Lock()
+	p.closed = true
+	p.closeListenersLocked()
+	active := len(p.conns)
+	p.mu.Unlock()
+
+	if active > 0 {
+		p.logger.Info("Draining TCP connections", "active", active)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+	}
+
+	p.mu.Lock()
+	remaining := len(p.conns)
+	for c := range p.conns {
+		c.close()
+	}
+	p.mu.Unlock()
+
+	p.logger.Warn("Drain timeout reached, closed remaining TCP connections", "closed", remaining)
+	<-done
+	return ctx.Err()
+}
+
+func (p *Proxy) closeListenersLocked() {
+	for _, l := range p.listeners {
+		if l.ln != nil {
+			l.ln.Close()
+		}
+	}
+}
+
+func (p *Proxy) handle(l *listener, client net.Conn) {
+	l.stats.accepted.Add(1)
+
+	clientIP, _, _ := net.SplitHostPort(client.RemoteAddr().String())
+	backend := p.picker.PickBackend(l.Service, clientIP)
+	if backend == nil {
+		l.stats.failed.Add(1)
+		client.Close()
+		p.logger.Warn("No healthy backend for TCP connection", "port", l.Port, "service", l.Service, "client", clientIP)
+		return
+	}
+
+	dialer := net.Dialer{Timeout: time.Duration(p.connectTimeout.Load())}
+	upstream, err := dialer.Dial("tcp", backend.HostPort())
+	if err != nil {
+		l.stats.failed.Add(1)
+		client.Close()
+		p.logger.Warn("Failed to connect TCP backend", "service", l.Service, "backend", backend.HostPort(), "err", err)
+		return
+	}
+
+	c := newConn(client, upstream, time.Duration(p.idleTimeout.Load()))
+	if !p.track(c) {
+		c.close()
+		return
+	}
+	defer p.untrack(c)
+
+	backend.IncConn()
+	defer backend.DecConn()
+	l.stats.active.Add(1)
+	defer l.stats.active.Add(-1)
+
+	start := time.Now()
+	c.pipe(&l.stats)
+
+	p.logger.Debug("TCP connection closed",
+		"service", l.Service,
+		"client", clientIP,
+		"backend", backend.HostPort(),
+		"bytes_in", c.bytesIn.Load(),
+		"bytes_out", c.bytesOut.Load(),
+		"duration", time.Since(start).Round(time.Millisecond),
+	)
+}
+
+/*
+*false khi proxy dang tat, ket noi moi khong duoc bat dau
+ */
+func (p *Proxy) track(c *conn) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return false
+	}
+	p.conns[c] = struct{}{}
+	return true
+}
+
+func (p *Proxy) untrack(c *conn) {
+	p.mu.Lock()
+	delete(p.conns, c)
+	p.mu.Unlock()
+}
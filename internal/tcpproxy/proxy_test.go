@@ -0,0 +1,157 @@
+package tcpproxy
+
+import (
+	"context"
+	"io"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/nhutphuongasasa/loadbalancer/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type staticPicker struct {
+	srv *model.Server
+}
+
+func (p staticPicker) PickBackend(string, string) *model.Server {
+	return p.srv
+}
+
+/*
+*Backend tra lai nguyen byte nhan duoc, dong chieu ghi khi client half-close
+ */
+func echoBackend(t *testing.T) *model.Server {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer c.Close()
+				io.Copy(c, c)
+				c.(*net.TCPConn).CloseWrite()
+			}()
+		}
+	}()
+
+	addr := ln.Addr().(*net.TCPAddr)
+	return model.NewServer("echo-1", "echo", "127.0.0.1", addr.Port, 1, nil, nil, model.WithTCP())
+}
+
+func startProxy(t *testing.T, backend *model.Server, opts ...Option) (*Proxy, string) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	port := ln.Addr().(*net.TCPAddr).Port
+	ln.Close()
+
+	p := NewProxy(staticPicker{backend}, []Listener{{Port: port, Service: "echo"}}, opts...)
+	go p.ListenAndServe()
+	t.Cleanup(func() { p.Shutdown(context.Background()) })
+
+	// cho listener mo, khong dial thu de khong lam lech so lieu
+	require.Eventually(t, func() bool {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		return p.listeners[0].ln != nil
+	}, time.Second, 10*time.Millisecond)
+	return p, "127.0.0.1:" + strconv.Itoa(port)
+}
+
+func TestProxy_ForwardsAndCountsBytes(t *testing.T) {
+	backend := echoBackend(t)
+	p, addr := startProxy(t, backend, WithTimeouts(time.Second, time.Minute))
+
+	c, err := net.Dial("tcp", addr)
+	require.NoError(t, err)
+	defer c.Close()
+
+	_, err = c.Write([]byte("hello tcp"))
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return backend.GetActiveConns() == 1 }, time.Second, 10*time.Millisecond)
+
+	// half-close: backend van tra loi duoc sau khi client ngung gui
+	require.NoError(t, c.(*net.TCPConn).CloseWrite())
+	got, err := io.ReadAll(c)
+	require.NoError(t, err)
+	assert.Equal(t, "hello tcp", string(got))
+
+	l := p.listeners[0]
+	require.Eventually(t, func() bool { return l.stats.active.Load() == 0 }, time.Second, 10*time.Millisecond)
+	assert.EqualValues(t, 9, l.stats.received.Load())
+	assert.EqualValues(t, 9, l.stats.sent.Load())
+	assert.Zero(t, backend.GetActiveConns())
+	assert.EqualValues(t, 1, l.stats.accepted.Load())
+}
+
+func TestProxy_ClosesIdleConnection(t *testing.T) {
+	_, addr := startProxy(t, echoBackend(t), WithTimeouts(time.Second, 100*time.Millisecond))
+
+	c, err := net.Dial("tcp", addr)
+	require.NoError(t, err)
+	defer c.Close()
+
+	start := time.Now()
+	c.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, err = c.Read(make([]byte, 1))
+	assert.ErrorIs(t, err, io.EOF)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestProxy_ShutdownDrainsConnections(t *testing.T) {
+	backend := echoBackend(t)
+	p, addr := startProxy(t, backend, WithTimeouts(time.Second, time.Minute))
+
+	c, err := net.Dial("tcp", addr)
+	require.NoError(t, err)
+	defer c.Close()
+	require.Eventually(t, func() bool { return backend.GetActiveConns() == 1 }, time.Second, 10*time.Millisecond)
+
+	// ket noi dang mo van chay trong luc drain, listener khong nhan ket noi moi
+	done := make(chan error, 1)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		done <- p.Shutdown(ctx)
+	}()
+
+	require.Eventually(t, func() bool {
+		_, err := net.DialTimeout("tcp", addr, 100*time.Millisecond)
+		return err != nil
+	}, time.Second, 10*time.Millisecond)
+
+	_, err = c.Write([]byte("ping"))
+	require.NoError(t, err)
+	buf := make([]byte, 4)
+	_, err = io.ReadFull(c, buf)
+	require.NoError(t, err)
+	assert.Equal(t, "ping", string(buf))
+
+	c.Close()
+	require.NoError(t, <-done)
+}
+
+func TestProxy_ShutdownClosesConnectionsAfterDeadline(t *testing.T) {
+	p, addr := startProxy(t, echoBackend(t), WithTimeouts(time.Second, time.Minute))
+
+	c, err := net.Dial("tcp", addr)
+	require.NoError(t, err)
+	defer c.Close()
+	require.Eventually(t, func() bool { return p.listeners[0].stats.active.Load() == 1 }, time.Second, 10*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, p.Shutdown(ctx), context.DeadlineExceeded)
+
+	c.SetReadDeadline(time.Now().Add(time.Second))
+	_, err = c.Read(make([]byte, 1))
+	assert.Error(t, err)
+}
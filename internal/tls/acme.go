@@ -0,0 +1,160 @@
+package tls
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"net/http"
+	"sort"
+	"time"
+
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+const acmeWarmupTimeout = 5 * time.Minute
+
+/*
+*Cap va gia han chung chi tu dong qua ACME (Let's Encrypt, Pebble, ...).
+*HTTP-01 phuc vu tren listener HTTP, TLS-ALPN-01 tra loi trong GetConfigForClient
+ */
+type ACMEConfig struct {
+	Domains      []string
+	Email        string
+	DirectoryURL string         // rong = Let's Encrypt production
+	RenewBefore  time.Duration  // gia han truoc khi het han bao lau, rong = 30 ngay
+	Cache        autocert.Cache // noi luu account key va chung chi (thu muc hoac redis)
+	RootCAs      *x509.CertPool // CA tin cay khi goi ACME server, dung cho Pebble
+}
+
+func WithACME(cfg ACMEConfig) Option {
+	return func(m *ManagerSTL) {
+		domains := make(map[string]bool, len(cfg.Domains))
+		for _, d := range cfg.Domains {
+			domains[normalizeName(d)] = true
+		}
+
+		client := &acme.Client{DirectoryURL: cfg.DirectoryURL}
+		if cfg.RootCAs != nil {
+			transport := http.DefaultTransport.(*http.Transport).Clone()
+			transport.TLSClientConfig = &tls.Config{RootCAs: cfg.RootCAs, MinVersion: tls.VersionTLS12}
+			client.HTTPClient = &http.Client{Transport: transport}
+		}
+
+		m.acmeDomains = domains
+		m.acme = &autocert.Manager{
+			Prompt:      autocert.AcceptTOS,
+			Cache:       cfg.Cache,
+			HostPolicy:  autocert.HostWhitelist(cfg.Domains...),
+			RenewBefore: cfg.RenewBefore,
+			Email:       cfg.Email,
+			Client:      client,
+		}
+	}
+}
+
+/*
+*Boc handler cua listener HTTP de tra loi HTTP-01 challenge, cac request khac di tiep vao fallback
+ */
+func (m *ManagerSTL) HTTPHandler(fallback http.Handler) http.Handler {
+	if m.acme == nil {
+		return fallback
+	}
+	return m.acme.HTTPHandler(fallback)
+}
+
+func isACMEChallenge(chi *tls.ClientHelloInfo) bool {
+	return len(chi.SupportedProtos) == 1 && chi.SupportedProtos[0] == acme.ALPNProto
+}
+
+/*
+*Chung chi ACME cho domain duoc cau hinh, autocert tu gia han khi gan het han.
+*ok = false nghia la domain khong thuoc ACME
+ */
+func (m *ManagerSTL) acmeCertificate(chi *tls.ClientHelloInfo) (*tls.Certificate, bool, error) {
+	if m.acme == nil || !m.acmeDomains[normalizeName(chi.ServerName)] {
+		return nil, false, nil
+	}
+	cert, err := m.acme.GetCertificate(chi)
+	return cert, true, err
+}
+
+/*
+*Chung chi ACME dang luu trong cache (thu muc hoac redis), domain chua duoc cap thi bo qua
+ */
+func (m *ManagerSTL) acmeLeaves(ctx context.Context) []*x509.Certificate {
+	if m.acme == nil || m.acme.Cache == nil {
+		return nil
+	}
+
+	domains := make([]string, 0, len(m.acmeDomains))
+	for d := range m.acmeDomains {
+		domains = append(domains, d)
+	}
+	sort.Strings(domains)
+
+	leaves := make([]*x509.Certificate, 0, len(domains))
+	for _, domain := range domains {
+		// autocert luu key ECDSA va chuoi cert PEM duoi ten domain
+		data, err := m.acme.Cache.Get(ctx, domain)
+		if err != nil {
+			continue
+		}
+		for {
+			var block *pem.Block
+			if block, data = pem.Decode(data); block == nil {
+				break
+			}
+			if block.Type != "CERTIFICATE" {
+				continue
+			}
+			if leaf, err := x509.ParseCertificate(block.Bytes); err == nil {
+				leaves = append(leaves, leaf)
+			}
+			break
+		}
+	}
+	return leaves
+}
+
+/*
+*Lay chung chi cho moi domain ngay khi khoi dong thay vi doi client dau tien
+ */
+func (m *ManagerSTL) warmupACME(ctx context.Context) {
+	defer m.wg.Done()
+
+	for domain := range m.acmeDomains {
+		if ctx.Err() != nil {
+			return
+		}
+
+		// hello gia lap client ho tro ECDSA de autocert cap cert ECDSA
+		hello := &tls.ClientHelloInfo{
+			ServerName:       domain,
+			SignatureSchemes: []tls.SignatureScheme{tls.ECDSAWithP256AndSHA256},
+			SupportedCurves:  []tls.CurveID{tls.CurveP256},
+			CipherSuites:     []uint16{tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256},
+		}
+
+		start := time.Now()
+		done := make(chan error, 1)
+		go func() {
+			_, err := m.acme.GetCertificate(hello)
+			done <- err
+		}()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(acmeWarmupTimeout):
+			m.logger.Warn("ACME certificate request is still pending", "domain", domain)
+		case err := <-done:
+			if err != nil {
+				m.logger.Error("Failed to obtain ACME certificate", "domain", domain, "error", err)
+				continue
+			}
+			m.logger.Info("ACME certificate ready", "domain", domain, "took", time.Since(start).Round(time.Millisecond))
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package tls
+
+import (
+	"context"
+	"time"
+
+	"golang.org/x/crypto/acme/autocert"
+)
+
+const acmeCachePrefix = "lb:acme:"
+
+/*
+*Phan cua cache.CacheClient can de luu chung chi, tach interface de tls khong phu thuoc redis
+ */
+type StringStore interface {
+	GetString(ctx context.Context, key string) (string, error) // "" khi khong co key
+	SetString(ctx context.Context, key string, value string, expiration time.Duration) error
+	Del(ctx context.Context, keys ...string) (int64, error)
+}
+
+/*
+*autocert.Cache luu trong redis de cac replica dung chung account key va chung chi
+ */
+type RedisCache struct {
+	store StringStore
+}
+
+func NewRedisCache(store StringStore) *RedisCache {
+	return &RedisCache{store: store}
+}
+
+func (c *RedisCache) Get(ctx context.Context, key string) ([]byte, error) {
+	value, err := c.store.GetString(ctx, acmeCachePrefix+key)
+	if err != nil {
+		return nil, err
+	}
+	if value == "" {
+		return nil, autocert.ErrCacheMiss
+	}
+	return []byte(value), nil
+}
+
+func (c *RedisCache) Put(ctx context.Context, key string, data []byte) error {
+	return c.store.SetString(ctx, acmeCachePrefix+key, string(data), 0)
+}
+
+func (c *RedisCache) Delete(ctx context.Context, key string) error {
+	_, err := c.store.Del(ctx, acmeCachePrefix+key)
+	return err
+}
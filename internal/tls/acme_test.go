@@ -0,0 +1,101 @@
+package tls
+
+import (
+	"context"
+	"crypto/tls"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+type memStore struct {
+	mu   sync.Mutex
+	data map[string]string
+}
+
+func (s *memStore) GetString(_ context.Context, key string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.data[key], nil
+}
+
+func (s *memStore) SetString(_ context.Context, key, value string, _ time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data[key] = value
+	return nil
+}
+
+func (s *memStore) Del(_ context.Context, keys ...string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, k := range keys {
+		delete(s.data, k)
+	}
+	return int64(len(keys)), nil
+}
+
+func TestRedisCache(t *testing.T) {
+	ctx := context.Background()
+	store := &memStore{data: map[string]string{}}
+	c := NewRedisCache(store)
+
+	_, err := c.Get(ctx, "example.com")
+	assert.ErrorIs(t, err, autocert.ErrCacheMiss)
+
+	require.NoError(t, c.Put(ctx, "example.com", []byte("pem")))
+	assert.Equal(t, "pem", store.data["lb:acme:example.com"])
+
+	data, err := c.Get(ctx, "example.com")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("pem"), data)
+
+	require.NoError(t, c.Delete(ctx, "example.com"))
+	_, err = c.Get(ctx, "example.com")
+	assert.ErrorIs(t, err, autocert.ErrCacheMiss)
+}
+
+/*
+*Chay voi Pebble cuc bo (PEBBLE_VA_ALWAYS_VALID=1 de bo qua xac thuc challenge).
+*LB_PEBBLE_DIRECTORY la directory URL cua Pebble, LB_PEBBLE_CA la file CA cua Pebble
+ */
+func TestACME_IssuesCertificateFromPebble(t *testing.T) {
+	directory, caFile := os.Getenv("LB_PEBBLE_DIRECTORY"), os.Getenv("LB_PEBBLE_CA")
+	if directory == "" || caFile == "" {
+		t.Skip("LB_PEBBLE_DIRECTORY and LB_PEBBLE_CA are not set")
+	}
+
+	roots, err := LoadCAPool(caFile)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	m, err := NewManagerSTL(dir, WithACME(ACMEConfig{
+		Domains:      []string{"lb.test"},
+		Email:        "ops@lb.test",
+		DirectoryURL: directory,
+		Cache:        autocert.DirCache(dir + "/acme"),
+		RootCAs:      roots,
+	}))
+	require.NoError(t, err)
+	require.NoError(t, m.Start())
+	defer m.Stop()
+
+	cfg, err := m.GetTLSConfig().GetConfigForClient(&tls.ClientHelloInfo{
+		ServerName:       "lb.test",
+		SignatureSchemes: []tls.SignatureScheme{tls.ECDSAWithP256AndSHA256},
+		SupportedCurves:  []tls.CurveID{tls.CurveP256},
+		CipherSuites:     []uint16{tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256},
+	})
+	require.NoError(t, err)
+	require.Len(t, cfg.Certificates, 1)
+
+	leaf := cfg.Certificates[0].Leaf
+	require.NotNil(t, leaf)
+	assert.Contains(t, leaf.DNSNames, "lb.test")
+	assert.NotEqual(t, leaf.Subject.String(), leaf.Issuer.String())
+}
@@ -0,0 +1,105 @@
+package tls
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"math"
+	"time"
+)
+
+var (
+	ErrNoCertificate      = errors.New("no TLS certificate loaded")
+	ErrCertificateExpired = errors.New("certificate has expired")
+)
+
+const (
+	CertSourceFile = "file"
+	CertSourceACME = "acme"
+)
+
+// doc cache ACME (redis) khi lay thong tin, khong de admin API hay kiem tra het han bi treo
+const acmeInfoTimeout = 3 * time.Second
+
+type CertificateInfo struct {
+	Subject           string    `json:"subject"`
+	Issuer            string    `json:"issuer"`
+	DNSNames          []string  `json:"dnsNames,omitempty"`
+	IPAddresses       []string  `json:"ipAddresses,omitempty"`
+	SerialNumber      string    `json:"serialNumber"`
+	NotBefore         time.Time `json:"notBefore"`
+	NotAfter          time.Time `json:"notAfter"`
+	ExpiresIn         string    `json:"expiresIn"`
+	DaysLeft          int       `json:"daysLeft"`
+	Status            string    `json:"status"` // ok | expiring | critical | expired
+	FingerprintSHA256 string    `json:"fingerprintSHA256"`
+	Source            string    `json:"source"` // file | acme
+	CertFile          string    `json:"certFile,omitempty"`
+	Default           bool      `json:"default"` // dung khi SNI khong khop domain nao
+}
+
+/*
+*Thong tin cac chung chi dang phuc vu (file va ACME), dung cho admin API
+ */
+func (m *ManagerSTL) CertificateInfo() ([]CertificateInfo, error) {
+	infos := m.certificateInfos(time.Now())
+	if len(infos) == 0 {
+		return nil, ErrNoCertificate
+	}
+	return infos, nil
+}
+
+func (m *ManagerSTL) certificateInfos(now time.Time) []CertificateInfo {
+	m.mux.RLock()
+	store := m.store
+	m.mux.RUnlock()
+
+	certs := store.snapshot()
+	infos := make([]CertificateInfo, 0, len(certs))
+	for _, c := range certs {
+		info := newCertificateInfo(c.leaf, now)
+		info.Source = CertSourceFile
+		info.CertFile = c.pair.CertFile
+		info.Default = store.isDefault(c)
+		infos = append(infos, info)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), acmeInfoTimeout)
+	defer cancel()
+	for _, leaf := range m.acmeLeaves(ctx) {
+		info := newCertificateInfo(leaf, now)
+		info.Source = CertSourceACME
+		infos = append(infos, info)
+	}
+
+	return infos
+}
+
+func newCertificateInfo(leaf *x509.Certificate, now time.Time) CertificateInfo {
+	ips := make([]string, 0, len(leaf.IPAddresses))
+	for _, ip := range leaf.IPAddresses {
+		ips = append(ips, ip.String())
+	}
+
+	days := daysLeft(leaf.NotAfter, now)
+	return CertificateInfo{
+		Subject:           leaf.Subject.String(),
+		Issuer:            leaf.Issuer.String(),
+		DNSNames:          leaf.DNSNames,
+		IPAddresses:       ips,
+		SerialNumber:      leaf.SerialNumber.String(),
+		NotBefore:         leaf.NotBefore,
+		NotAfter:          leaf.NotAfter,
+		ExpiresIn:         leaf.NotAfter.Sub(now).Round(time.Second).String(),
+		DaysLeft:          days,
+		Status:            expiryStatus(days),
+		FingerprintSHA256: Fingerprint(leaf),
+	}
+}
+
+/*
+*So ngay tron con lai, am khi da het han
+ */
+func daysLeft(notAfter, now time.Time) int {
+	return int(math.Floor(notAfter.Sub(now).Hours() / 24))
+}
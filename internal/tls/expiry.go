@@ -0,0 +1,160 @@
+package tls
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	expiryCheckInterval = time.Hour
+	// tu nguong cuoi (7 ngay) tro xuong thi nhac lai moi ngay
+	expiryRepeat = 24 * time.Hour
+)
+
+const (
+	ExpiryOK       = "ok"
+	ExpiryWarning  = "expiring"
+	ExpiryCritical = "critical"
+	ExpiryExpired  = "expired"
+)
+
+// nguong canh bao theo so ngay con lai, giam dan
+var expiryThresholds = []int{30, 14, 7}
+
+func expiryStatus(days int) string {
+	switch {
+	case days < 0:
+		return ExpiryExpired
+	case days <= expiryThresholds[len(expiryThresholds)-1]:
+		return ExpiryCritical
+	case days <= expiryThresholds[0]:
+		return ExpiryWarning
+	default:
+		return ExpiryOK
+	}
+}
+
+/*
+*Nguong nho nhat ma cert da vuot qua, 0 khi chua toi nguong nao, -1 khi da het han
+ */
+func expiryLevel(days int) int {
+	if days < 0 {
+		return -1
+	}
+	level := 0
+	for _, t := range expiryThresholds {
+		if days <= t {
+			level = t
+		}
+	}
+	return level
+}
+
+type expiryNotice struct {
+	level int
+	at    time.Time
+}
+
+/*
+*Nho lan bao gan nhat cua tung cert (theo fingerprint) de moi nguong chi bao 1 lan
+ */
+type expiryMonitor struct {
+	mu       sync.Mutex
+	notified map[string]expiryNotice
+}
+
+func newExpiryMonitor() *expiryMonitor {
+	return &expiryMonitor{notified: make(map[string]expiryNotice)}
+}
+
+func (e *expiryMonitor) due(fingerprint string, level int, now time.Time) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if level == 0 {
+		delete(e.notified, fingerprint)
+		return false
+	}
+
+	// het han (-1) la muc khan cap nhat
+	rank := func(l int) int {
+		if l < 0 {
+			return 0
+		}
+		return l
+	}
+
+	prev, ok := e.notified[fingerprint]
+	escalated := !ok || rank(level) < rank(prev.level)
+	repeat := rank(level) <= expiryThresholds[len(expiryThresholds)-1] && now.Sub(prev.at) >= expiryRepeat
+	if !escalated && !repeat {
+		return false
+	}
+
+	e.notified[fingerprint] = expiryNotice{level: level, at: now}
+	return true
+}
+
+func (e *expiryMonitor) prune(seen map[string]bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for fp := range e.notified {
+		if !seen[fp] {
+			delete(e.notified, fp)
+		}
+	}
+}
+
+/*
+*Ghi log khi cert sap het han: warn o 30 va 14 ngay, error tu 7 ngay va khi da het han
+ */
+func (m *ManagerSTL) checkExpiry(now time.Time) {
+	infos := m.certificateInfos(now)
+
+	seen := make(map[string]bool, len(infos))
+	for _, info := range infos {
+		seen[info.FingerprintSHA256] = true
+
+		level := expiryLevel(info.DaysLeft)
+		if !m.expiry.due(info.FingerprintSHA256, level, now) {
+			continue
+		}
+
+		attrs := []any{
+			"subject", info.Subject,
+			"names", info.DNSNames,
+			"cert_source", info.Source,
+			"cert", info.CertFile,
+			"not_after", info.NotAfter.Format(time.RFC3339),
+			"days_left", info.DaysLeft,
+		}
+		switch {
+		case level < 0:
+			m.logger.Error("TLS certificate has expired", attrs...)
+		case level <= expiryThresholds[len(expiryThresholds)-1]:
+			m.logger.Error("TLS certificate expires soon", attrs...)
+		default:
+			m.logger.Warn("TLS certificate expires soon", attrs...)
+		}
+	}
+
+	m.expiry.prune(seen)
+}
+
+func (m *ManagerSTL) expiryLoop(ctx context.Context) {
+	defer m.wg.Done()
+
+	ticker := time.NewTicker(expiryCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.checkExpiry(time.Now())
+		}
+	}
+}
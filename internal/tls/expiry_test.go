@@ -0,0 +1,53 @@
+package tls
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCertStore_RefusesExpiredOrMismatchedCert(t *testing.T) {
+	dir := t.TempDir()
+	pair := CertPair{CertFile: filepath.Join(dir, "site.crt"), KeyFile: filepath.Join(dir, "site.key")}
+	writePair(t, pair.CertFile, pair.KeyFile, "site.example.com")
+
+	s := NewCertStore()
+	require.NoError(t, s.Load(pair))
+	serving := s.Select("site.example.com").Leaf
+
+	writePairExpiring(t, pair.CertFile, pair.KeyFile, time.Now().Add(-time.Hour), "site.example.com")
+	assert.ErrorIs(t, s.Load(pair), ErrCertificateExpired)
+
+	// cert moi nhung key cua cap khac
+	other := filepath.Join(dir, "other")
+	writePair(t, pair.CertFile, other+".key", "site.example.com")
+	writePair(t, other+".crt", pair.KeyFile, "other.example.com")
+	require.Error(t, s.Load(pair))
+
+	assert.Equal(t, serving.SerialNumber, s.Select("site.example.com").Leaf.SerialNumber)
+}
+
+func TestExpiryMonitor_EscalatesAtThresholds(t *testing.T) {
+	e := newExpiryMonitor()
+	now := time.Now()
+	fp := "abc"
+
+	assert.False(t, e.due(fp, expiryLevel(45), now))
+	assert.True(t, e.due(fp, expiryLevel(30), now))
+	assert.False(t, e.due(fp, expiryLevel(20), now.Add(10*24*time.Hour)))
+	assert.True(t, e.due(fp, expiryLevel(14), now))
+	assert.True(t, e.due(fp, expiryLevel(7), now))
+
+	// tu 7 ngay tro xuong nhac lai moi ngay
+	assert.False(t, e.due(fp, expiryLevel(6), now.Add(time.Hour)))
+	assert.True(t, e.due(fp, expiryLevel(6), now.Add(expiryRepeat)))
+	assert.True(t, e.due(fp, expiryLevel(-1), now.Add(expiryRepeat)))
+
+	assert.Equal(t, ExpiryWarning, expiryStatus(30))
+	assert.Equal(t, ExpiryCritical, expiryStatus(7))
+	assert.Equal(t, ExpiryExpired, expiryStatus(-1))
+	assert.Equal(t, ExpiryOK, expiryStatus(31))
+}
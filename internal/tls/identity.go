@@ -0,0 +1,100 @@
+package tls
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"strings"
+)
+
+/*
+*Danh tinh lay tu client cert cua ket noi. Verified = false khi listener chi yeu cau
+*cert ma khong kiem tra voi CA (mode optional), khong dung de phan quyen
+ */
+type ClientIdentity struct {
+	Subject     string
+	CommonName  string
+	Issuer      string
+	SANs        []string // DNS, email, URI va IP
+	Fingerprint string   // hex SHA-256 cua cert, chu thuong
+	Serial      string
+	Verified    bool
+}
+
+/*
+*nil khi client khong gui cert hoac ket noi khong phai TLS
+ */
+func IdentityOf(cs *tls.ConnectionState) *ClientIdentity {
+	if cs == nil || len(cs.PeerCertificates) == 0 {
+		return nil
+	}
+	leaf := cs.PeerCertificates[0]
+
+	sans := append([]string(nil), leaf.DNSNames...)
+	sans = append(sans, leaf.EmailAddresses...)
+	for _, u := range leaf.URIs {
+		sans = append(sans, u.String())
+	}
+	for _, ip := range leaf.IPAddresses {
+		sans = append(sans, ip.String())
+	}
+
+	return &ClientIdentity{
+		Subject:     leaf.Subject.String(),
+		CommonName:  leaf.Subject.CommonName,
+		Issuer:      leaf.Issuer.String(),
+		SANs:        sans,
+		Fingerprint: Fingerprint(leaf),
+		Serial:      leaf.SerialNumber.Text(16),
+		Verified:    len(cs.VerifiedChains) > 0,
+	}
+}
+
+func Fingerprint(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.Raw)
+	return hex.EncodeToString(sum[:])
+}
+
+/*
+*Chap nhan dang AB:CD:... hoac abcd..., tra ve "" neu khong phai SHA-256
+ */
+func NormalizeFingerprint(s string) string {
+	s = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(s), ":", ""))
+	if raw, err := hex.DecodeString(s); err != nil || len(raw) != sha256.Size {
+		return ""
+	}
+	return s
+}
+
+/*
+*Khop neu cert da verify va trung bat ky subject (CN hoac DN day du), SAN hoac fingerprint nao.
+*Ca 3 danh sach rong thi moi cert da verify deu duoc
+ */
+func (id *ClientIdentity) Matches(subjects, sans, fingerprints []string) bool {
+	if id == nil || !id.Verified {
+		return false
+	}
+	if len(subjects) == 0 && len(sans) == 0 && len(fingerprints) == 0 {
+		return true
+	}
+
+	for _, s := range subjects {
+		if s == id.CommonName || s == id.Subject {
+			return true
+		}
+	}
+	for _, s := range sans {
+		for _, san := range id.SANs {
+			if strings.EqualFold(s, san) {
+				return true
+			}
+		}
+	}
+	for _, f := range fingerprints {
+		if NormalizeFingerprint(f) == id.Fingerprint {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,49 @@
+package tls
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientIdentity_Matches(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "client.crt"), filepath.Join(dir, "client.key")
+	writePair(t, certFile, keyFile, "billing-worker", "billing.internal")
+
+	pair, err := tls.LoadX509KeyPair(certFile, keyFile)
+	require.NoError(t, err)
+	leaf := pair.Leaf
+
+	assert.Nil(t, IdentityOf(&tls.ConnectionState{}))
+
+	// cert khong verify (mode optional) khong duoc dung de phan quyen
+	unverified := IdentityOf(&tls.ConnectionState{PeerCertificates: []*x509.Certificate{leaf}})
+	assert.False(t, unverified.Verified)
+	assert.False(t, unverified.Matches(nil, nil, nil))
+
+	id := IdentityOf(&tls.ConnectionState{
+		PeerCertificates: []*x509.Certificate{leaf},
+		VerifiedChains:   [][]*x509.Certificate{{leaf}},
+	})
+	assert.Equal(t, "CN=billing-worker", id.Subject)
+	assert.Equal(t, []string{"billing-worker", "billing.internal"}, id.SANs)
+
+	assert.True(t, id.Matches(nil, nil, nil))
+	assert.True(t, id.Matches([]string{"billing-worker"}, nil, nil))
+	assert.True(t, id.Matches(nil, []string{"BILLING.internal"}, nil))
+	assert.False(t, id.Matches([]string{"other"}, []string{"other.internal"}, nil))
+
+	// fingerprint dang AB:CD:... cung khop
+	var colon []string
+	for i := 0; i < len(id.Fingerprint); i += 2 {
+		colon = append(colon, strings.ToUpper(id.Fingerprint[i:i+2]))
+	}
+	assert.True(t, id.Matches([]string{"other"}, nil, []string{strings.Join(colon, ":")}))
+	assert.Empty(t, NormalizeFingerprint("abcd"))
+}
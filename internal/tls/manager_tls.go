@@ -12,15 +12,24 @@ import (
 	"time"
 
 	"github.com/fsnotify/fsnotify"
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
 )
 
 type ManagerSTL struct {
-	mux        sync.RWMutex
-	certFile   string
-	keyFile    string
-	rootCAs    *x509.CertPool     //danh sach chung chi ma minh chap nhan tu client goi den
-	config     *tls.Config        //chua thong tin chung chi dang dung
-	clientAuth tls.ClientAuthType //cac che do kiem tra client
+	mux     sync.RWMutex
+	certDir string
+	pairs   []CertPair          // cap khai bao trong config, rong thi quet certDir
+	store   *CertStore          // chung chi chon theo SNI
+	pending map[string]struct{} // file thay doi cho watcher nap lai
+
+	acme        *autocert.Manager // nil khi khong dung ACME
+	expiry      *expiryMonitor
+	acmeDomains map[string]bool
+	rootCAs     *x509.CertPool     //danh sach chung chi ma minh chap nhan tu client goi den
+	config      *tls.Config        //chua thong tin chung chi dang dung
+	clientAuth  tls.ClientAuthType //cac che do kiem tra client
+	nextProtos  []string           //giao thuc ALPN chao client, vd h2 va http/1.1
 
 	logger *slog.Logger
 
@@ -37,6 +46,9 @@ type ManagerSTL struct {
 
 type Option func(*ManagerSTL)
 
+/*
+*Kiem tra client cert tren listener HTTPS. CAPool nil chi dung duoc voi RequestClientCert
+ */
 func WithClientAuth(CAPool *x509.CertPool, auth tls.ClientAuthType) Option {
 	return func(m *ManagerSTL) {
 		m.rootCAs = CAPool
@@ -44,6 +56,25 @@ func WithClientAuth(CAPool *x509.CertPool, auth tls.ClientAuthType) Option {
 	}
 }
 
+/*
+*Chi dung cac cap duoc liet ke thay vi quet thu muc, duong dan tuong doi tinh tu certDir
+ */
+func WithCertificates(pairs []CertPair) Option {
+	return func(m *ManagerSTL) {
+		m.pairs = append([]CertPair(nil), pairs...)
+	}
+}
+
+/*
+*Giao thuc ALPN cho client. Config tra ve tu GetConfigForClient thay the config cua http.Server
+*nen NextProtos ma net/http them vao (h2) khong con, phai khai bao o day
+ */
+func WithNextProtos(protos ...string) Option {
+	return func(m *ManagerSTL) {
+		m.nextProtos = append([]string(nil), protos...)
+	}
+}
+
 func WithLogger(logger *slog.Logger) Option {
 	return func(m *ManagerSTL) {
 		m.logger = logger
@@ -57,9 +88,11 @@ func NewManagerSTL(certDir string, opts ...Option) (*ManagerSTL, error) {
 	}
 
 	m := &ManagerSTL{
-		certFile:   filepath.Join(absDir, "cert.pem"),
-		keyFile:    filepath.Join(absDir, "key.pem"),
+		certDir:    absDir,
+		store:      NewCertStore(),
+		pending:    make(map[string]struct{}),
 		clientAuth: tls.NoClientCert,
+		expiry:     newExpiryMonitor(),
 		logger:     slog.Default(),
 		done:       make(chan struct{}),
 		reloadChan: make(chan struct{}, 1),
@@ -69,9 +102,14 @@ func NewManagerSTL(certDir string, opts ...Option) (*ManagerSTL, error) {
 		opt(m)
 	}
 
+	for i, p := range m.pairs {
+		m.pairs[i].CertFile = m.resolve(p.CertFile)
+		m.pairs[i].KeyFile = m.resolve(p.KeyFile)
+	}
+
 	m.logger.Info("TLS Manager initialized with auto-reload",
-		"cert_file", m.certFile,
-		"key_file", m.keyFile,
+		"cert_dir", m.certDir,
+		"pairs", len(m.pairs),
 	)
 
 	return m, nil
@@ -81,20 +119,51 @@ func (m *ManagerSTL) Reload() error {
 	return m.reload()
 }
 
+func (m *ManagerSTL) resolve(path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(m.certDir, path)
+}
+
+/*
+*Nap lai toan bo chung chi vao kho moi. Cap khai bao trong config loi thi that bai,
+*cap tim thay khi quet thu muc loi thi bo qua
+ */
 func (m *ManagerSTL) reload() error {
-	m.mux.Lock()
-	defer m.mux.Unlock()
+	explicit := len(m.pairs) > 0
+	pairs := m.pairs
+	if !explicit {
+		// chi dung ACME thi thu muc chung chi co the chua ton tai
+		if m.acme != nil {
+			if err := os.MkdirAll(m.certDir, 0700); err != nil {
+				return err
+			}
+		}
 
-	//kierm tra cap key
-	cert, err := tls.LoadX509KeyPair(m.certFile, m.keyFile)
-	if err != nil {
-		m.logger.Error("Failed to load TLS cert/key", "error", err, "cert", m.certFile, "key", m.keyFile)
-		return err
+		var err error
+		if pairs, err = DiscoverPairs(m.certDir); err != nil {
+			m.logger.Error("Failed to scan certificate directory", "dir", m.certDir, "error", err)
+			return err
+		}
+	}
+
+	store := NewCertStore()
+	for _, p := range pairs {
+		if err := store.Load(p); err != nil {
+			if explicit {
+				m.logger.Error("Failed to load TLS cert/key", "error", err, "cert", p.CertFile, "key", p.KeyFile)
+				return err
+			}
+			m.logger.Warn("Skipping invalid TLS cert/key", "error", err, "cert", p.CertFile, "key", p.KeyFile)
+		}
+	}
+	if store.Len() == 0 && m.acme == nil {
+		return fmt.Errorf("%w in %s", ErrNoCertificate, m.certDir)
 	}
 
 	newConfig := &tls.Config{
-		Certificates: []tls.Certificate{cert}, // privatekey giup chung minh ra day chinh la server co domain nay
-		MinVersion:   tls.VersionTLS12,
+		MinVersion: tls.VersionTLS12,
 		// giao tiep cleint va chon laoi toan hoc ma hoa trao doi public va cung gen ra 1 cap key moi
 		// no ma hoa data trong seqment
 		CurvePreferences: []tls.CurveID{
@@ -103,27 +172,106 @@ func (m *ManagerSTL) reload() error {
 		},
 	}
 
-	if m.rootCAs != nil {
-		newConfig.ClientAuth = m.clientAuth
-		newConfig.ClientCAs = m.rootCAs
-	}
+	newConfig.ClientAuth = m.clientAuth
+	newConfig.ClientCAs = m.rootCAs
+	newConfig.NextProtos = m.nextProtos
 
+	m.mux.Lock()
 	m.config = newConfig
+	m.store = store
+	m.mux.Unlock()
 
-	m.logger.Info("TLS certificate reloaded successfully",
-		"cert_file", m.certFile,
-		"key_file", m.keyFile,
+	m.logger.Info("TLS certificates reloaded successfully",
+		"cert_dir", m.certDir,
+		"certificates", store.Len(),
 		"timestamp", time.Now().Format(time.RFC3339),
 	)
 
+	m.checkExpiry(time.Now())
 	return nil
 }
 
+/*
+*Nap lai rieng cac cap co file thay doi, cap loi van giu ban cu.
+*Khi quet thu muc thi file moi duoc them va cert bi xoa duoc go khoi kho
+ */
+func (m *ManagerSTL) reloadChanged(files []string) {
+	defer m.checkExpiry(time.Now())
+
+	m.mux.RLock()
+	store := m.store
+	m.mux.RUnlock()
+
+	rescan := false
+	reloaded := make(map[string]bool)
+	for _, file := range files {
+		pair, ok := store.PairOf(file)
+		if !ok {
+			rescan = rescan || len(m.pairs) == 0
+			continue
+		}
+		if reloaded[pair.CertFile] {
+			continue
+		}
+		reloaded[pair.CertFile] = true
+
+		if _, err := os.Stat(pair.CertFile); os.IsNotExist(err) && len(m.pairs) == 0 {
+			// khong go cert cuoi cung, handshake se that bai het
+			if store.Len() > 1 && store.Remove(pair.CertFile) {
+				m.logger.Info("TLS certificate removed", "cert", pair.CertFile)
+			}
+			continue
+		}
+
+		if err := store.Load(pair); err != nil {
+			m.logger.Warn("Failed to reload TLS certificate, keeping previous one", "cert", pair.CertFile, "error", err)
+			continue
+		}
+		m.logger.Info("TLS certificate reloaded", "cert", pair.CertFile)
+	}
+
+	if !rescan {
+		return
+	}
+
+	pairs, err := DiscoverPairs(m.certDir)
+	if err != nil {
+		m.logger.Warn("Failed to scan certificate directory", "dir", m.certDir, "error", err)
+		return
+	}
+	for _, p := range pairs {
+		if _, known := store.PairOf(p.CertFile); known {
+			continue
+		}
+		if err := store.Load(p); err != nil {
+			m.logger.Warn("Skipping invalid TLS cert/key", "cert", p.CertFile, "error", err)
+			continue
+		}
+		m.logger.Info("TLS certificate added", "cert", p.CertFile)
+	}
+}
+
+/*
+*Cac thu muc can theo doi: certDir va thu muc cua tung cap khai bao
+ */
+func (m *ManagerSTL) watchDirs() []string {
+	seen := map[string]bool{m.certDir: true}
+	dirs := []string{m.certDir}
+	for _, p := range m.pairs {
+		for _, dir := range []string{filepath.Dir(p.CertFile), filepath.Dir(p.KeyFile)} {
+			if !seen[dir] {
+				seen[dir] = true
+				dirs = append(dirs, dir)
+			}
+		}
+	}
+	return dirs
+}
+
 func (m *ManagerSTL) Start() error {
 	var err error
 	m.startOne.Do(func() {
 		m.ctx, m.cancel = context.WithCancel(context.Background())
-		m.wg.Add(1)
 		//thuc hien kiem tra den dia chi ram co du lieu khong
 		if err = m.reload(); err != nil {
 			m.logger.Error("initial TLS load failed", "err", err)
@@ -134,6 +282,14 @@ func (m *ManagerSTL) Start() error {
 			m.logger.Error("failed to start fsnotify watcher", "err", err)
 			return
 		}
+
+		if m.acme != nil {
+			m.wg.Add(1)
+			go m.warmupACME(m.ctx)
+		}
+
+		m.wg.Add(1)
+		go m.expiryLoop(m.ctx)
 	})
 
 	return err
@@ -161,14 +317,15 @@ func (m *ManagerSTL) startWatcher() error {
 
 	m.watcher = watcher
 
-	dir := filepath.Dir(m.certFile)
-	if err = watcher.Add(dir); err != nil {
-		m.logger.Error("Failed to watch directory", "dir", dir, "error", err)
-		return err
+	for _, dir := range m.watchDirs() {
+		if err = watcher.Add(dir); err != nil {
+			m.logger.Error("Failed to watch directory", "dir", dir, "error", err)
+			return err
+		}
+		m.logger.Info("Started fsnotify watcher on directory", "dir", dir)
 	}
 
-	m.logger.Info("Started fsnotify watcher on directory", "dir", dir)
-
+	m.wg.Add(1)
 	go func() {
 		defer m.wg.Done()
 		for {
@@ -184,14 +341,15 @@ func (m *ManagerSTL) startWatcher() error {
 					event.Has(fsnotify.Create) ||
 					event.Has(fsnotify.Rename) ||
 					event.Has(fsnotify.Remove) {
-					if filepath.Base(event.Name) == filepath.Base(m.certFile) ||
-						filepath.Base(event.Name) == filepath.Base(m.keyFile) {
-						m.logger.Debug("Detected certificate file change", "event", event)
-
-						select {
-						case m.reloadChan <- struct{}{}:
-						default:
-						}
+					m.logger.Debug("Detected certificate file change", "event", event)
+
+					m.mux.Lock()
+					m.pending[event.Name] = struct{}{}
+					m.mux.Unlock()
+
+					select {
+					case m.reloadChan <- struct{}{}:
+					default:
 					}
 				}
 			case err, ok := <-watcher.Errors:
@@ -201,9 +359,15 @@ func (m *ManagerSTL) startWatcher() error {
 				}
 				m.logger.Warn("fsnotify error", "error", err)
 			case <-m.reloadChan:
-				if err := m.reload(); err != nil {
-					m.logger.Warn("Having error in reload config tls", "error", err)
+				m.mux.Lock()
+				files := make([]string, 0, len(m.pending))
+				for file := range m.pending {
+					files = append(files, file)
 				}
+				m.pending = make(map[string]struct{})
+				m.mux.Unlock()
+
+				m.reloadChanged(files)
 			case <-m.ctx.Done():
 				m.watcher.Close()
 				return
@@ -215,21 +379,46 @@ func (m *ManagerSTL) startWatcher() error {
 }
 
 /*
-*giup nap lai config khong can khoi dong dua cho server phuong thuc thay vi ca doi tuong
+*giup nap lai config khong can khoi dong dua cho server phuong thuc thay vi ca doi tuong.
+*Chung chi duoc chon theo SNI cua tung ClientHello
  */
 func (m *ManagerSTL) GetTLSConfig() *tls.Config {
 	return &tls.Config{
 		GetConfigForClient: func(chi *tls.ClientHelloInfo) (*tls.Config, error) {
+			// khong giu lock khi cho ACME cap chung chi
 			m.mux.RLock()
-			defer m.mux.RUnlock()
+			base, store := m.config, m.store
+			m.mux.RUnlock()
 
-			cfg := m.config.Clone()
+			if base == nil {
+				return nil, ErrNoCertificate
+			}
+			cfg := base.Clone()
 
-			//tranh gap loi
-			if m.rootCAs != nil {
-				cfg.ClientCAs = m.rootCAs
-				cfg.ClientAuth = m.clientAuth
+			// TLS-ALPN-01: ACME server ket noi voi proto acme-tls/1 de lay cert xac thuc
+			if m.acme != nil && isACMEChallenge(chi) {
+				cert, err := m.acme.GetCertificate(chi)
+				if err != nil {
+					return nil, err
+				}
+				cfg.Certificates = []tls.Certificate{*cert}
+				cfg.NextProtos = []string{acme.ALPNProto}
+				cfg.ClientAuth = tls.NoClientCert
+				return cfg, nil
+			}
+
+			cert, isACME, err := m.acmeCertificate(chi)
+			if err != nil {
+				// chua cap duoc thi dung cert tinh neu co
+				m.logger.Warn("ACME certificate unavailable", "server_name", chi.ServerName, "error", err)
+			}
+			if !isACME || err != nil {
+				cert = store.Select(chi.ServerName)
+			}
+			if cert == nil {
+				return nil, ErrNoCertificate
 			}
+			cfg.Certificates = []tls.Certificate{*cert}
 
 			return cfg, nil
 		},
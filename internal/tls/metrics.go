@@ -0,0 +1,31 @@
+package tls
+
+import (
+	"strings"
+	"time"
+
+	"github.com/nhutphuongasasa/loadbalancer/internal/metric"
+)
+
+/*
+*Metric het han cua cac chung chi dang phuc vu, dung de dat alert ben ngoai
+ */
+func (m *ManagerSTL) Metrics() []*metric.Family {
+	notAfter := metric.NewGauge("lb_tls_certificate_not_after_timestamp_seconds", "Expiry time of a served TLS certificate as a Unix timestamp.")
+	remaining := metric.NewGauge("lb_tls_certificate_expires_in_seconds", "Seconds until a served TLS certificate expires, negative once expired.")
+
+	now := time.Now()
+	for _, info := range m.certificateInfos(now) {
+		labels := []string{
+			"source", info.Source,
+			"subject", info.Subject,
+			"issuer", info.Issuer,
+			"names", strings.Join(info.DNSNames, ","),
+			"fingerprint", info.FingerprintSHA256,
+		}
+		notAfter.Add(float64(info.NotAfter.Unix()), labels...)
+		remaining.Add(info.NotAfter.Sub(now).Seconds(), labels...)
+	}
+
+	return []*metric.Family{notAfter, remaining}
+}
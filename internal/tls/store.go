@@ -0,0 +1,268 @@
+package tls
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+/*
+*1 cap cert/key, Default danh dau cert dung khi SNI khong khop domain nao
+ */
+type CertPair struct {
+	CertFile string
+	KeyFile  string
+	Default  bool
+}
+
+type storedCert struct {
+	pair  CertPair
+	cert  *tls.Certificate
+	leaf  *x509.Certificate
+	names []string // SAN da chuan hoa chu thuong, co the co *.domain
+}
+
+/*
+*Kho chung chi chon theo SNI: index theo SAN (ke ca wildcard), moi cap duoc nap lai rieng.
+*Doc nhieu hon ghi nen dung RWMutex, index duoc dung lai sau moi lan thay doi
+ */
+type CertStore struct {
+	mu       sync.RWMutex
+	certs    map[string]*storedCert // cert file -> cert
+	exact    map[string]*storedCert
+	wildcard map[string]*storedCert // "example.com" cho "*.example.com"
+	fallback *storedCert
+}
+
+func NewCertStore() *CertStore {
+	return &CertStore{
+		certs:    make(map[string]*storedCert),
+		exact:    make(map[string]*storedCert),
+		wildcard: make(map[string]*storedCert),
+	}
+}
+
+/*
+*LoadX509KeyPair da tu choi cert khong khop key, o day tu choi them cert da het han
+*de khong bao gio thay cert dang phuc vu bang 1 cert hong
+ */
+func loadPair(p CertPair) (*storedCert, error) {
+	cert, err := tls.LoadX509KeyPair(p.CertFile, p.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	leaf := cert.Leaf
+	if leaf == nil {
+		if leaf, err = x509.ParseCertificate(cert.Certificate[0]); err != nil {
+			return nil, fmt.Errorf("parse %s: %w", p.CertFile, err)
+		}
+		cert.Leaf = leaf
+	}
+
+	if time.Now().After(leaf.NotAfter) {
+		return nil, fmt.Errorf("%w: %s expired at %s", ErrCertificateExpired, p.CertFile, leaf.NotAfter.Format(time.RFC3339))
+	}
+
+	names := make([]string, 0, len(leaf.DNSNames)+1)
+	for _, name := range leaf.DNSNames {
+		names = append(names, normalizeName(name))
+	}
+	// cert cu chi co CN, khong co SAN
+	if len(names) == 0 && leaf.Subject.CommonName != "" {
+		names = append(names, normalizeName(leaf.Subject.CommonName))
+	}
+
+	return &storedCert{pair: p, cert: &cert, leaf: leaf, names: names}, nil
+}
+
+/*
+*Nap (hoac nap lai) 1 cap, loi thi giu ban dang phuc vu
+ */
+func (s *CertStore) Load(p CertPair) error {
+	c, err := loadPair(p)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.certs[p.CertFile] = c
+	s.rebuildLocked()
+	return nil
+}
+
+func (s *CertStore) Remove(certFile string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.certs[certFile]; !ok {
+		return false
+	}
+	delete(s.certs, certFile)
+	s.rebuildLocked()
+	return true
+}
+
+/*
+*Tim cap co chua file (cert hoac key), dung khi watcher bao file thay doi
+ */
+func (s *CertStore) PairOf(file string) (CertPair, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, c := range s.certs {
+		if c.pair.CertFile == file || c.pair.KeyFile == file {
+			return c.pair, true
+		}
+	}
+	return CertPair{}, false
+}
+
+func (s *CertStore) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.certs)
+}
+
+/*
+*Chon cert theo ServerName: khop chinh xac, roi wildcard 1 cap, cuoi cung la cert mac dinh
+ */
+func (s *CertStore) Select(serverName string) *tls.Certificate {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if c := s.matchLocked(normalizeName(serverName)); c != nil {
+		return c.cert
+	}
+	if s.fallback != nil {
+		return s.fallback.cert
+	}
+	return nil
+}
+
+func (s *CertStore) matchLocked(name string) *storedCert {
+	if name == "" {
+		return nil
+	}
+	if c, ok := s.exact[name]; ok {
+		return c
+	}
+	// wildcard chi thay 1 label: *.example.com khop a.example.com, khong khop a.b.example.com
+	if i := strings.IndexByte(name, '.'); i > 0 {
+		if c, ok := s.wildcard[name[i+1:]]; ok {
+			return c
+		}
+	}
+	return nil
+}
+
+func (s *CertStore) rebuildLocked() {
+	s.exact = make(map[string]*storedCert)
+	s.wildcard = make(map[string]*storedCert)
+	s.fallback = nil
+
+	for _, c := range s.sortedLocked() {
+		for _, name := range c.names {
+			index, key := s.exact, name
+			if rest, ok := strings.CutPrefix(name, "*."); ok {
+				index, key = s.wildcard, rest
+			}
+
+			// 2 cert cung domain (dang xoay vong) thi uu tien cert het han sau
+			if prev, ok := index[key]; !ok || c.leaf.NotAfter.After(prev.leaf.NotAfter) {
+				index[key] = c
+			}
+		}
+
+		if s.fallback == nil || (c.pair.Default && !s.fallback.pair.Default) {
+			s.fallback = c
+		}
+	}
+}
+
+func (s *CertStore) sortedLocked() []*storedCert {
+	out := make([]*storedCert, 0, len(s.certs))
+	for _, c := range s.certs {
+		out = append(out, c)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].pair.CertFile < out[j].pair.CertFile })
+	return out
+}
+
+func (s *CertStore) snapshot() []*storedCert {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.sortedLocked()
+}
+
+func (s *CertStore) isDefault(c *storedCert) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.fallback == c
+}
+
+func normalizeName(name string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(name)), ".")
+}
+
+/*
+*Tim cac cap cert/key trong thu muc theo quy uoc ten:
+*cert.pem + key.pem (cap mac dinh), <ten>.crt + <ten>.key, <ten>.pem + <ten>-key.pem hoac <ten>.key
+ */
+func DiscoverPairs(dir string) ([]CertPair, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var pairs []CertPair
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		if p, ok := pairFor(filepath.Join(dir, e.Name())); ok {
+			pairs = append(pairs, p)
+		}
+	}
+	return pairs, nil
+}
+
+/*
+*Suy ra cap tu ten file cert, chi nhan khi file key tuong ung ton tai
+ */
+func pairFor(certFile string) (CertPair, bool) {
+	dir, name := filepath.Split(certFile)
+
+	var keys []string
+	isDefault := false
+	switch {
+	case name == "cert.pem":
+		keys = []string{"key.pem"}
+		isDefault = true
+	case name == "key.pem" || strings.HasSuffix(name, "-key.pem") || strings.HasSuffix(name, ".key.pem"):
+		return CertPair{}, false
+	case strings.HasSuffix(name, ".crt"):
+		keys = []string{strings.TrimSuffix(name, ".crt") + ".key"}
+	case strings.HasSuffix(name, ".pem"):
+		base := strings.TrimSuffix(name, ".pem")
+		keys = []string{base + "-key.pem", base + ".key"}
+	default:
+		return CertPair{}, false
+	}
+
+	for _, key := range keys {
+		keyFile := filepath.Join(dir, key)
+		if _, err := os.Stat(keyFile); err == nil {
+			return CertPair{CertFile: certFile, KeyFile: keyFile, Default: isDefault}, true
+		}
+	}
+	return CertPair{}, false
+}
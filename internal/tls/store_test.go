@@ -0,0 +1,94 @@
+package tls
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writePair(t *testing.T, certFile, keyFile string, names ...string) {
+	t.Helper()
+	writePairExpiring(t, certFile, keyFile, time.Now().Add(24*time.Hour), names...)
+}
+
+func writePairExpiring(t *testing.T, certFile, keyFile string, notAfter time.Time, names ...string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: names[0]},
+		DNSNames:     names,
+		NotBefore:    notAfter.Add(-90 * 24 * time.Hour),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600))
+}
+
+func leafName(t *testing.T, s *CertStore, serverName string) string {
+	t.Helper()
+	cert := s.Select(serverName)
+	require.NotNil(t, cert)
+	return cert.Leaf.Subject.CommonName
+}
+
+func TestCertStore_SelectBySNI(t *testing.T) {
+	dir := t.TempDir()
+	writePair(t, filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem"), "default.local")
+	writePair(t, filepath.Join(dir, "api.crt"), filepath.Join(dir, "api.key"), "api.example.com")
+	writePair(t, filepath.Join(dir, "wild.pem"), filepath.Join(dir, "wild-key.pem"), "*.example.com")
+	// cert khong co key thi bi bo qua
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "ca.pem"), []byte("x"), 0644))
+
+	pairs, err := DiscoverPairs(dir)
+	require.NoError(t, err)
+	require.Len(t, pairs, 3)
+
+	s := NewCertStore()
+	for _, p := range pairs {
+		require.NoError(t, s.Load(p))
+	}
+
+	assert.Equal(t, "api.example.com", leafName(t, s, "API.example.com."))
+	assert.Equal(t, "*.example.com", leafName(t, s, "www.example.com"))
+	// wildcard chi khop 1 label
+	assert.Equal(t, "default.local", leafName(t, s, "a.b.example.com"))
+	assert.Equal(t, "default.local", leafName(t, s, ""))
+}
+
+func TestCertStore_ReloadKeepsPreviousOnError(t *testing.T) {
+	dir := t.TempDir()
+	pair := CertPair{CertFile: filepath.Join(dir, "site.crt"), KeyFile: filepath.Join(dir, "site.key")}
+	writePair(t, pair.CertFile, pair.KeyFile, "site.example.com")
+
+	s := NewCertStore()
+	require.NoError(t, s.Load(pair))
+
+	require.NoError(t, os.WriteFile(pair.CertFile, []byte("broken"), 0644))
+	require.Error(t, s.Load(pair))
+	assert.Equal(t, "site.example.com", leafName(t, s, "site.example.com"))
+
+	writePair(t, pair.CertFile, pair.KeyFile, "site.example.com", "www.site.example.com")
+	require.NoError(t, s.Load(pair))
+	assert.Equal(t, "site.example.com", leafName(t, s, "www.site.example.com"))
+}
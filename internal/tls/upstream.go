@@ -0,0 +1,176 @@
+package tls
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const pinPrefix = "sha256/"
+
+var ErrPinMismatch = errors.New("upstream certificate does not match any pinned key")
+
+/*
+*TLS cho ket noi toi backend: CA rieng, SNI, client cert cho mTLS va pin public key
+ */
+type UpstreamOptions struct {
+	ServerName         string
+	CAFile             string
+	CAPEM              []byte // CA gui kem luc dang ky, cong them vao CAFile
+	CertFile           string
+	KeyFile            string
+	InsecureSkipVerify bool     // chi nen dung cung Pins cho backend tu ky
+	Pins               []string // base64 SHA-256 cua SPKI, co the co tien to sha256/
+}
+
+func UpstreamClientConfig(o UpstreamOptions) (*tls.Config, error) {
+	cfg := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         o.ServerName,
+		InsecureSkipVerify: o.InsecureSkipVerify,
+	}
+
+	if o.CAFile != "" || len(o.CAPEM) > 0 {
+		pool := x509.NewCertPool()
+		if o.CAFile != "" {
+			data, err := os.ReadFile(o.CAFile)
+			if err != nil {
+				return nil, fmt.Errorf("read CA file: %w", err)
+			}
+			if !pool.AppendCertsFromPEM(data) {
+				return nil, fmt.Errorf("no certificate found in CA file %s", o.CAFile)
+			}
+		}
+		if len(o.CAPEM) > 0 && !pool.AppendCertsFromPEM(o.CAPEM) {
+			return nil, errors.New("no certificate found in CA PEM")
+		}
+		cfg.RootCAs = pool
+	}
+
+	if o.CertFile != "" || o.KeyFile != "" {
+		if o.CertFile == "" || o.KeyFile == "" {
+			return nil, errors.New("client certificate needs both cert and key file")
+		}
+		loader := &clientCertLoader{certFile: o.CertFile, keyFile: o.KeyFile}
+		if _, err := loader.get(nil); err != nil {
+			return nil, fmt.Errorf("load client certificate: %w", err)
+		}
+		cfg.GetClientCertificate = loader.get
+	}
+
+	if len(o.Pins) > 0 {
+		pins, err := ParsePins(o.Pins)
+		if err != nil {
+			return nil, err
+		}
+		cfg.VerifyConnection = func(cs tls.ConnectionState) error {
+			return verifyPins(cs, pins)
+		}
+	}
+
+	return cfg, nil
+}
+
+/*
+*Pin cua 1 cert theo dinh dang sha256/<base64>, dung de lay gia tri cau hinh
+ */
+func SPKIPin(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return pinPrefix + base64.StdEncoding.EncodeToString(sum[:])
+}
+
+func ParsePins(values []string) ([][]byte, error) {
+	pins := make([][]byte, 0, len(values))
+	for _, v := range values {
+		raw, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(strings.TrimSpace(v), pinPrefix))
+		if err != nil || len(raw) != sha256.Size {
+			return nil, fmt.Errorf("invalid pin %q: expected base64 SHA-256 of the public key", v)
+		}
+		pins = append(pins, raw)
+	}
+	return pins, nil
+}
+
+/*
+*Chuoi da verify (leaf hoac CA trung gian/goc) khop 1 pin thi chap nhan.
+*Khong verify (insecure_skip_verify) thi chi tin leaf: cac cert con lai do backend tu gui,
+*ke tan cong co the gan them cert da pin (cong khai) vao sau leaf cua minh
+ */
+func verifyPins(cs tls.ConnectionState, pins [][]byte) error {
+	if len(cs.VerifiedChains) == 0 {
+		if len(cs.PeerCertificates) > 0 && matchPin(cs.PeerCertificates[0], pins) {
+			return nil
+		}
+		return ErrPinMismatch
+	}
+
+	for _, chain := range cs.VerifiedChains {
+		for _, cert := range chain {
+			if matchPin(cert, pins) {
+				return nil
+			}
+		}
+	}
+	return ErrPinMismatch
+}
+
+func matchPin(cert *x509.Certificate, pins [][]byte) bool {
+	sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	for _, pin := range pins {
+		if bytes.Equal(sum[:], pin) {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+*Nap lai client cert khi file doi (so mtime moi lan handshake), loi thi giu cert cu
+ */
+type clientCertLoader struct {
+	certFile string
+	keyFile  string
+
+	mu      sync.Mutex
+	cert    *tls.Certificate
+	modTime time.Time
+}
+
+func (l *clientCertLoader) get(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	modTime := latestModTime(l.certFile, l.keyFile)
+	if l.cert != nil && !modTime.After(l.modTime) {
+		return l.cert, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(l.certFile, l.keyFile)
+	if err != nil {
+		if l.cert != nil {
+			return l.cert, nil
+		}
+		return nil, err
+	}
+
+	l.cert, l.modTime = &cert, modTime
+	return l.cert, nil
+}
+
+func latestModTime(files ...string) time.Time {
+	var latest time.Time
+	for _, f := range files {
+		if info, err := os.Stat(f); err == nil && info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest
+}
@@ -0,0 +1,147 @@
+package tls
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+/*
+*Backend HTTPS yeu cau client cert ky boi clientCA, tra ve CN cua client
+ */
+func newMTLSBackend(t *testing.T, dir string) (*httptest.Server, *x509.Certificate) {
+	t.Helper()
+
+	serverCert, serverKey := filepath.Join(dir, "backend.crt"), filepath.Join(dir, "backend.key")
+	writePair(t, serverCert, serverKey, "backend.internal")
+	writePair(t, filepath.Join(dir, "client.crt"), filepath.Join(dir, "client.key"), "lb-client")
+
+	pair, err := tls.LoadX509KeyPair(serverCert, serverKey)
+	require.NoError(t, err)
+	clientCAs, err := LoadCAPool(filepath.Join(dir, "client.crt"))
+	require.NoError(t, err)
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.TLS.PeerCertificates[0].Subject.CommonName))
+	}))
+	srv.TLS = &tls.Config{
+		Certificates: []tls.Certificate{pair},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    clientCAs,
+	}
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+
+	return srv, pair.Leaf
+}
+
+func get(t *testing.T, cfg *tls.Config, url string) (string, error) {
+	t.Helper()
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: cfg}}
+	defer client.CloseIdleConnections()
+
+	resp, err := client.Get(url)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	return string(body), err
+}
+
+func TestUpstreamClientConfig_MTLSWithCustomCA(t *testing.T) {
+	dir := t.TempDir()
+	backend, _ := newMTLSBackend(t, dir)
+
+	cfg, err := UpstreamClientConfig(UpstreamOptions{
+		ServerName: "backend.internal",
+		CAFile:     filepath.Join(dir, "backend.crt"),
+		CertFile:   filepath.Join(dir, "client.crt"),
+		KeyFile:    filepath.Join(dir, "client.key"),
+	})
+	require.NoError(t, err)
+
+	body, err := get(t, cfg, backend.URL)
+	require.NoError(t, err)
+	assert.Equal(t, "lb-client", body)
+
+	// sai SNI thi verify ten that bai
+	cfg.ServerName = "other.internal"
+	_, err = get(t, cfg, backend.URL)
+	assert.Error(t, err)
+}
+
+func TestUpstreamClientConfig_Pinning(t *testing.T) {
+	dir := t.TempDir()
+	backend, leaf := newMTLSBackend(t, dir)
+
+	opts := UpstreamOptions{
+		CertFile:           filepath.Join(dir, "client.crt"),
+		KeyFile:            filepath.Join(dir, "client.key"),
+		InsecureSkipVerify: true,
+		Pins:               []string{SPKIPin(leaf)},
+	}
+	cfg, err := UpstreamClientConfig(opts)
+	require.NoError(t, err)
+	_, err = get(t, cfg, backend.URL)
+	require.NoError(t, err)
+
+	writePair(t, filepath.Join(dir, "other.crt"), filepath.Join(dir, "other.key"), "other")
+	other, err := tls.LoadX509KeyPair(filepath.Join(dir, "other.crt"), filepath.Join(dir, "other.key"))
+	require.NoError(t, err)
+
+	opts.Pins = []string{SPKIPin(other.Leaf)}
+	cfg, err = UpstreamClientConfig(opts)
+	require.NoError(t, err)
+	_, err = get(t, cfg, backend.URL)
+	assert.ErrorIs(t, err, ErrPinMismatch)
+
+	// chuoi da verify bang CA: pin leaf van khop
+	cfg, err = UpstreamClientConfig(UpstreamOptions{
+		ServerName: "backend.internal",
+		CAFile:     filepath.Join(dir, "backend.crt"),
+		CertFile:   filepath.Join(dir, "client.crt"),
+		KeyFile:    filepath.Join(dir, "client.key"),
+		Pins:       []string{SPKIPin(leaf)},
+	})
+	require.NoError(t, err)
+	_, err = get(t, cfg, backend.URL)
+	require.NoError(t, err)
+
+	_, err = UpstreamClientConfig(UpstreamOptions{Pins: []string{"sha256/short"}})
+	assert.Error(t, err)
+}
+
+func TestUpstreamClientConfig_PinIgnoresAppendedCertificate(t *testing.T) {
+	dir := t.TempDir()
+	writePair(t, filepath.Join(dir, "pinned.crt"), filepath.Join(dir, "pinned.key"), "backend.internal")
+	writePair(t, filepath.Join(dir, "mitm.crt"), filepath.Join(dir, "mitm.key"), "backend.internal")
+
+	pinned, err := tls.LoadX509KeyPair(filepath.Join(dir, "pinned.crt"), filepath.Join(dir, "pinned.key"))
+	require.NoError(t, err)
+	mitm, err := tls.LoadX509KeyPair(filepath.Join(dir, "mitm.crt"), filepath.Join(dir, "mitm.key"))
+	require.NoError(t, err)
+
+	// leaf khong duoc pin, cert da pin chi duoc gan them vao cuoi chuoi
+	mitm.Certificate = append(mitm.Certificate, pinned.Certificate[0])
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	srv.TLS = &tls.Config{Certificates: []tls.Certificate{mitm}}
+	srv.StartTLS()
+	defer srv.Close()
+
+	cfg, err := UpstreamClientConfig(UpstreamOptions{
+		InsecureSkipVerify: true,
+		Pins:               []string{SPKIPin(pinned.Leaf)},
+	})
+	require.NoError(t, err)
+	_, err = get(t, cfg, srv.URL)
+	assert.ErrorIs(t, err, ErrPinMismatch)
+}
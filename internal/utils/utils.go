@@ -5,8 +5,6 @@ import (
 	"io"
 	"log/slog"
 	"os"
-	"path/filepath"
-	"runtime"
 	"strings"
 	"sync"
 
@@ -16,39 +14,22 @@ import (
 var (
 	once     sync.Once
 	instance *slog.Logger
+	logLevel slog.LevelVar // doi duoc luc chay khi config reload
 )
 
-func GetRootDir() string {
-	_, filename, _, ok := runtime.Caller(0)
-	if ok {
-		return filepath.Dir(filepath.Dir(filepath.Dir(filename)))
-	}
-
-	exePath, err := os.Executable()
-	if err != nil {
-		dir, _ := os.Getwd()
-		return dir
-	}
-
-	return filepath.Dir(exePath)
-}
 func GetLogger(cfg *config.ConfigManager) *slog.Logger {
 	once.Do(func() {
-		var level slog.Level
 		logCfg := cfg.GetConfig().LogConfig
-		switch strings.ToLower(logCfg.Level) {
-		case "debug":
-			level = slog.LevelDebug
-		case "warn":
-			level = slog.LevelWarn
-		case "error":
-			level = slog.LevelError
-		default:
-			level = slog.LevelInfo
+		level := ParseLogLevel("")
+		format := ""
+		if logCfg != nil {
+			level = ParseLogLevel(logCfg.Level)
+			format = logCfg.Format
 		}
+		logLevel.Set(level)
 
 		opts := &slog.HandlerOptions{
-			Level:     level,
+			Level:     &logLevel,
 			AddSource: level == slog.LevelDebug,
 		}
 
@@ -62,7 +43,7 @@ func GetLogger(cfg *config.ConfigManager) *slog.Logger {
 		}
 
 		var handler slog.Handler
-		if strings.ToLower(logCfg.Format) == "json" {
+		if strings.ToLower(format) == "json" {
 			handler = slog.NewJSONHandler(output, opts)
 		} else {
 			handler = slog.NewTextHandler(output, opts)
@@ -75,3 +56,23 @@ func GetLogger(cfg *config.ConfigManager) *slog.Logger {
 
 	return instance
 }
+
+func ParseLogLevel(level string) slog.Level {
+	switch strings.ToLower(level) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+/*
+*Doi log level cua logger dang dung ma khong can tao lai logger
+ */
+func SetLogLevel(level string) {
+	logLevel.Set(ParseLogLevel(level))
+}